@@ -113,3 +113,70 @@ func (b *BoundingBox2D) contains(point *Vector3D.Vector3D) bool {
 	}
 	return true
 }
+
+// BoundingBoxRayIntersection2 is the result of a ray/box intersection query.
+type BoundingBoxRayIntersection2 struct {
+
+	// True if the box and the ray intersect.
+	isIntersecting bool
+
+	// Distance to the first intersection point.
+	tNear float64
+
+	// Distance to the second (and the last) intersection point.
+	tFar float64
+}
+
+// overlaps returns true if this box and other box overlap.
+func (b *BoundingBox2D) overlaps(other *BoundingBox2D) bool {
+
+	if b.upperCorner.X < other.lowerCorner.X || b.lowerCorner.X > other.upperCorner.X {
+		return false
+	}
+	if b.upperCorner.Y < other.lowerCorner.Y || b.lowerCorner.Y > other.upperCorner.Y {
+		return false
+	}
+	return true
+}
+
+// distanceSquaredTo returns the squared distance from the given point to the
+// box, which is zero for points inside the box.
+func (b *BoundingBox2D) distanceSquaredTo(point *Vector3D.Vector3D) float64 {
+
+	dx := math.Max(0, math.Max(b.lowerCorner.X-point.X, point.X-b.upperCorner.X))
+	dy := math.Max(0, math.Max(b.lowerCorner.Y-point.Y, point.Y-b.upperCorner.Y))
+	return dx*dx + dy*dy
+}
+
+// intersects returns true if the given ray intersects this box.
+func (b *BoundingBox2D) intersects(ray *Ray2) bool {
+
+	isIntersecting, _, tMax := intersectRayWithSlabs(b.lowerCorner, b.upperCorner, ray.origin, ray.direction, 2)
+	return isIntersecting && tMax >= 0
+}
+
+// closestIntersection returns the intersection of the given ray with this box.
+// If the ray starts inside the box, tNear is the distance to the exit point
+// and tFar is math.MaxFloat64.
+func (b *BoundingBox2D) closestIntersection(ray *Ray2) *BoundingBoxRayIntersection2 {
+
+	intersection := &BoundingBoxRayIntersection2{
+		isIntersecting: false,
+		tNear:          math.MaxFloat64,
+		tFar:           math.MaxFloat64,
+	}
+
+	isIntersecting, tMin, tMax := intersectRayWithSlabs(b.lowerCorner, b.upperCorner, ray.origin, ray.direction, 2)
+	if !isIntersecting || tMax < 0 {
+		return intersection
+	}
+
+	intersection.isIntersecting = true
+	if tMin >= 0 {
+		intersection.tNear = tMin
+		intersection.tFar = tMax
+	} else {
+		intersection.tNear = tMax
+	}
+	return intersection
+}
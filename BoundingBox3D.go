@@ -129,3 +129,111 @@ func (b *BoundingBox3D) merge(other *BoundingBox3D) {
 	b.upperCorner.Y = math.Max(b.upperCorner.Y, other.upperCorner.Y)
 	b.upperCorner.Z = math.Max(b.upperCorner.Z, other.upperCorner.Z)
 }
+
+// BoundingBoxRayIntersection3 is the result of a ray/box intersection query.
+type BoundingBoxRayIntersection3 struct {
+
+	// True if the box and the ray intersect.
+	isIntersecting bool
+
+	// Distance to the first intersection point.
+	tNear float64
+
+	// Distance to the second (and the last) intersection point.
+	tFar float64
+}
+
+// overlaps returns true if this box and other box overlap.
+func (b *BoundingBox3D) overlaps(other *BoundingBox3D) bool {
+
+	if b.upperCorner.X < other.lowerCorner.X || b.lowerCorner.X > other.upperCorner.X {
+		return false
+	}
+	if b.upperCorner.Y < other.lowerCorner.Y || b.lowerCorner.Y > other.upperCorner.Y {
+		return false
+	}
+	if b.upperCorner.Z < other.lowerCorner.Z || b.lowerCorner.Z > other.upperCorner.Z {
+		return false
+	}
+	return true
+}
+
+// distanceSquaredTo returns the squared distance from the given point to the
+// box, which is zero for points inside the box.
+func (b *BoundingBox3D) distanceSquaredTo(point *Vector3D.Vector3D) float64 {
+
+	dx := math.Max(0, math.Max(b.lowerCorner.X-point.X, point.X-b.upperCorner.X))
+	dy := math.Max(0, math.Max(b.lowerCorner.Y-point.Y, point.Y-b.upperCorner.Y))
+	dz := math.Max(0, math.Max(b.lowerCorner.Z-point.Z, point.Z-b.upperCorner.Z))
+	return dx*dx + dy*dy + dz*dz
+}
+
+// intersects returns true if the given ray intersects this box.
+func (b *BoundingBox3D) intersects(ray *Ray3) bool {
+
+	isIntersecting, _, tMax := intersectRayWithSlabs(b.lowerCorner, b.upperCorner, ray.origin, ray.direction, 3)
+	return isIntersecting && tMax >= 0
+}
+
+// closestIntersection returns the intersection of the given ray with this box.
+// If the ray starts inside the box, tNear is the distance to the exit point
+// and tFar is math.MaxFloat64.
+func (b *BoundingBox3D) closestIntersection(ray *Ray3) *BoundingBoxRayIntersection3 {
+
+	intersection := &BoundingBoxRayIntersection3{
+		isIntersecting: false,
+		tNear:          math.MaxFloat64,
+		tFar:           math.MaxFloat64,
+	}
+
+	isIntersecting, tMin, tMax := intersectRayWithSlabs(b.lowerCorner, b.upperCorner, ray.origin, ray.direction, 3)
+	if !isIntersecting || tMax < 0 {
+		return intersection
+	}
+
+	intersection.isIntersecting = true
+	if tMin >= 0 {
+		intersection.tNear = tMin
+		intersection.tFar = tMax
+	} else {
+		intersection.tNear = tMax
+	}
+	return intersection
+}
+
+// intersectRayWithSlabs clips the line origin + t * direction against the
+// slabs of the box spanned by lower and upper along the first dims axes. It
+// returns false if the line misses the box, otherwise the parameters where
+// the line enters and leaves the box, which may be negative.
+func intersectRayWithSlabs(lower, upper, origin, direction *Vector3D.Vector3D, dims int) (bool, float64, float64) {
+
+	tMin := -math.MaxFloat64
+	tMax := math.MaxFloat64
+
+	for axis := 0; axis < dims; axis++ {
+
+		o := origin.At(axis)
+		d := direction.At(axis)
+
+		// A ray parallel to the slab either always or never lies inside it.
+		if d == 0 {
+			if o < lower.At(axis) || o > upper.At(axis) {
+				return false, 0, 0
+			}
+			continue
+		}
+
+		tNear := (lower.At(axis) - o) / d
+		tFar := (upper.At(axis) - o) / d
+		if tNear > tFar {
+			tNear, tFar = tFar, tNear
+		}
+		tMin = math.Max(tMin, tNear)
+		tMax = math.Min(tMax, tFar)
+
+		if tMin > tMax {
+			return false, 0, 0
+		}
+	}
+	return true, tMin, tMax
+}
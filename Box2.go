@@ -89,7 +89,7 @@ func (p *Box2) closestPoint(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
 // point.
 func (p *Box2) closestNormal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
 
-	result := p.transform.toWorldDirection(p.closestNormalLocal(otherPoint))
+	result := p.transform.toWorldDirection(p.closestNormalLocal(p.transform.toLocal(otherPoint)))
 	if p.isNormalFlipped {
 
 		//result.Multiply(-1)
@@ -123,8 +123,31 @@ func (p *Box2) closestNormalLocal(otherPoint *Vector3D.Vector3D) *Vector3D.Vecto
 			}
 		}
 		return closestNormal
+	} else {
+		closestPoint := Vector3D.NewVector(mathHelper.Clamp(
+			otherPoint.X,
+			p.bound.lowerCorner.X,
+			p.bound.upperCorner.X,
+		), mathHelper.Clamp(
+			otherPoint.Y,
+			p.bound.lowerCorner.Y,
+			p.bound.upperCorner.Y,
+		), 0)
+
+		closestPointToInputPoint := otherPoint.Substract(closestPoint)
+		closestNormal := planes[0].normal
+		maxCosineAngle := closestNormal.DotProduct(closestPointToInputPoint)
+
+		for i := 1; i < 4; i++ {
+			cosineAngle := planes[i].normal.DotProduct(closestPointToInputPoint)
+
+			if cosineAngle > maxCosineAngle {
+				closestNormal = planes[i].normal
+				maxCosineAngle = cosineAngle
+			}
+		}
+		return closestNormal
 	}
-	return nil
 }
 
 func (p *Box2) getTransform() *Transform2 {
@@ -93,7 +93,7 @@ func (p *Box3) closestDistance(otherPoint *Vector3D.Vector3D) float64 {
 // point.
 func (p *Box3) closestNormal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
 
-	result := p.transform.toWorldDirection(p.closestNormalLocal(otherPoint))
+	result := p.transform.toWorldDirection(p.closestNormalLocal(p.transform.toLocal(otherPoint)))
 	if p.isNormalFlipped {
 
 		result = result.Multiply(-1)
@@ -163,6 +163,11 @@ func (p *Box3) getTransform() *Transform3 {
 	return p.transform
 }
 
+// getIsNormalFlipped returns true if the normal is flipped.
+func (p *Box3) getIsNormalFlipped() bool {
+	return p.isNormalFlipped
+}
+
 // Returns true if otherPoint is inside the volume defined by the surface.
 func (p *Box3) isInside(otherPoint *Vector3D.Vector3D) bool {
 
@@ -178,3 +183,59 @@ func (p *Box3) isInsideLocal(otherPointLocal *Vector3D.Vector3D) bool {
 	r := otherPointLocal.Substract(cpLocal)
 	return r.DotProduct(normalLocal) < 0.0
 }
+
+// closestIntersection returns the first hit of the ray with the faces of the box.
+func (p *Box3) closestIntersection(ray *Ray3) *SurfaceRayIntersection3 {
+
+	return intersectionToWorld(p.transform, p.isNormalFlipped, p.closestIntersectionLocal(p.transform.toLocalRay(ray)))
+}
+
+// intersects returns true if the ray hits the box.
+func (p *Box3) intersects(ray *Ray3) bool {
+
+	return p.bound.intersects(p.transform.toLocalRay(ray))
+}
+
+func (p *Box3) closestIntersectionLocal(ray *Ray3) *SurfaceRayIntersection3 {
+
+	intersection := NewSurfaceRayIntersection3()
+
+	// tNear is the exit point if the ray starts inside the box.
+	bbRayIntersection := p.bound.closestIntersection(ray)
+	if !bbRayIntersection.isIntersecting {
+		return intersection
+	}
+
+	intersection.isIntersecting = true
+	intersection.distance = bbRayIntersection.tNear
+	intersection.point = ray.pointAt(bbRayIntersection.tNear)
+	intersection.normal = p.closestNormalLocal(intersection.point)
+	return intersection
+}
+
+// isBounded returns true if bounding box can be defined.
+func (p *Box3) isBounded() bool {
+
+	return true
+}
+
+// boundingBox returns the bounding box of this surface object.
+func (p *Box3) boundingBox() *BoundingBox3D {
+
+	return p.transform.toWorldBoundingBox(p.bound)
+}
+
+func (p *Box3) signedDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	otherPointLocal := p.transform.toLocal(otherPoint)
+	x := p.closestPointLocal(otherPointLocal)
+
+	sd := x.DistanceTo(otherPointLocal)
+	if p.isInsideLocal(otherPointLocal) {
+		sd = -sd
+	}
+	if p.isNormalFlipped {
+		sd = -sd
+	}
+	return sd
+}
@@ -1,5 +1,14 @@
 package main
 
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"sort"
+)
+
+// Bvh2 is a bounding volume hierarchy over 2-D implicit surfaces. The nodes
+// are stored depth-first: the first child of an internal node directly
+// follows it and node.child is the index of the second one.
 type Bvh2 struct {
 	IntersectionQueryEngine2    *IntersectionQueryEngine2
 	NearestNeighborQueryEngine2 *NearestNeighborQueryEngine2
@@ -10,7 +19,10 @@ type Bvh2 struct {
 }
 
 func NewBvh2() *Bvh2 {
-	return &Bvh2{nodes: make([]*Node2D, 0, 0)}
+	return &Bvh2{
+		bound: NewBoundingBox2DReset(),
+		nodes: make([]*Node2D, 0, 0),
+	}
 }
 
 // build the bounding volume hierarchy.
@@ -18,12 +30,12 @@ func (b *Bvh2) build(items []ImplicitSurface2, itemsBounds []*BoundingBox2D) {
 
 	b.items = items
 	b.itemBounds = itemsBounds
+	b.nodes = nil
+	b.bound = NewBoundingBox2DReset()
 
 	if len(items) == 0 {
 		return
 	}
-	b.nodes = nil
-	b.bound = NewBoundingBox2DReset()
 
 	itemsize := float64(len(b.items))
 	for i := float64(0); i < itemsize; i++ {
@@ -51,5 +63,194 @@ func (b *Bvh2) buildInternal(nodeIndex int, itemIndices []float64, nItems float6
 		return currentDepth + 1
 	}
 
-	return 0
+	// find the bounding box of the items of this node.
+	nodeBound := NewBoundingBox2DReset()
+	for i := 0; i < int(nItems); i++ {
+		nodeBound.merge(b.itemBounds[int64(itemIndices[i])])
+	}
+
+	// split along the longest axis of the node.
+	d := nodeBound.upperCorner.Substract(nodeBound.lowerCorner)
+	axis := 0
+	if d.Y > d.X {
+		axis = 1
+	}
+
+	// median split: order the items by the center of their bounds along the
+	// axis and give each child half of them.
+	items := itemIndices[:int(nItems)]
+	sort.SliceStable(items, func(i, j int) bool {
+		return b.itemBounds[int64(items[i])].midPoint().At(axis) <
+			b.itemBounds[int64(items[j])].midPoint().At(axis)
+	})
+	midPoint := int(nItems) / 2
+
+	// recursively initialize children.
+	d0 := b.buildInternal(nodeIndex+1, items[:midPoint], float64(midPoint), currentDepth+1)
+	b.nodes[nodeIndex].initInternal(axis, float64(len(b.nodes)), nodeBound)
+	d1 := b.buildInternal(len(b.nodes), items[midPoint:], nItems-float64(midPoint), currentDepth+1)
+
+	return int(math.Max(float64(d0), float64(d1)))
+}
+
+// numberOfItems returns the number of items in the hierarchy.
+func (b *Bvh2) numberOfItems() int {
+
+	return len(b.items)
+}
+
+// boundingBox returns the bounding box of all the items.
+func (b *Bvh2) boundingBox() *BoundingBox2D {
+
+	return b.bound
+}
+
+// nearest returns the item with the smallest distance to the point. Subtrees
+// whose bounds are farther than the best distance found so far are skipped,
+// but subtrees whose bounds contain the point are always visited, so the
+// distance function may return negative values inside the items.
+func (b *Bvh2) nearest(point *Vector3D.Vector3D, distanceFunc NearestNeighborDistanceFunc2) *NearestNeighborQueryResult2 {
+
+	best := &NearestNeighborQueryResult2{
+		item:     nil,
+		distance: math.MaxFloat64,
+	}
+	if len(b.nodes) == 0 {
+		return best
+	}
+	b.nearestInternal(0, point, distanceFunc, best)
+	return best
+}
+
+func (b *Bvh2) nearestInternal(nodeIndex int, point *Vector3D.Vector3D, distanceFunc NearestNeighborDistanceFunc2, best *NearestNeighborQueryResult2) {
+
+	node := b.nodes[nodeIndex]
+	if node.isLeaf() {
+		item := b.items[int64(node.item)]
+		if d := distanceFunc(item, point); d < best.distance {
+			best.item = item
+			best.distance = d
+		}
+		return
+	}
+
+	// visit the closer child first so the other one is more likely pruned.
+	children := [2]int{nodeIndex + 1, int(node.child)}
+	distances := [2]float64{
+		b.nodes[children[0]].bound.distanceSquaredTo(point),
+		b.nodes[children[1]].bound.distanceSquaredTo(point),
+	}
+	if distances[1] < distances[0] {
+		children[0], children[1] = children[1], children[0]
+		distances[0], distances[1] = distances[1], distances[0]
+	}
+
+	for i, child := range children {
+		if distances[i] == 0 || math.Sqrt(distances[i]) < best.distance {
+			b.nearestInternal(child, point, distanceFunc, best)
+		}
+	}
+}
+
+// intersectsBox returns true if any item intersects the box.
+func (b *Bvh2) intersectsBox(box *BoundingBox2D, testFunc BoxIntersectionTestFunc2) bool {
+
+	hit := false
+	b.forEachIntersectingItemBox(box, func(item ImplicitSurface2, box *BoundingBox2D) bool {
+		return !hit && testFunc(item, box)
+	}, func(item ImplicitSurface2) {
+		hit = true
+	})
+	return hit
+}
+
+// intersectsRay returns true if any item intersects the ray.
+func (b *Bvh2) intersectsRay(ray *Ray2, testFunc RayIntersectionTestFunc2) bool {
+
+	hit := false
+	b.forEachIntersectingItemRay(ray, func(item ImplicitSurface2, ray *Ray2) bool {
+		return !hit && testFunc(item, ray)
+	}, func(item ImplicitSurface2) {
+		hit = true
+	})
+	return hit
+}
+
+// forEachIntersectingItemBox visits every item intersecting the box.
+func (b *Bvh2) forEachIntersectingItemBox(box *BoundingBox2D, testFunc BoxIntersectionTestFunc2, visitorFunc IntersectionVisitorFunc2) {
+
+	b.forEachNode(func(node *Node2D) bool {
+		return node.bound.overlaps(box)
+	}, func(item ImplicitSurface2) {
+		if testFunc(item, box) {
+			visitorFunc(item)
+		}
+	})
+}
+
+// forEachIntersectingItemRay visits every item intersecting the ray.
+func (b *Bvh2) forEachIntersectingItemRay(ray *Ray2, testFunc RayIntersectionTestFunc2, visitorFunc IntersectionVisitorFunc2) {
+
+	b.forEachNode(func(node *Node2D) bool {
+		return node.bound.intersects(ray)
+	}, func(item ImplicitSurface2) {
+		if testFunc(item, ray) {
+			visitorFunc(item)
+		}
+	})
+}
+
+// closestIntersection returns the item hit first by the ray.
+func (b *Bvh2) closestIntersection(ray *Ray2, testFunc GetRayIntersectionFunc2) *ClosestIntersectionQueryResult2 {
+
+	best := &ClosestIntersectionQueryResult2{
+		item:     nil,
+		distance: math.MaxFloat64,
+	}
+
+	b.forEachNode(func(node *Node2D) bool {
+
+		// a subtree can only improve the result if the ray enters its
+		// bounds before the best hit found so far.
+		intersection := node.bound.closestIntersection(ray)
+		if !intersection.isIntersecting {
+			return false
+		}
+		tEnter := intersection.tNear
+		if node.bound.contains(ray.origin) {
+			tEnter = 0
+		}
+		return tEnter < best.distance
+	}, func(item ImplicitSurface2) {
+		if d := testFunc(item, ray); d < best.distance {
+			best.item = item
+			best.distance = d
+		}
+	})
+	return best
+}
+
+// forEachNode walks the hierarchy depth-first, descending only into nodes
+// accepted by shouldVisit, and calls visitorFunc for each accepted leaf item.
+func (b *Bvh2) forEachNode(shouldVisit func(node *Node2D) bool, visitorFunc IntersectionVisitorFunc2) {
+
+	if len(b.nodes) == 0 {
+		return
+	}
+
+	stack := []int{0}
+	for len(stack) > 0 {
+		nodeIndex := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		node := b.nodes[nodeIndex]
+		if !shouldVisit(node) {
+			continue
+		}
+		if node.isLeaf() {
+			visitorFunc(b.items[int64(node.item)])
+			continue
+		}
+		stack = append(stack, int(node.child), nodeIndex+1)
+	}
 }
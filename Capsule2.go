@@ -0,0 +1,35 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// Capsule2 is the 2-D counterpart of Capsule3: all the points within radius
+// of a segment on the y-axis of the local frame through center, which spans
+// height/2 to each side of center.
+type Capsule2 struct {
+	*surfaceOfRevolution2
+
+	// Radius of the capsule.
+	radius float64
+
+	// Length of the inner segment.
+	height float64
+}
+
+func NewCapsule2(center *Vector3D.Vector3D, radius, height float64) *Capsule2 {
+	c := &Capsule2{
+		radius: radius,
+		height: height,
+	}
+	c.surfaceOfRevolution2 = newSurfaceOfRevolution2(center, c)
+	return c
+}
+
+func (c *Capsule2) closestProfilePoint(r, y float64) *profileQueryResult {
+
+	return capsuleProfilePoint(c.radius, c.height, r, y)
+}
+
+func (c *Capsule2) profileExtent() (float64, float64, float64) {
+
+	return c.radius, -0.5*c.height - c.radius, 0.5*c.height + c.radius
+}
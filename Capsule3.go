@@ -0,0 +1,61 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"jimmykiang/fluidengine/mathHelper"
+)
+
+// Capsule3 is a 3-D capsule geometry: all the points within radius of a
+// segment. The segment lies on the y-axis of the local frame through center
+// and spans height/2 to each side of center, so the capsule is
+// height + 2*radius tall.
+type Capsule3 struct {
+	*surfaceOfRevolution3
+
+	// Radius of the capsule.
+	radius float64
+
+	// Length of the inner segment.
+	height float64
+}
+
+func NewCapsule3(center *Vector3D.Vector3D, radius, height float64) *Capsule3 {
+	c := &Capsule3{
+		radius: radius,
+		height: height,
+	}
+	c.surfaceOfRevolution3 = newSurfaceOfRevolution3(center, c)
+	return c
+}
+
+func (c *Capsule3) closestProfilePoint(r, y float64) *profileQueryResult {
+
+	return capsuleProfilePoint(c.radius, c.height, r, y)
+}
+
+func (c *Capsule3) profileExtent() (float64, float64, float64) {
+
+	return c.radius, -0.5*c.height - c.radius, 0.5*c.height + c.radius
+}
+
+// capsuleProfilePoint queries the outline of a capsule, which is offset by
+// radius from the inner segment.
+func capsuleProfilePoint(radius, height, r, y float64) *profileQueryResult {
+
+	h := 0.5 * height
+	s := mathHelper.Clamp(y, -h, h)
+
+	d := Vector3D.NewVector(r, y-s, 0)
+	length := d.Length()
+
+	normal := Vector3D.NewVector(1, 0, 0)
+	if length > 0 {
+		normal = d.Divide(length)
+	}
+
+	return &profileQueryResult{
+		point:    Vector3D.NewVector(normal.X*radius, s+normal.Y*radius, 0),
+		normal:   normal,
+		isInside: length < radius,
+	}
+}
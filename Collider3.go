@@ -0,0 +1,19 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// Collider3IF is the interface shared by 3-D colliders. Solvers update the
+// collider once per sub-timestep and then resolve every particle against it,
+// passing the position from the beginning of the sub-timestep for swept tests.
+type Collider3IF interface {
+	update(currentTime, timeIntervalInSeconds float64)
+	resolveCollision(
+		particleIndex int64,
+		mass float64,
+		radius float64,
+		restitutionCoefficient float64,
+		oldPosition *Vector3D.Vector3D,
+		newPosition **Vector3D.Vector3D,
+		newVelocity **Vector3D.Vector3D,
+	)
+}
@@ -0,0 +1,22 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// ColliderContact is a particle contact resolved by a collider during the
+// last sub-timestep.
+type ColliderContact struct {
+	// Index of the particle in its particle system.
+	particleIndex int64
+
+	// Closest point on the collider surface.
+	point *Vector3D.Vector3D
+
+	// Surface normal at the contact point.
+	normal *Vector3D.Vector3D
+
+	// Velocity of the particle relative to the surface before the response.
+	relativeVelocity *Vector3D.Vector3D
+
+	// Impulse applied to the particle. The collider receives the opposite.
+	impulse *Vector3D.Vector3D
+}
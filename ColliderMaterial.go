@@ -0,0 +1,108 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"jimmykiang/fluidengine/mathHelper"
+	"math"
+)
+
+// ColliderMaterial holds the contact properties of a collider surface.
+//
+// Friction follows Coulomb's model: a contact sticks while the tangential
+// velocity is within the static cone of the normal impulse and slides with
+// the dynamic coefficient otherwise. Restitution scales the normal and the
+// tangential relative velocity after impact separately.
+type ColliderMaterial struct {
+	staticFrictionCoefficient  float64
+	dynamicFrictionCoefficient float64
+	// Negative values fall back to the coefficient passed by the solver.
+	normalRestitutionCoefficient     float64
+	tangentialRestitutionCoefficient float64
+}
+
+// NewColliderMaterial returns a frictionless material that keeps the tangential
+// velocity and uses the solver restitution.
+func NewColliderMaterial() *ColliderMaterial {
+	return &ColliderMaterial{
+		staticFrictionCoefficient:        0,
+		dynamicFrictionCoefficient:       0,
+		normalRestitutionCoefficient:     -1,
+		tangentialRestitutionCoefficient: 1,
+	}
+}
+
+// setStaticFrictionCoefficient sets the friction coefficient below which contacts stick.
+func (m *ColliderMaterial) setStaticFrictionCoefficient(coefficient float64) {
+
+	m.staticFrictionCoefficient = math.Max(coefficient, 0)
+}
+
+// setDynamicFrictionCoefficient sets the friction coefficient of sliding contacts.
+func (m *ColliderMaterial) setDynamicFrictionCoefficient(coefficient float64) {
+
+	m.dynamicFrictionCoefficient = math.Max(coefficient, 0)
+}
+
+// setNormalRestitutionCoefficient overrides the solver restitution.
+func (m *ColliderMaterial) setNormalRestitutionCoefficient(coefficient float64) {
+
+	m.normalRestitutionCoefficient = mathHelper.Clamp(coefficient, 0, 1)
+}
+
+// setTangentialRestitutionCoefficient sets the fraction of the tangential
+// velocity kept after friction.
+func (m *ColliderMaterial) setTangentialRestitutionCoefficient(coefficient float64) {
+
+	m.tangentialRestitutionCoefficient = mathHelper.Clamp(coefficient, 0, 1)
+}
+
+// relativeVelocityAfterImpact returns the relative velocity after a contact
+// with the given normal. Velocities leaving the surface are returned unchanged.
+func (m *ColliderMaterial) relativeVelocityAfterImpact(
+	relativeVel *Vector3D.Vector3D,
+	normal *Vector3D.Vector3D,
+	restitutionCoefficient float64,
+) *Vector3D.Vector3D {
+
+	normalDotRelativeVel := normal.DotProduct(relativeVel)
+	if normalDotRelativeVel >= 0.0 {
+		return relativeVel
+	}
+
+	if m.normalRestitutionCoefficient >= 0 {
+		restitutionCoefficient = m.normalRestitutionCoefficient
+	}
+
+	relativeVelN := normal.Multiply(normalDotRelativeVel)
+	relativeVelT := relativeVel.Substract(relativeVelN)
+
+	// Apply restitution coefficient to the surface normal component of the velocity.
+	deltaRelativeVelN := relativeVelN.Multiply(-restitutionCoefficient - 1.0).Length()
+	relativeVelN = relativeVelN.Multiply(-restitutionCoefficient)
+
+	// Apply friction to the tangential component of the velocity From Bridson et
+	// al., Robust Treatment of Collisions, Contact and Friction for Cloth Animation,
+	// 2002. http://graphics.stanford.edu/papers/cloth-sig02/cloth.pdf
+	if speedT := relativeVelT.Length(); speedT > 0.0 {
+		if speedT <= m.staticFrictionCoefficient*deltaRelativeVelN {
+			relativeVelT = Vector3D.NewVector(0, 0, 0)
+		} else {
+			frictionScale := math.Max(1-m.dynamicFrictionCoefficient*deltaRelativeVelN/speedT, 0)
+			relativeVelT = relativeVelT.Multiply(frictionScale * m.tangentialRestitutionCoefficient)
+		}
+	}
+
+	return relativeVelN.Add(relativeVelT)
+}
+
+// surfaceMaterial3 assigns a material to a part of a 3-D collider surface.
+type surfaceMaterial3 struct {
+	surface  ImplicitSurface3
+	material *ColliderMaterial
+}
+
+// surfaceMaterial2 assigns a material to a part of a 2-D collider surface.
+type surfaceMaterial2 struct {
+	surface  ImplicitSurface2
+	material *ColliderMaterial
+}
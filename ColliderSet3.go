@@ -0,0 +1,102 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
+
+// ColliderSet3 is a collection of 3-D rigid body colliders. Each collider keeps
+// its own surface, friction, restitution and motion. A particle is resolved
+// against the nearest surface it penetrates.
+type ColliderSet3 struct {
+	colliders []*RigidBodyCollider3
+}
+
+func NewColliderSet3(colliders ...*RigidBodyCollider3) *ColliderSet3 {
+	c := &ColliderSet3{
+		colliders: make([]*RigidBodyCollider3, 0, len(colliders)),
+	}
+	c.colliders = append(c.colliders, colliders...)
+	return c
+}
+
+// addCollider adds a collider to the set.
+func (c *ColliderSet3) addCollider(collider *RigidBodyCollider3) {
+
+	c.colliders = append(c.colliders, collider)
+}
+
+// numberOfColliders returns the number of colliders in the set.
+func (c *ColliderSet3) numberOfColliders() int {
+
+	return len(c.colliders)
+}
+
+// collider returns the i-th collider.
+func (c *ColliderSet3) collider(i int) *RigidBodyCollider3 {
+
+	return c.colliders[i]
+}
+
+// update updates every collider in the set.
+func (c *ColliderSet3) update(currentTime, timeIntervalInSeconds float64) {
+
+	for _, collider := range c.colliders {
+		collider.update(currentTime, timeIntervalInSeconds)
+	}
+}
+
+// Resolves collision for given point. The earliest impact along the path from
+// oldPosition wins; otherwise the point is resolved against the most
+// penetrated surface. Surfaces the point is inside of rank by their negated
+// distance, so they come before surfaces that are only within the radius.
+func (c *ColliderSet3) resolveCollision(
+	particleIndex int64,
+	mass float64,
+	radius float64,
+	restitutionCoefficient float64,
+	oldPosition *Vector3D.Vector3D,
+	newPosition **Vector3D.Vector3D,
+	newVelocity **Vector3D.Vector3D,
+) {
+
+	earliestImpact := math.MaxFloat64
+	var impactPoint *Vector3D.Vector3D
+	for _, collider := range c.colliders {
+		if point, ok := collider.sweptImpactPoint(oldPosition, *newPosition, radius); ok {
+			if d := point.DistanceTo(oldPosition); d < earliestImpact {
+				earliestImpact = d
+				impactPoint = point
+			}
+		}
+	}
+	if impactPoint != nil {
+		(*newPosition).Set(impactPoint)
+	}
+
+	var nearest *RigidBodyCollider3
+	var nearestPoint *ColliderQueryResult
+	minDistance := math.MaxFloat64
+
+	for _, collider := range c.colliders {
+		colliderPoint := collider.NewColliderQueryResult()
+		collider.getClosestPoint(collider.surface, *newPosition, colliderPoint)
+
+		if !collider.isPenetrating(colliderPoint, *newPosition, radius) {
+			continue
+		}
+		signedDistance := colliderPoint.distance
+		if collider.surface.isInside(*newPosition) {
+			signedDistance = -signedDistance
+		}
+		if signedDistance < minDistance {
+			nearest = collider
+			nearestPoint = colliderPoint
+			minDistance = signedDistance
+		}
+	}
+
+	if nearest != nil {
+		nearest.resolveCollisionWithQuery(particleIndex, mass, nearestPoint, radius, restitutionCoefficient, newPosition, newVelocity)
+	}
+}
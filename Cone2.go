@@ -0,0 +1,35 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// Cone2 is the 2-D counterpart of Cone3: the isosceles triangle cut from the
+// cone through its axis. The base is at height/2 below center and the apex
+// at height/2 above it.
+type Cone2 struct {
+	*surfaceOfRevolution2
+
+	// Radius of the base.
+	radius float64
+
+	// Height of the cone.
+	height float64
+}
+
+func NewCone2(center *Vector3D.Vector3D, radius, height float64) *Cone2 {
+	c := &Cone2{
+		radius: radius,
+		height: height,
+	}
+	c.surfaceOfRevolution2 = newSurfaceOfRevolution2(center, c)
+	return c
+}
+
+func (c *Cone2) closestProfilePoint(r, y float64) *profileQueryResult {
+
+	return coneProfilePoint(c.radius, c.height, r, y)
+}
+
+func (c *Cone2) profileExtent() (float64, float64, float64) {
+
+	return c.radius, -0.5 * c.height, 0.5 * c.height
+}
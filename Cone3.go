@@ -0,0 +1,71 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+)
+
+// Cone3 is a 3-D solid cone geometry.
+// The axis of the cone is the y-axis of its local frame through center. The
+// base disk is at height/2 below center and the apex at height/2 above it.
+type Cone3 struct {
+	*surfaceOfRevolution3
+
+	// Radius of the base.
+	radius float64
+
+	// Height of the cone.
+	height float64
+}
+
+func NewCone3(center *Vector3D.Vector3D, radius, height float64) *Cone3 {
+	c := &Cone3{
+		radius: radius,
+		height: height,
+	}
+	c.surfaceOfRevolution3 = newSurfaceOfRevolution3(center, c)
+	return c
+}
+
+func (c *Cone3) closestProfilePoint(r, y float64) *profileQueryResult {
+
+	return coneProfilePoint(c.radius, c.height, r, y)
+}
+
+func (c *Cone3) profileExtent() (float64, float64, float64) {
+
+	return c.radius, -0.5 * c.height, 0.5 * c.height
+}
+
+// coneProfilePoint queries the triangular outline of a cone: the base from
+// the axis to (radius, -height/2) and the slant up to the apex (0, height/2).
+func coneProfilePoint(radius, height, r, y float64) *profileQueryResult {
+
+	h := 0.5 * height
+	p := Vector3D.NewVector(r, y, 0)
+
+	baseCorner := Vector3D.NewVector(radius, -h, 0)
+	basePoint := closestPointOnSegment2(p, Vector3D.NewVector(0, -h, 0), baseCorner)
+	slantPoint := closestPointOnSegment2(p, baseCorner, Vector3D.NewVector(0, h, 0))
+
+	point := basePoint
+	normal := Vector3D.NewVector(0, -1, 0)
+	if slantPoint.DistanceSquaredTo(p) < basePoint.DistanceSquaredTo(p) {
+		point = slantPoint
+		normal = Vector3D.NewVector(height, radius, 0).Normalize()
+	}
+
+	isInside := y >= -h && y <= h && r <= radius*(h-y)/height
+
+	// Outside the rim and the apex the normal points from the corner to the
+	// query point.
+	isCorner := point.IsSimilar(baseCorner) || point.IsSimilar(Vector3D.NewVector(0, h, 0))
+	if !isInside && isCorner && !point.IsSimilar(p) {
+		normal = p.Substract(point).Normalize()
+	}
+
+	return &profileQueryResult{
+		point:    point,
+		normal:   normal,
+		isInside: isInside,
+	}
+}
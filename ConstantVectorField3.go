@@ -18,3 +18,9 @@ func (c *ConstantVectorField3) withValue(v *Vector3D.Vector3D) {
 
 	c.value.Set(v)
 }
+
+// sample returns the constant value regardless of the point.
+func (c *ConstantVectorField3) sample(x *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	return Vector3D.NewVector(c.value.X, c.value.Y, c.value.Z)
+}
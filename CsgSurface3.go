@@ -0,0 +1,292 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"jimmykiang/fluidengine/mathHelper"
+	"math"
+)
+
+// CsgOperation selects how a CsgSurface3 combines its two operands.
+type CsgOperation int
+
+const (
+	// CsgUnion keeps the points inside either operand.
+	CsgUnion CsgOperation = iota
+
+	// CsgIntersection keeps the points inside both operands.
+	CsgIntersection
+
+	// CsgDifference keeps the points inside the first operand but not the
+	// second one.
+	CsgDifference
+
+	// CsgSmoothUnion is a union that blends the operands over the smoothing
+	// distance.
+	CsgSmoothUnion
+
+	// CsgSmoothSubtract is a difference that blends the operands over the
+	// smoothing distance.
+	CsgSmoothSubtract
+)
+
+// kCsgGradientDelta is the finite difference step used for the normals of
+// CSG surfaces.
+const kCsgGradientDelta = 1e-6
+
+// CsgSurface3 is a 3-D constructive solid geometry surface.
+// Combines two implicit surfaces by their signed distances, so either
+// operand may itself be a CsgSurface3, an ImplicitSurfaceSet3 or any other
+// ImplicitSurface3. The operands are given in the local frame of the
+// composite. The result is a surface that can be used both as an
+// ImplicitSurface3 (emitters) and as a Surface3IF (colliders). Normals are
+// the gradient of the combined signed distance and the closest point is found
+// by following it, which is exact for the non-smooth operations as long as
+// the closest point is on a single operand.
+type CsgSurface3 struct {
+
+	// Base struct for 3-D surface.
+	surface3 *Surface3
+
+	// How the operands are combined.
+	operation CsgOperation
+
+	// The operands.
+	a, b ImplicitSurface3
+
+	// Blending distance of the smooth operations.
+	smoothness float64
+
+	// Local-to-world transform.
+	transform *Transform3
+
+	// Flips normal when calling Surface3::closestNormal(...).
+	isNormalFlipped bool
+}
+
+func NewCsgSurface3(operation CsgOperation, a, b ImplicitSurface3, smoothness float64) *CsgSurface3 {
+	return &CsgSurface3{
+		surface3:        NewSurface3(),
+		operation:       operation,
+		a:               a,
+		b:               b,
+		smoothness:      smoothness,
+		transform:       NewTransform3(),
+		isNormalFlipped: false,
+	}
+}
+
+// NewCsgUnion3 constructs the union of a and b.
+func NewCsgUnion3(a, b ImplicitSurface3) *CsgSurface3 {
+	return NewCsgSurface3(CsgUnion, a, b, 0)
+}
+
+// NewCsgIntersection3 constructs the intersection of a and b.
+func NewCsgIntersection3(a, b ImplicitSurface3) *CsgSurface3 {
+	return NewCsgSurface3(CsgIntersection, a, b, 0)
+}
+
+// NewCsgDifference3 constructs a minus b.
+func NewCsgDifference3(a, b ImplicitSurface3) *CsgSurface3 {
+	return NewCsgSurface3(CsgDifference, a, b, 0)
+}
+
+// NewCsgSmoothUnion3 constructs the union of a and b blended over smoothness.
+func NewCsgSmoothUnion3(a, b ImplicitSurface3, smoothness float64) *CsgSurface3 {
+	return NewCsgSurface3(CsgSmoothUnion, a, b, smoothness)
+}
+
+// NewCsgSmoothSubtract3 constructs a minus b blended over smoothness.
+func NewCsgSmoothSubtract3(a, b ImplicitSurface3, smoothness float64) *CsgSurface3 {
+	return NewCsgSurface3(CsgSmoothSubtract, a, b, smoothness)
+}
+
+func (c *CsgSurface3) getTransform() *Transform3 {
+	return c.transform
+}
+
+// getIsNormalFlipped returns true if the normal is flipped.
+func (c *CsgSurface3) getIsNormalFlipped() bool {
+	return c.isNormalFlipped
+}
+
+// isBounded returns true if bounding box can be defined.
+func (c *CsgSurface3) isBounded() bool {
+
+	switch c.operation {
+	case CsgIntersection:
+		return c.a.isBounded() || c.b.isBounded()
+	case CsgDifference, CsgSmoothSubtract:
+		return c.a.isBounded()
+	default:
+		return c.a.isBounded() && c.b.isBounded()
+	}
+}
+
+// boundingBox returns the bounding box of this surface object.
+func (c *CsgSurface3) boundingBox() *BoundingBox3D {
+
+	return c.transform.toWorldBoundingBox(c.boundingBoxLocal())
+}
+
+func (c *CsgSurface3) boundingBoxLocal() *BoundingBox3D {
+
+	switch c.operation {
+	case CsgIntersection:
+		if !c.b.isBounded() {
+			return c.a.boundingBox()
+		}
+		if !c.a.isBounded() {
+			return c.b.boundingBox()
+		}
+		boxA := c.a.boundingBox()
+		boxB := c.b.boundingBox()
+		return &BoundingBox3D{
+			lowerCorner: boxA.lowerCorner.Max(boxB.lowerCorner),
+			upperCorner: boxA.upperCorner.Min(boxB.upperCorner),
+		}
+
+	case CsgDifference, CsgSmoothSubtract:
+		return c.a.boundingBox()
+
+	default:
+		bound := NewBoundingBox3DFromStruct(c.a.boundingBox())
+		bound.merge(c.b.boundingBox())
+
+		// The smooth union bulges out by at most a quarter of the smoothness.
+		if c.operation == CsgSmoothUnion {
+			bound.expand(0.25 * c.smoothness)
+		}
+		return bound
+	}
+}
+
+func (c *CsgSurface3) signedDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	sd := c.signedDistanceLocal(c.transform.toLocal(otherPoint))
+	if c.isNormalFlipped {
+		sd = -sd
+	}
+	return sd
+}
+
+// signedDistanceLocal combines the signed distances of the operands. The
+// smooth operations use the polynomial smooth minimum.
+func (c *CsgSurface3) signedDistanceLocal(otherPoint *Vector3D.Vector3D) float64 {
+
+	da := c.a.signedDistance(otherPoint)
+	db := c.b.signedDistance(otherPoint)
+
+	switch c.operation {
+	case CsgIntersection:
+		return math.Max(da, db)
+
+	case CsgDifference:
+		return math.Max(da, -db)
+
+	case CsgSmoothUnion:
+		if c.smoothness <= 0 {
+			return math.Min(da, db)
+		}
+		h := mathHelper.Clamp(0.5+0.5*(db-da)/c.smoothness, 0, 1)
+		return db + (da-db)*h - c.smoothness*h*(1-h)
+
+	case CsgSmoothSubtract:
+		if c.smoothness <= 0 {
+			return math.Max(da, -db)
+		}
+		h := mathHelper.Clamp(0.5-0.5*(da+db)/c.smoothness, 0, 1)
+		return da + (-db-da)*h + c.smoothness*h*(1-h)
+
+	default:
+		return math.Min(da, db)
+	}
+}
+
+// Returns the closest point from the given point otherPoint to the surface.
+func (c *CsgSurface3) closestPoint(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	return c.transform.toWorld(c.closestPointLocal(c.transform.toLocal(otherPoint)))
+}
+
+func (c *CsgSurface3) closestPointLocal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	sd := c.signedDistanceLocal(otherPoint)
+	return otherPoint.Substract(c.closestNormalLocal(otherPoint).Multiply(sd))
+}
+
+// Returns the closest distance from the given point otherPoint to the surface.
+func (c *CsgSurface3) closestDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	return math.Abs(c.signedDistanceLocal(c.transform.toLocal(otherPoint)))
+}
+
+// closestNormal returns the normal to the closest point on the surface from the given
+// point.
+func (c *CsgSurface3) closestNormal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	result := c.transform.toWorldDirection(c.closestNormalLocal(c.transform.toLocal(otherPoint)))
+	if c.isNormalFlipped {
+		result = result.Multiply(-1)
+	}
+	return result
+}
+
+// closestNormalLocal returns the gradient of the signed distance by central
+// differences.
+func (c *CsgSurface3) closestNormalLocal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	h := kCsgGradientDelta
+	gradient := Vector3D.NewVector(
+		c.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(h, 0, 0)))-
+			c.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(-h, 0, 0))),
+		c.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(0, h, 0)))-
+			c.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(0, -h, 0))),
+		c.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(0, 0, h)))-
+			c.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(0, 0, -h))))
+
+	if gradient.Length() == 0 {
+		return Vector3D.NewVector(1, 0, 0)
+	}
+	return gradient.Normalize()
+}
+
+// Returns true if otherPoint is inside the volume defined by the surface.
+func (c *CsgSurface3) isInside(otherPoint *Vector3D.Vector3D) bool {
+
+	return c.signedDistance(otherPoint) < 0
+}
+
+// closestIntersection returns the first hit of the ray with the surface.
+func (c *CsgSurface3) closestIntersection(ray *Ray3) *SurfaceRayIntersection3 {
+
+	return intersectionToWorld(c.transform, c.isNormalFlipped, c.closestIntersectionLocal(c.transform.toLocalRay(ray)))
+}
+
+// intersects returns true if the ray hits the surface.
+func (c *CsgSurface3) intersects(ray *Ray3) bool {
+
+	return c.closestIntersectionLocal(c.transform.toLocalRay(ray)).isIntersecting
+}
+
+// closestIntersectionLocal sphere traces the combined signed distance, which
+// never overestimates the distance to the surface.
+func (c *CsgSurface3) closestIntersectionLocal(ray *Ray3) *SurfaceRayIntersection3 {
+
+	intersection := NewSurfaceRayIntersection3()
+
+	var bound *BoundingBox3D
+	if c.isBounded() {
+		bound = c.boundingBoxLocal()
+	}
+
+	t, isIntersecting := sphereTrace3(ray, bound, func(point *Vector3D.Vector3D) float64 {
+		return math.Abs(c.signedDistanceLocal(point))
+	})
+	if isIntersecting {
+		intersection.isIntersecting = true
+		intersection.distance = t
+		intersection.point = ray.pointAt(t)
+		intersection.normal = c.closestNormalLocal(intersection.point)
+	}
+	return intersection
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"testing"
+)
+
+func TestCsgSurface3SignedDistance(t *testing.T) {
+
+	box := NewBox3(NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(2, 2, 2)))
+	box.isNormalFlipped = false
+	sphere := NewSphere3(Vector3D.NewVector(1, 1, 1), 0.5)
+	other := NewSphere3(Vector3D.NewVector(2, 1, 1), 0.75)
+
+	cases := []struct {
+		name     string
+		surface  ImplicitSurface3
+		point    *Vector3D.Vector3D
+		expected float64
+	}{
+		{"union", NewCsgUnion3(sphere, other), Vector3D.NewVector(1.4, 1, 1), -0.15},
+		{"intersection", NewCsgIntersection3(sphere, other), Vector3D.NewVector(1.4, 1, 1), -0.1},
+		{"box with a hole", NewCsgDifference3(box, sphere), Vector3D.NewVector(1, 1, 1), 0.5},
+		{"box with a hole wall", NewCsgDifference3(box, sphere), Vector3D.NewVector(1, 1, 1.8), -0.2},
+		{"nested", NewCsgDifference3(NewCsgUnion3(sphere, other), box), Vector3D.NewVector(2.5, 1, 1), -0.25},
+	}
+	for _, c := range cases {
+		if sd := c.surface.signedDistance(c.point); math.Abs(sd-c.expected) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, sd)
+		}
+	}
+
+	// The smooth union fills the crease between the spheres.
+	midPoint := Vector3D.NewVector(1.3, 1.6, 1)
+	smooth := NewCsgSmoothUnion3(sphere, other, 0.2)
+	if sd, sharp := smooth.signedDistance(midPoint), NewCsgUnion3(sphere, other).signedDistance(midPoint); sd >= sharp {
+		t.Errorf("expected the smooth union %v to be below the union %v", sd, sharp)
+	}
+	if sd := smooth.signedDistance(Vector3D.NewVector(1, 1, 1)); math.Abs(sd+0.5) > 1e-9 {
+		t.Errorf("expected the smooth union to match the union away from the crease, got %v", sd)
+	}
+	subtract := NewCsgSmoothSubtract3(box, sphere, 0.2)
+	if sd := subtract.signedDistance(Vector3D.NewVector(1, 1, 1)); math.Abs(sd-0.5) > 1e-9 {
+		t.Errorf("expected the smooth difference to match the difference inside the hole, got %v", sd)
+	}
+}
+
+func TestCsgSurface3Queries(t *testing.T) {
+
+	box := NewBox3(NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(2, 2, 2)))
+	box.isNormalFlipped = false
+	csg := NewCsgDifference3(box, NewSphere3(Vector3D.NewVector(1, 1, 1), 0.5))
+	csg.transform.translation = Vector3D.NewVector(10, 0, 0)
+
+	var surface Surface3IF = csg
+	point := Vector3D.NewVector(11, 1.2, 1)
+	if surface.closestPoint(point).DistanceTo(Vector3D.NewVector(11, 1.5, 1)) > 1e-6 {
+		t.Errorf("unexpected closest point %v", surface.closestPoint(point))
+	}
+	if normal := surface.closestNormal(point); normal.DistanceTo(Vector3D.NewVector(0, -1, 0)) > 1e-6 {
+		t.Errorf("expected the normal to point into the hole, got %v", normal)
+	}
+	if surface.isInside(point) || !surface.isInside(Vector3D.NewVector(10.1, 0.1, 0.1)) {
+		t.Errorf("unexpected isInside result")
+	}
+
+	bound := csg.boundingBox()
+	if !bound.lowerCorner.IsSimilar(Vector3D.NewVector(10, 0, 0)) || !bound.upperCorner.IsSimilar(Vector3D.NewVector(12, 2, 2)) {
+		t.Errorf("unexpected bounding box %v %v", bound.lowerCorner, bound.upperCorner)
+	}
+
+	// The ray enters the hole through the box and hits the sphere.
+	ray := NewRay3(Vector3D.NewVector(11, 1, 1), Vector3D.NewVector(0, 1, 0))
+	result := surface.closestIntersection(ray)
+	if !result.isIntersecting || math.Abs(result.distance-0.5) > 1e-6 {
+		t.Errorf("expected a hit at distance 0.5, got %v", result.distance)
+	}
+
+	// Through an emitter set, only the points outside the hole are filled.
+	surfaceSet := NewImplicitSurfaceSet3()
+	surfaceSet.addExplicitSurface(NewCsgDifference3(box, NewSphere3(Vector3D.NewVector(1, 1, 1), 0.5)))
+	if surfaceSet.signedDistance(Vector3D.NewVector(1, 1, 1)) <= 0 || surfaceSet.signedDistance(Vector3D.NewVector(0.2, 1, 1)) >= 0 {
+		t.Errorf("unexpected signed distance of the set")
+	}
+
+	// A particle rising in the hole stops below its wall.
+	collider := NewRigidBodyCollider3(csg)
+	newPosition := Vector3D.NewVector(11, 1.48, 1)
+	velocity := Vector3D.NewVector(0, 1, 0)
+	collider.resolveCollision(0, 1, 0.05, 0, Vector3D.NewVector(11, 1.3, 1), &newPosition, &velocity)
+	if newPosition.DistanceTo(Vector3D.NewVector(11, 1.45, 1)) > 1e-6 || velocity.Length() > 1e-6 {
+		t.Errorf("expected the particle stopped at (11, 1.45, 1), got %v with %v", newPosition, velocity)
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// CustomVectorField3 3-D vector field with a custom sampler function.
+type CustomVectorField3 struct {
+	customFunction func(*Vector3D.Vector3D) *Vector3D.Vector3D
+}
+
+func NewCustomVectorField3(customFunction func(*Vector3D.Vector3D) *Vector3D.Vector3D) *CustomVectorField3 {
+	return &CustomVectorField3{
+		customFunction: customFunction,
+	}
+}
+
+// sample returns the value of the custom function at the given point.
+func (c *CustomVectorField3) sample(x *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	return c.customFunction(x)
+}
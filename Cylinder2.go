@@ -0,0 +1,35 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// Cylinder2 is the 2-D counterpart of Cylinder3: the rectangle cut from the
+// cylinder through its axis. The axis is the y-axis of its local frame
+// through center.
+type Cylinder2 struct {
+	*surfaceOfRevolution2
+
+	// Radius of the cylinder.
+	radius float64
+
+	// Height of the cylinder.
+	height float64
+}
+
+func NewCylinder2(center *Vector3D.Vector3D, radius, height float64) *Cylinder2 {
+	c := &Cylinder2{
+		radius: radius,
+		height: height,
+	}
+	c.surfaceOfRevolution2 = newSurfaceOfRevolution2(center, c)
+	return c
+}
+
+func (c *Cylinder2) closestProfilePoint(r, y float64) *profileQueryResult {
+
+	return cylinderProfilePoint(c.radius, c.height, r, y)
+}
+
+func (c *Cylinder2) profileExtent() (float64, float64, float64) {
+
+	return c.radius, -0.5 * c.height, 0.5 * c.height
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"jimmykiang/fluidengine/mathHelper"
+	"math"
+)
+
+// Cylinder3 is a 3-D capped cylinder geometry.
+// The axis of the cylinder is the y-axis of its local frame through center,
+// and the cylinder spans height/2 to each side of center.
+type Cylinder3 struct {
+	*surfaceOfRevolution3
+
+	// Radius of the cylinder.
+	radius float64
+
+	// Height of the cylinder.
+	height float64
+}
+
+func NewCylinder3(center *Vector3D.Vector3D, radius, height float64) *Cylinder3 {
+	c := &Cylinder3{
+		radius: radius,
+		height: height,
+	}
+	c.surfaceOfRevolution3 = newSurfaceOfRevolution3(center, c)
+	return c
+}
+
+func (c *Cylinder3) closestProfilePoint(r, y float64) *profileQueryResult {
+
+	return cylinderProfilePoint(c.radius, c.height, r, y)
+}
+
+func (c *Cylinder3) profileExtent() (float64, float64, float64) {
+
+	return c.radius, -0.5 * c.height, 0.5 * c.height
+}
+
+// cylinderProfilePoint queries the rectangular outline of a cylinder: the
+// side wall at r = radius and the caps at y = +-height/2.
+func cylinderProfilePoint(radius, height, r, y float64) *profileQueryResult {
+
+	h := 0.5 * height
+	capSign := 1.0
+	if y < 0 {
+		capSign = -1
+	}
+
+	if r <= radius && math.Abs(y) <= h {
+
+		// Inside: the closer of the side wall and the nearest cap.
+		if radius-r < h-math.Abs(y) {
+			return &profileQueryResult{
+				point:    Vector3D.NewVector(radius, y, 0),
+				normal:   Vector3D.NewVector(1, 0, 0),
+				isInside: true,
+			}
+		}
+		return &profileQueryResult{
+			point:    Vector3D.NewVector(r, capSign*h, 0),
+			normal:   Vector3D.NewVector(0, capSign, 0),
+			isInside: true,
+		}
+	}
+
+	point := Vector3D.NewVector(math.Min(r, radius), mathHelper.Clamp(y, -h, h), 0)
+
+	normal := Vector3D.NewVector(0, capSign, 0)
+	if r > radius && math.Abs(y) > h {
+		normal = Vector3D.NewVector(r-point.X, y-point.Y, 0).Normalize()
+	} else if r > radius {
+		normal = Vector3D.NewVector(1, 0, 0)
+	}
+
+	return &profileQueryResult{
+		point:    point,
+		normal:   normal,
+		isInside: false,
+	}
+}
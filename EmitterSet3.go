@@ -0,0 +1,136 @@
+package main
+
+import "math"
+
+// EmitterSet3 is a collection of particle emitters sharing one target. Each
+// emitter is only updated inside its own time window and while it is enabled,
+// so several sources can switch on and off over an animation. Emission rates
+// are configured on the emitters themselves. Each emitter is updated with the
+// time it has been active, so time spent inactive does not count towards its
+// rate and a reactivated emitter does not catch up in a burst.
+type EmitterSet3 struct {
+	particles        *ParticleSystemData3
+	emitters         []*scheduledEmitter3
+	onUpdateCallback OnBeginUpdateCallbackEmitterSet3
+}
+
+// scheduledEmitter3 is an emitter with the time window it is active in.
+type scheduledEmitter3 struct {
+	emitter   ParticleEmitter3IF
+	startTime float64
+	stopTime  float64
+	isEnabled bool
+	// Whether the emitter has been updated at least once.
+	hasStarted bool
+	// Time the set was updated while the emitter was inactive after it started.
+	inactiveTime float64
+}
+
+// OnBeginUpdateCallbackEmitterSet3 is a brief Callback function signature type for update calls.
+// This type of callback function will take the emitter set pointer, current
+// time, and time interval in seconds.
+type OnBeginUpdateCallbackEmitterSet3 func(
+	emitterSet *EmitterSet3,
+	currentTime float64,
+	timeInterval float64,
+)
+
+func NewEmitterSet3(emitters ...ParticleEmitter3IF) *EmitterSet3 {
+	e := &EmitterSet3{
+		particles:        nil,
+		emitters:         make([]*scheduledEmitter3, 0, len(emitters)),
+		onUpdateCallback: nil,
+	}
+	for _, emitter := range emitters {
+		e.addEmitter(emitter)
+	}
+	return e
+}
+
+// addEmitter adds an emitter that is active for the whole animation and
+// returns its index.
+func (e *EmitterSet3) addEmitter(emitter ParticleEmitter3IF) int {
+
+	return e.addScheduledEmitter(emitter, 0, math.Inf(1))
+}
+
+// addScheduledEmitter adds an emitter that is only updated from startTime
+// until stopTime and returns its index.
+func (e *EmitterSet3) addScheduledEmitter(emitter ParticleEmitter3IF, startTime, stopTime float64) int {
+
+	e.emitters = append(e.emitters, &scheduledEmitter3{
+		emitter:   emitter,
+		startTime: startTime,
+		stopTime:  stopTime,
+		isEnabled: true,
+	})
+	if e.particles != nil {
+		emitter.setTarget(e.particles)
+	}
+	return len(e.emitters) - 1
+}
+
+// numberOfEmitters returns the number of emitters in the set.
+func (e *EmitterSet3) numberOfEmitters() int {
+
+	return len(e.emitters)
+}
+
+// emitter returns the i-th emitter.
+func (e *EmitterSet3) emitter(i int) ParticleEmitter3IF {
+
+	return e.emitters[i].emitter
+}
+
+// setTimeWindow sets the time window in which the i-th emitter is updated.
+func (e *EmitterSet3) setTimeWindow(i int, startTime, stopTime float64) {
+
+	e.emitters[i].startTime = startTime
+	e.emitters[i].stopTime = stopTime
+}
+
+// setIsEmitterEnabled enables or disables the i-th emitter.
+func (e *EmitterSet3) setIsEmitterEnabled(i int, isEnabled bool) {
+
+	e.emitters[i].isEnabled = isEnabled
+}
+
+// isEmitterActive returns true if the i-th emitter is enabled and its time
+// window contains the given time.
+func (e *EmitterSet3) isEmitterActive(i int, currentTime float64) bool {
+
+	s := e.emitters[i]
+	return s.isEnabled && currentTime >= s.startTime && currentTime < s.stopTime
+}
+
+// setOnBeginUpdateCallback sets the callback invoked at the beginning of each update.
+func (e *EmitterSet3) setOnBeginUpdateCallback(callback OnBeginUpdateCallbackEmitterSet3) {
+
+	e.onUpdateCallback = callback
+}
+
+func (e *EmitterSet3) setTarget(particles *ParticleSystemData3) {
+
+	e.particles = particles
+	for _, s := range e.emitters {
+		s.emitter.setTarget(particles)
+	}
+}
+
+// update updates every active emitter in the set. The emitters receive the
+// current time minus the time they spent inactive.
+func (e *EmitterSet3) update(currentTimeInSeconds, timeIntervalInSeconds float64) {
+
+	if e.onUpdateCallback != nil {
+		e.onUpdateCallback(e, currentTimeInSeconds, timeIntervalInSeconds)
+	}
+
+	for i, s := range e.emitters {
+		if e.isEmitterActive(i, currentTimeInSeconds) {
+			s.hasStarted = true
+			s.emitter.update(currentTimeInSeconds-s.inactiveTime, timeIntervalInSeconds)
+		} else if s.hasStarted {
+			s.inactiveTime += timeIntervalInSeconds
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"testing"
+)
+
+func TestEmitterSet3Schedule(t *testing.T) {
+
+	// A one-shot dam break at the start and a pour between t=2 and t=3.
+	damBreak, _ := newTestVolumeParticleEmitter3()
+	damBreak.setIsOneShot(true)
+
+	pour := NewPointParticleEmitter3()
+	pour.withOrigin(Vector3D.NewVector(0, 1, 0))
+	pour.withMaxNumberOfNewParticlesPerSecond(10)
+
+	particles := NewParticleSystemData3()
+	set := NewEmitterSet3(damBreak)
+	set.setTarget(particles)
+	set.addScheduledEmitter(pour, 2, 3)
+
+	set.update(0, 0.25)
+	filled := particles.numberOfParticles
+	if filled == 0 {
+		t.Fatalf("expected the dam break to fill the volume")
+	}
+
+	for currentTime := 0.25; currentTime < 2; currentTime += 0.25 {
+		set.update(currentTime, 0.25)
+	}
+	if particles.numberOfParticles != filled {
+		t.Errorf("expected no particles before the pour starts, got %d", particles.numberOfParticles-filled)
+	}
+
+	for currentTime := 2.0; currentTime < 4; currentTime += 0.25 {
+		set.update(currentTime, 0.25)
+	}
+	if particles.numberOfParticles != filled+10 {
+		t.Errorf("expected 10 poured particles, got %d", particles.numberOfParticles-filled)
+	}
+}
+
+func TestEmitterSet3Callbacks(t *testing.T) {
+
+	pour := NewPointParticleEmitter3()
+	pour.withMaxNumberOfNewParticlesPerSecond(10)
+	calls := 0
+	pour.setOnBeginUpdateCallback(func(emitter *PointParticleEmitter3, currentTime, timeInterval float64) {
+		calls++
+		emitter.withDirection(Vector3D.NewVector(1, 0, 0))
+	})
+
+	particles := NewParticleSystemData3()
+	set := NewEmitterSet3(pour)
+	set.setTarget(particles)
+	set.setOnBeginUpdateCallback(func(emitterSet *EmitterSet3, currentTime, timeInterval float64) {
+		emitterSet.setIsEmitterEnabled(0, currentTime < 0.5)
+	})
+
+	for currentTime := 0.0; currentTime < 1; currentTime += 0.25 {
+		set.update(currentTime, 0.25)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected the emitter callback while enabled only, got %d calls", calls)
+	}
+	if particles.numberOfParticles != 5 {
+		t.Errorf("expected 5 particles before the set disabled the emitter, got %d", particles.numberOfParticles)
+	}
+	if v := particles.velocities()[0]; v.DistanceTo(Vector3D.NewVector(1, 0, 0)) > 1e-12 {
+		t.Errorf("expected the callback to redirect the emitter, got %v", v)
+	}
+}
+
+func TestEmitterSet3Reactivation(t *testing.T) {
+
+	pour := NewPointParticleEmitter3()
+	pour.withMaxNumberOfNewParticlesPerSecond(10)
+
+	particles := NewParticleSystemData3()
+	set := NewEmitterSet3()
+	set.setTarget(particles)
+	set.addScheduledEmitter(pour, 0, 1)
+
+	currentTime := 0.0
+	for ; currentTime < 2; currentTime += 0.25 {
+		set.update(currentTime, 0.25)
+	}
+	if particles.numberOfParticles != 10 {
+		t.Fatalf("expected 10 particles in the first window, got %d", particles.numberOfParticles)
+	}
+
+	// Reopening the window resumes at the rate instead of catching up.
+	set.setTimeWindow(0, 3, 4)
+	for ; currentTime < 3.25; currentTime += 0.25 {
+		set.update(currentTime, 0.25)
+	}
+	if particles.numberOfParticles != 13 {
+		t.Errorf("expected 3 particles in the first step after the gap, got %d", particles.numberOfParticles-10)
+	}
+
+	// The same holds for disabling and enabling the emitter.
+	set.setIsEmitterEnabled(0, false)
+	for ; currentTime < 3.75; currentTime += 0.25 {
+		set.update(currentTime, 0.25)
+	}
+	set.setIsEmitterEnabled(0, true)
+	set.update(currentTime, 0.25)
+	if particles.numberOfParticles != 15 {
+		t.Errorf("expected 2 particles in the first step after re-enabling, got %d", particles.numberOfParticles-13)
+	}
+}
@@ -8,4 +8,6 @@ type ImplicitSurface2 interface {
 	boundingBox() *BoundingBox2D
 	signedDistance(otherPoint *Vector3D.Vector3D) float64
 	getTransform() *Transform2
+	// getIsNormalFlipped returns true if the normal is flipped.
+	getIsNormalFlipped() bool
 }
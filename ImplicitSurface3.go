@@ -9,4 +9,10 @@ type ImplicitSurface3 interface {
 	// signedDistance returns signed distance from the given point otherPoint.
 	signedDistance(otherPoint *Vector3D.Vector3D) float64
 	getTransform() *Transform3
+	// getIsNormalFlipped returns true if the normal is flipped.
+	getIsNormalFlipped() bool
+	// closestIntersection returns the first hit of the ray with the surface.
+	closestIntersection(ray *Ray3) *SurfaceRayIntersection3
+	// intersects returns true if the ray hits the surface.
+	intersects(ray *Ray3) bool
 }
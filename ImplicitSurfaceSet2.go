@@ -11,7 +11,9 @@ type ImplicitSurfaceSet2 struct {
 	bvhInvalidated    bool
 	surfaces          []ImplicitSurface2
 	unboundedSurfaces []ImplicitSurface2
+	flippedSurfaces   []ImplicitSurface2
 	bvh               *Bvh2
+	transform         *Transform2
 }
 
 func NewImplicitSurfaceSet2() *ImplicitSurfaceSet2 {
@@ -19,7 +21,9 @@ func NewImplicitSurfaceSet2() *ImplicitSurfaceSet2 {
 		bvhInvalidated:    true,
 		surfaces:          make([]ImplicitSurface2, 0),
 		unboundedSurfaces: make([]ImplicitSurface2, 0),
+		flippedSurfaces:   make([]ImplicitSurface2, 0),
 		bvh:               NewBvh2(),
+		transform:         NewTransform2(),
 	}
 }
 
@@ -51,10 +55,14 @@ func (s *ImplicitSurfaceSet2) buildBvh() {
 		surfs := make([]ImplicitSurface2, 0, 0)
 		bounds := make([]*BoundingBox2D, 0, 0)
 
+		s.flippedSurfaces = s.flippedSurfaces[:0]
 		for i := 0; i < len(s.surfaces); i++ {
 			if s.surfaces[i].isBounded() {
 				surfs = append(surfs, s.surfaces[i])
 				bounds = append(bounds, s.surfaces[i].boundingBox())
+				if s.surfaces[i].getIsNormalFlipped() {
+					s.flippedSurfaces = append(s.flippedSurfaces, s.surfaces[i])
+				}
 			}
 		}
 		s.bvh.build(surfs, bounds)
@@ -74,11 +82,38 @@ func (s *ImplicitSurfaceSet2) isBounded() bool {
 	return len(s.surfaces) != 0
 }
 
-func (s *ImplicitSurfaceSet2) signedDistance(candidate *Vector3D.Vector3D) float64 {
+func (s *ImplicitSurfaceSet2) signedDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	return s.signedDistanceLocal(s.transform.toLocal(otherPoint))
+}
+
+func (s *ImplicitSurfaceSet2) signedDistanceLocal(candidate *Vector3D.Vector3D) float64 {
+
+	// Bounded surfaces are looked up through the bvh, unbounded ones are not
+	// part of it and are always tested. A flipped surface is negative outside
+	// its bounding box, so the box distance does not bound it and the bvh
+	// would wrongly prune it; those are always tested as well.
+	s.buildBvh()
+
 	sdf := math.MaxFloat64
-	for _, surface := range s.surfaces {
+	for _, surface := range s.unboundedSurfaces {
 
 		sdf = math.Min(sdf, surface.signedDistance(candidate))
 	}
-	return sdf
+	for _, surface := range s.flippedSurfaces {
+
+		sdf = math.Min(sdf, surface.signedDistance(candidate))
+	}
+
+	result := s.bvh.nearest(candidate, func(surface ImplicitSurface2, point *Vector3D.Vector3D) float64 {
+		if surface.getIsNormalFlipped() {
+			return math.MaxFloat64
+		}
+		return surface.signedDistance(point)
+	})
+	return math.Min(sdf, result.distance)
+}
+
+func (s *ImplicitSurfaceSet2) getTransform() *Transform2 {
+	return s.transform
 }
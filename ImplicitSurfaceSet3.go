@@ -11,6 +11,7 @@ type ImplicitSurfaceSet3 struct {
 	bvhInvalidated    bool
 	surfaces          []ImplicitSurface3
 	unboundedSurfaces []ImplicitSurface3
+	flippedSurfaces   []ImplicitSurface3
 	bvh               *Bvh3
 	transform         *Transform3
 	isNormalFlipped   bool
@@ -21,6 +22,7 @@ func NewImplicitSurfaceSet3() *ImplicitSurfaceSet3 {
 		bvhInvalidated:    true,
 		surfaces:          make([]ImplicitSurface3, 0),
 		unboundedSurfaces: make([]ImplicitSurface3, 0),
+		flippedSurfaces:   make([]ImplicitSurface3, 0),
 		bvh:               NewBvh3(),
 		transform:         NewTransform3(),
 		isNormalFlipped:   false,
@@ -54,10 +56,14 @@ func (s *ImplicitSurfaceSet3) buildBvh() {
 		surfs := make([]ImplicitSurface3, 0, 0)
 		bounds := make([]*BoundingBox3D, 0, 0)
 
+		s.flippedSurfaces = s.flippedSurfaces[:0]
 		for i := 0; i < len(s.surfaces); i++ {
 			if s.surfaces[i].isBounded() {
 				surfs = append(surfs, s.surfaces[i])
 				bounds = append(bounds, s.surfaces[i].boundingBox())
+				if s.surfaces[i].getIsNormalFlipped() {
+					s.flippedSurfaces = append(s.flippedSurfaces, s.surfaces[i])
+				}
 			}
 		}
 		s.bvh.build(surfs, bounds)
@@ -90,10 +96,89 @@ func (s *ImplicitSurfaceSet3) signedDistance(otherPoint *Vector3D.Vector3D) floa
 }
 
 func (s *ImplicitSurfaceSet3) signedDistanceLocal(otherPoint *Vector3D.Vector3D) float64 {
+
+	// Bounded surfaces are looked up through the bvh, unbounded ones are not
+	// part of it and are always tested. A flipped surface is negative outside
+	// its bounding box, so the box distance does not bound it and the bvh
+	// would wrongly prune it; those are always tested as well.
+	s.buildBvh()
+
 	sdf := math.MaxFloat64
-	for _, surface := range s.surfaces {
+	for _, surface := range s.unboundedSurfaces {
+
+		sdf = math.Min(sdf, surface.signedDistance(otherPoint))
+	}
+	for _, surface := range s.flippedSurfaces {
 
 		sdf = math.Min(sdf, surface.signedDistance(otherPoint))
 	}
-	return sdf
+
+	result := s.bvh.nearest(otherPoint, func(surface ImplicitSurface3, point *Vector3D.Vector3D) float64 {
+		if surface.getIsNormalFlipped() {
+			return math.MaxFloat64
+		}
+		return surface.signedDistance(point)
+	})
+	return math.Min(sdf, result.distance)
+}
+
+// closestIntersection returns the first hit of the ray with any surface of
+// the set. Bounded surfaces are looked up through the bvh.
+func (s *ImplicitSurfaceSet3) closestIntersection(ray *Ray3) *SurfaceRayIntersection3 {
+
+	s.buildBvh()
+
+	rayLocal := s.transform.toLocalRay(ray)
+	intersection := NewSurfaceRayIntersection3()
+
+	for _, surface := range s.unboundedSurfaces {
+		if localResult := surface.closestIntersection(rayLocal); localResult.distance < intersection.distance {
+			intersection = localResult
+		}
+	}
+
+	// The query only returns the hit surface, so its intersection is
+	// recomputed once to get the point and normal.
+	result := s.bvh.closestIntersection(rayLocal, func(surface ImplicitSurface3, ray *Ray3) float64 {
+		return surface.closestIntersection(ray).distance
+	})
+	if result.item != nil && result.distance < intersection.distance {
+		intersection = result.item.closestIntersection(rayLocal)
+	}
+
+	return intersectionToWorld(s.transform, s.isNormalFlipped, intersection)
+}
+
+// intersects returns true if the ray hits any surface of the set.
+func (s *ImplicitSurfaceSet3) intersects(ray *Ray3) bool {
+
+	s.buildBvh()
+
+	rayLocal := s.transform.toLocalRay(ray)
+	for _, surface := range s.unboundedSurfaces {
+		if surface.intersects(rayLocal) {
+			return true
+		}
+	}
+
+	return s.bvh.intersectsRay(rayLocal, func(surface ImplicitSurface3, ray *Ray3) bool {
+		return surface.intersects(ray)
+	})
+}
+
+// boundingBox returns the bounding box of the bounded surfaces of the set.
+func (s *ImplicitSurfaceSet3) boundingBox() *BoundingBox3D {
+
+	s.buildBvh()
+
+	return s.transform.toWorldBoundingBox(s.bvh.boundingBox())
+}
+
+func (s *ImplicitSurfaceSet3) getTransform() *Transform3 {
+	return s.transform
+}
+
+// getIsNormalFlipped returns true if the normal is flipped.
+func (s *ImplicitSurfaceSet3) getIsNormalFlipped() bool {
+	return s.isNormalFlipped
 }
@@ -1,4 +1,45 @@
 package main
 
+// BoxIntersectionTestFunc2 returns true if the given item intersects the box.
+type BoxIntersectionTestFunc2 func(item ImplicitSurface2, box *BoundingBox2D) bool
+
+// RayIntersectionTestFunc2 returns true if the given item intersects the ray.
+type RayIntersectionTestFunc2 func(item ImplicitSurface2, ray *Ray2) bool
+
+// GetRayIntersectionFunc2 returns the distance along the ray to the first
+// intersection with the given item, or math.MaxFloat64 if there is none.
+type GetRayIntersectionFunc2 func(item ImplicitSurface2, ray *Ray2) float64
+
+// IntersectionVisitorFunc2 is called for each item found by an intersection
+// query.
+type IntersectionVisitorFunc2 func(item ImplicitSurface2)
+
+// ClosestIntersectionQueryResult2 is the result of a first-hit ray query.
+type ClosestIntersectionQueryResult2 struct {
+
+	// The item hit first, nil if the ray hits nothing.
+	item ImplicitSurface2
+
+	// Distance along the ray to the hit.
+	distance float64
+}
+
+// IntersectionQueryEngine2 is the interface for intersection queries over a
+// collection of items.
 type IntersectionQueryEngine2 interface {
+
+	// intersectsBox returns true if any item intersects the box.
+	intersectsBox(box *BoundingBox2D, testFunc BoxIntersectionTestFunc2) bool
+
+	// intersectsRay returns true if any item intersects the ray.
+	intersectsRay(ray *Ray2, testFunc RayIntersectionTestFunc2) bool
+
+	// forEachIntersectingItemBox visits every item intersecting the box.
+	forEachIntersectingItemBox(box *BoundingBox2D, testFunc BoxIntersectionTestFunc2, visitorFunc IntersectionVisitorFunc2)
+
+	// forEachIntersectingItemRay visits every item intersecting the ray.
+	forEachIntersectingItemRay(ray *Ray2, testFunc RayIntersectionTestFunc2, visitorFunc IntersectionVisitorFunc2)
+
+	// closestIntersection returns the item hit first by the ray.
+	closestIntersection(ray *Ray2, testFunc GetRayIntersectionFunc2) *ClosestIntersectionQueryResult2
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"sort"
+)
+
+// Keyframe2 is a 2-D rigid body pose at a given time.
+type Keyframe2 struct {
+	time        float64
+	translation *Vector3D.Vector3D
+	orientation float64
+}
+
+// KeyframeTrack2 is a time-sorted list of 2-D rigid body poses. Poses between
+// two keyframes are interpolated linearly. Outside the track the first or last
+// pose is held.
+type KeyframeTrack2 struct {
+	keyframes []*Keyframe2
+}
+
+func NewKeyframeTrack2() *KeyframeTrack2 {
+	return &KeyframeTrack2{
+		keyframes: make([]*Keyframe2, 0),
+	}
+}
+
+// addKeyframe inserts a pose at the given time, replacing an existing
+// keyframe at the same time. The orientation is in radians.
+func (k *KeyframeTrack2) addKeyframe(time float64, translation *Vector3D.Vector3D, orientation float64) {
+
+	keyframe := &Keyframe2{
+		time:        time,
+		translation: Vector3D.NewVector(translation.X, translation.Y, 0),
+		orientation: orientation,
+	}
+
+	i := sort.Search(len(k.keyframes), func(i int) bool { return k.keyframes[i].time >= time })
+	if i < len(k.keyframes) && k.keyframes[i].time == time {
+		k.keyframes[i] = keyframe
+		return
+	}
+
+	k.keyframes = append(k.keyframes, nil)
+	copy(k.keyframes[i+1:], k.keyframes[i:])
+	k.keyframes[i] = keyframe
+}
+
+// numberOfKeyframes returns the number of keyframes in the track.
+func (k *KeyframeTrack2) numberOfKeyframes() int {
+
+	return len(k.keyframes)
+}
+
+// sample returns the interpolated translation and orientation at the given time.
+func (k *KeyframeTrack2) sample(time float64) (*Vector3D.Vector3D, float64) {
+
+	n := len(k.keyframes)
+	if n == 0 {
+		return Vector3D.NewVector(0, 0, 0), 0
+	}
+
+	i := sort.Search(n, func(i int) bool { return k.keyframes[i].time > time })
+	if i == 0 {
+		first := k.keyframes[0]
+		return Vector3D.NewVector(first.translation.X, first.translation.Y, 0), first.orientation
+	}
+	if i == n {
+		last := k.keyframes[n-1]
+		return Vector3D.NewVector(last.translation.X, last.translation.Y, 0), last.orientation
+	}
+
+	a := k.keyframes[i-1]
+	b := k.keyframes[i]
+	t := (time - a.time) / (b.time - a.time)
+
+	translation := a.translation.Multiply(1 - t)
+	translation.AddScaledInPlace(b.translation, t)
+	return translation, (1-t)*a.orientation + t*b.orientation
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"sort"
+)
+
+// Keyframe3 is a rigid body pose at a given time.
+type Keyframe3 struct {
+	time        float64
+	translation *Vector3D.Vector3D
+	orientation *Quaternion
+}
+
+// KeyframeTrack3 is a time-sorted list of rigid body poses. Poses between two
+// keyframes are interpolated linearly for the translation and spherically for
+// the orientation. Outside the track the first or last pose is held.
+type KeyframeTrack3 struct {
+	keyframes []*Keyframe3
+}
+
+func NewKeyframeTrack3() *KeyframeTrack3 {
+	return &KeyframeTrack3{
+		keyframes: make([]*Keyframe3, 0),
+	}
+}
+
+// addKeyframe inserts a pose at the given time, replacing an existing
+// keyframe at the same time.
+func (k *KeyframeTrack3) addKeyframe(time float64, translation *Vector3D.Vector3D, orientation *Quaternion) {
+
+	keyframe := &Keyframe3{
+		time:        time,
+		translation: Vector3D.NewVector(translation.X, translation.Y, translation.Z),
+		orientation: orientation.normalized(),
+	}
+
+	i := sort.Search(len(k.keyframes), func(i int) bool { return k.keyframes[i].time >= time })
+	if i < len(k.keyframes) && k.keyframes[i].time == time {
+		k.keyframes[i] = keyframe
+		return
+	}
+
+	k.keyframes = append(k.keyframes, nil)
+	copy(k.keyframes[i+1:], k.keyframes[i:])
+	k.keyframes[i] = keyframe
+}
+
+// numberOfKeyframes returns the number of keyframes in the track.
+func (k *KeyframeTrack3) numberOfKeyframes() int {
+
+	return len(k.keyframes)
+}
+
+// sample returns the interpolated translation and orientation at the given time.
+func (k *KeyframeTrack3) sample(time float64) (*Vector3D.Vector3D, *Quaternion) {
+
+	n := len(k.keyframes)
+	if n == 0 {
+		return Vector3D.NewVector(0, 0, 0), newQuaternion()
+	}
+
+	i := sort.Search(n, func(i int) bool { return k.keyframes[i].time > time })
+	if i == 0 {
+		first := k.keyframes[0]
+		return Vector3D.NewVector(first.translation.X, first.translation.Y, first.translation.Z), first.orientation.normalized()
+	}
+	if i == n {
+		last := k.keyframes[n-1]
+		return Vector3D.NewVector(last.translation.X, last.translation.Y, last.translation.Z), last.orientation.normalized()
+	}
+
+	a := k.keyframes[i-1]
+	b := k.keyframes[i]
+	t := (time - a.time) / (b.time - a.time)
+
+	translation := a.translation.Multiply(1 - t)
+	translation.AddScaledInPlace(b.translation, t)
+	return translation, a.orientation.slerp(b.orientation, t)
+}
@@ -0,0 +1,57 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// KillZone3 represents a region of space where particles are removed at every
+// time-step. BoundingBox3D and BoundingBox2D can be used as kill zones directly.
+type KillZone3 interface {
+	contains(point *Vector3D.Vector3D) bool
+}
+
+// ImplicitSurfaceKillZone3 is a kill zone defined by the inside of a 3-D
+// implicit surface.
+type ImplicitSurfaceKillZone3 struct {
+	surface ImplicitSurface3
+}
+
+func NewImplicitSurfaceKillZone3(surface ImplicitSurface3) *ImplicitSurfaceKillZone3 {
+	return &ImplicitSurfaceKillZone3{surface: surface}
+}
+
+// contains returns true if the point is inside the surface.
+func (k *ImplicitSurfaceKillZone3) contains(point *Vector3D.Vector3D) bool {
+
+	return k.surface.signedDistance(point) <= 0.0
+}
+
+// ImplicitSurfaceKillZone2 is a kill zone defined by the inside of a 2-D
+// implicit surface.
+type ImplicitSurfaceKillZone2 struct {
+	surface ImplicitSurface2
+}
+
+func NewImplicitSurfaceKillZone2(surface ImplicitSurface2) *ImplicitSurfaceKillZone2 {
+	return &ImplicitSurfaceKillZone2{surface: surface}
+}
+
+// contains returns true if the point is inside the surface.
+func (k *ImplicitSurfaceKillZone2) contains(point *Vector3D.Vector3D) bool {
+
+	return k.surface.signedDistance(point) <= 0.0
+}
+
+// InvertedKillZone3 removes every particle outside of the wrapped zone, which
+// is handy to discard particles that left the simulation domain.
+type InvertedKillZone3 struct {
+	zone KillZone3
+}
+
+func NewInvertedKillZone3(zone KillZone3) *InvertedKillZone3 {
+	return &InvertedKillZone3{zone: zone}
+}
+
+// contains returns true if the point is outside the wrapped zone.
+func (k *InvertedKillZone3) contains(point *Vector3D.Vector3D) bool {
+
+	return !k.zone.contains(point)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"jimmykiang/fluidengine/constants"
+)
+
+// MeshVolumeParticleEmitter3 is a 3-D particle emitter that fills the inside
+// of a closed triangle mesh or any other implicit surface. The particles are
+// generated by a PoissonDiskPointGenerator3 instead of lying on a lattice, at
+// the density of the lattice the solvers assume for the spacing. Particles are
+// only spawned inside the given region. The emitter is one-shot.
+type MeshVolumeParticleEmitter3 struct {
+	surface                  ImplicitSurface3
+	particles                *ParticleSystemData3
+	bounds                   *BoundingBox3D
+	spacing                  float64
+	initialVel               *Vector3D.Vector3D
+	maxNumberOfParticles     float64
+	numberOfEmittedParticles float64
+	pointsGen                *PoissonDiskPointGenerator3
+	isEnabled                bool
+}
+
+func NewMeshVolumeParticleEmitter3(
+	surface ImplicitSurface3,
+	maxRegion *BoundingBox3D,
+	spacing float64,
+	initialVel *Vector3D.Vector3D,
+) *MeshVolumeParticleEmitter3 {
+	return &MeshVolumeParticleEmitter3{
+		surface:                  surface,
+		bounds:                   maxRegion,
+		spacing:                  spacing,
+		initialVel:               initialVel,
+		maxNumberOfParticles:     constants.KMaxSize,
+		numberOfEmittedParticles: 0,
+		pointsGen:                NewPoissonDiskPointGenerator3(),
+		isEnabled:                true,
+	}
+}
+
+// setSeed sets the seed of the point generator.
+func (e *MeshVolumeParticleEmitter3) setSeed(seed int64) {
+
+	e.pointsGen.setSeed(seed)
+}
+
+// setMaxNumberOfParticles sets the total number of particles the emitter may spawn.
+func (e *MeshVolumeParticleEmitter3) setMaxNumberOfParticles(maxNumberOfParticles float64) {
+
+	e.maxNumberOfParticles = maxNumberOfParticles
+}
+
+func (e *MeshVolumeParticleEmitter3) setTarget(particles *ParticleSystemData3) {
+
+	e.particles = particles
+}
+
+func (e *MeshVolumeParticleEmitter3) update(currentTimeInSeconds, timeIntervalInSeconds float64) {
+
+	if e.particles == nil || !e.isEnabled {
+		return
+	}
+
+	newPositions := make([]*Vector3D.Vector3D, 0)
+	newVelocities := make([]*Vector3D.Vector3D, 0)
+
+	// The samples fill the box around the surface and only those inside are
+	// kept, which leaves their density unchanged.
+	if box, ok := sampleRegion3(e.bounds, e.surface, 0); ok {
+		e.pointsGen.forEachPoint(box, e.spacing, &newPositions, func(points *([]*Vector3D.Vector3D), point *Vector3D.Vector3D) bool {
+			if e.numberOfEmittedParticles >= e.maxNumberOfParticles {
+				return false
+			}
+			if e.surface.signedDistance(point) <= 0 {
+				*points = append(*points, point)
+				newVelocities = append(newVelocities, Vector3D.NewVector(e.initialVel.X, e.initialVel.Y, e.initialVel.Z))
+				e.numberOfEmittedParticles++
+			}
+			return true
+		})
+	}
+
+	e.particles.addParticles(newPositions, newVelocities, nil)
+	e.isEnabled = false
+}
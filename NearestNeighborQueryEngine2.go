@@ -1,4 +1,25 @@
 package main
 
+import "jimmykiang/fluidengine/Vector3D"
+
+// NearestNeighborDistanceFunc2 returns the distance from the given point to
+// the item. It may be negative inside the item, e.g. a signed distance.
+type NearestNeighborDistanceFunc2 func(item ImplicitSurface2, point *Vector3D.Vector3D) float64
+
+// NearestNeighborQueryResult2 is the result of a nearest neighbor query.
+type NearestNeighborQueryResult2 struct {
+
+	// The nearest item, nil if there are no items.
+	item ImplicitSurface2
+
+	// Distance to the nearest item.
+	distance float64
+}
+
+// NearestNeighborQueryEngine2 is the interface for nearest neighbor queries
+// over a collection of items.
 type NearestNeighborQueryEngine2 interface {
+
+	// nearest returns the item with the smallest distance to the point.
+	nearest(point *Vector3D.Vector3D, distanceFunc NearestNeighborDistanceFunc2) *NearestNeighborQueryResult2
 }
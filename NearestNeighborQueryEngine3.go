@@ -1,4 +1,25 @@
 package main
 
+import "jimmykiang/fluidengine/Vector3D"
+
+// NearestNeighborDistanceFunc3 returns the distance from the given point to
+// the item. It may be negative inside the item, e.g. a signed distance.
+type NearestNeighborDistanceFunc3 func(item ImplicitSurface3, point *Vector3D.Vector3D) float64
+
+// NearestNeighborQueryResult3 is the result of a nearest neighbor query.
+type NearestNeighborQueryResult3 struct {
+
+	// The nearest item, nil if there are no items.
+	item ImplicitSurface3
+
+	// Distance to the nearest item.
+	distance float64
+}
+
+// NearestNeighborQueryEngine3 is the interface for nearest neighbor queries
+// over a collection of items.
 type NearestNeighborQueryEngine3 interface {
+
+	// nearest returns the item with the smallest distance to the point.
+	nearest(point *Vector3D.Vector3D, distanceFunc NearestNeighborDistanceFunc3) *NearestNeighborQueryResult3
 }
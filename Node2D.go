@@ -1,6 +1,9 @@
 package main
 
-import "jimmykiang/fluidengine/constants"
+import (
+	"jimmykiang/fluidengine/constants"
+	"strconv"
+)
 
 type Node2D struct {
 	flags string
@@ -24,3 +27,16 @@ func (n *Node2D) initLeaf(it float64, b *BoundingBox2D) {
 	n.child = it
 	n.bound = b
 }
+
+// initInternal initializes an internal node split along the given axis whose
+// second child is at index c. The first child always follows the node.
+func (n *Node2D) initInternal(axis int, c float64, b *BoundingBox2D) {
+	n.flags = strconv.Itoa(axis)
+	n.child = c
+	n.bound = b
+}
+
+// isLeaf returns true if the node holds an item.
+func (n *Node2D) isLeaf() bool {
+	return n.flags == "2"
+}
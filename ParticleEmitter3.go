@@ -0,0 +1,11 @@
+package main
+
+// ParticleEmitter3IF is the interface shared by particle emitters. Solvers set
+// the particle system as the emitter target once and update the emitter at the
+// beginning of every sub-timestep. SphSolver2 keeps its particles in a
+// ParticleSystemData3 as well, so the same emitters feed 2-D solvers as long as
+// they emit in the xy-plane.
+type ParticleEmitter3IF interface {
+	setTarget(particles *ParticleSystemData3)
+	update(currentTimeInSeconds, timeIntervalInSeconds float64)
+}
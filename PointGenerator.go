@@ -0,0 +1,27 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// PointGenerator3IF is the interface shared by 3-D point generators. The
+// callback receives every generated point and stops the iteration by
+// returning false.
+type PointGenerator3IF interface {
+	generate(boundingBox *BoundingBox3D, spacing float64, points *([]*Vector3D.Vector3D))
+	forEachPoint(
+		boundingBox *BoundingBox3D,
+		spacing float64,
+		points *[]*Vector3D.Vector3D,
+		callback func(*([]*Vector3D.Vector3D), *Vector3D.Vector3D) bool,
+	)
+}
+
+// PointGenerator2IF is the interface shared by 2-D point generators.
+type PointGenerator2IF interface {
+	generate(boundingBox *BoundingBox2D, spacing float64, points *([]*Vector3D.Vector3D))
+	forEachPoint(
+		boundingBox *BoundingBox2D,
+		spacing float64,
+		points *[]*Vector3D.Vector3D,
+		callback func(*([]*Vector3D.Vector3D), *Vector3D.Vector3D) bool,
+	)
+}
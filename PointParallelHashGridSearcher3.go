@@ -301,6 +301,33 @@ func (s *PointParallelHashGridSearcher3) forEachNearbyPoint3(
 	}
 }
 
+// hasNearbyPoint3 (3D) returns true if there is a point within given radius
+// around the origin. The radius must not exceed half the grid spacing.
+func (s *PointParallelHashGridSearcher3) hasNearbyPoint3(origin *Vector3D.Vector3D, radius float64) bool {
+
+	nearbyKeys := make([]int64, 8, 8)
+	s.getNearbyKeys3(origin, nearbyKeys)
+
+	queryRadiusSquared := radius * radius
+
+	for i := 0; i < 8; i++ {
+		nearbyKey := nearbyKeys[i]
+		start := s.startIndexTable[nearbyKey]
+		end := s.endIndexTable[nearbyKey]
+
+		// Empty bucket -- continue to next bucket.
+		if start == math.MaxInt64 {
+			continue
+		}
+		for j := start; j < end; j++ {
+			if s.points[j].Substract(origin).Squared() <= queryRadiusSquared {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *PointParallelHashGridSearcher3) getNearbyKeys(
 	position *Vector3D.Vector3D,
 	nearbyKeys []int64,
@@ -386,3 +413,76 @@ func (s *PointParallelHashGridSearcher3) getNearbyKeys3(
 		nearbyKeys[i] = s.getHashKeyFromBucketIndex3(nearbyBucketIndices[i])
 	}
 }
+
+// mortonOrder returns the point indices ordered by the Morton (Z-order) code of
+// their hash-grid bucket. It reuses the key-sorted indices of the last build,
+// so only the buckets themselves have to be sorted.
+func (s *PointParallelHashGridSearcher3) mortonOrder() []int64 {
+
+	numberOfPoints := len(s.sortedIndices)
+	order := make([]int64, 0, numberOfPoints)
+
+	if numberOfPoints == 0 {
+		return order
+	}
+
+	// Each run holds the range of key-sorted points that share a bucket.
+	type bucketRun struct {
+		code       int64
+		start, end int
+	}
+
+	runs := make([]bucketRun, 0)
+	start := 0
+	for i := 1; i <= numberOfPoints; i++ {
+		if i == numberOfPoints || s.keys[i] != s.keys[i-1] {
+			runs = append(runs, bucketRun{s.getMortonCodeFromHashKey(s.keys[start]), start, i})
+			start = i
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].code < runs[j].code })
+
+	for _, run := range runs {
+		order = append(order, s.sortedIndices[run.start:run.end]...)
+	}
+
+	return order
+}
+
+// remapIndices updates the stored point indices after the points have been
+// reordered, where newIndexOf maps every old index to its new one and points
+// holds the reordered points.
+func (s *PointParallelHashGridSearcher3) remapIndices(newIndexOf []int64, points []*Vector3D.Vector3D) {
+
+	for j := range s.sortedIndices {
+		s.sortedIndices[j] = newIndexOf[s.sortedIndices[j]]
+		s.points[j] = points[s.sortedIndices[j]]
+	}
+}
+
+// getMortonCodeFromHashKey returns the Morton code of the bucket with the
+// given hash key by interleaving the bits of its wrapped 3-D bucket index.
+func (s *PointParallelHashGridSearcher3) getMortonCodeFromHashKey(key int64) int64 {
+
+	resolutionX := int64(s.resolution.X)
+	resolutionY := int64(s.resolution.Y)
+
+	x := key % resolutionX
+	y := (key / resolutionX) % resolutionY
+	z := key / (resolutionX * resolutionY)
+
+	return spreadBits3(x) | spreadBits3(y)<<1 | spreadBits3(z)<<2
+}
+
+// spreadBits3 inserts two zero bits between each of the lower 21 bits of v.
+func spreadBits3(v int64) int64 {
+
+	v &= 0x1fffff
+	v = (v | v<<32) & 0x1f00000000ffff
+	v = (v | v<<16) & 0x1f0000ff0000ff
+	v = (v | v<<8) & 0x100f00f00f00f00f
+	v = (v | v<<4) & 0x10c30c30c30c30c3
+	v = (v | v<<2) & 0x1249249249249249
+	return v
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"math/rand"
+)
+
+// PointParticleEmitter2 represents a 2-D particle emitter.
+// This class emits particles from a single point in given direction, speed, and spreading angle.
+// The emitted velocities stay in the xy-plane.
+type PointParticleEmitter2 struct {
+	isEnabled                        bool
+	particles                        *ParticleSystemData3
+	onUpdateCallback                 OnBeginUpdateCallbackEmitter2
+	rng                              *rand.Rand
+	firstFrameTimeInSeconds          float64
+	numberOfEmittedParticles         int
+	maxNumberOfNewParticlesPerSecond int
+	maxNumberOfParticles             uint64
+	origin                           *Vector3D.Vector3D
+	direction                        *Vector3D.Vector3D
+	speed                            float64
+	spreadAngleInRadians             float64
+	seed                             uint32
+}
+
+// OnBeginUpdateCallbackEmitter2 is a brief Callback function signature type for update calls.
+// This type of callback function will take the emitter pointer, current
+// time, and time interval in seconds.
+type OnBeginUpdateCallbackEmitter2 func(
+	emitter *PointParticleEmitter2,
+	currentTime float64,
+	timeInterval float64,
+)
+
+func NewPointParticleEmitter2() *PointParticleEmitter2 {
+	return &PointParticleEmitter2{
+		isEnabled:                        true,
+		particles:                        nil,
+		onUpdateCallback:                 nil,
+		rng:                              rand.New(rand.NewSource(0)),
+		firstFrameTimeInSeconds:          0,
+		numberOfEmittedParticles:         0,
+		maxNumberOfNewParticlesPerSecond: 0,
+		maxNumberOfParticles:             18446744073709551615,
+		origin:                           Vector3D.NewVector(0, 0, 0),
+		direction:                        Vector3D.NewVector(0, 1, 0),
+		speed:                            1,
+		spreadAngleInRadians:             0,
+		seed:                             0,
+	}
+}
+
+func (e *PointParticleEmitter2) withOrigin(v *Vector3D.Vector3D) {
+
+	e.origin.Set(v)
+}
+
+func (e *PointParticleEmitter2) withDirection(v *Vector3D.Vector3D) {
+
+	e.direction.Set(v)
+}
+
+func (e *PointParticleEmitter2) withSpeed(s float64) {
+
+	e.speed = s
+}
+
+func (e *PointParticleEmitter2) withSpreadAngleInDegrees(d float64) {
+
+	e.spreadAngleInRadians = degreesToRadians(d)
+}
+
+func (e *PointParticleEmitter2) withMaxNumberOfNewParticlesPerSecond(m int) {
+
+	e.maxNumberOfNewParticlesPerSecond = m
+}
+
+// withSeed restarts the random sequence of the emitter from the given seed.
+func (e *PointParticleEmitter2) withSeed(seed uint32) {
+
+	e.seed = seed
+	e.rng = rand.New(rand.NewSource(int64(seed)))
+}
+
+// setOnBeginUpdateCallback sets the callback invoked at the beginning of each update.
+func (e *PointParticleEmitter2) setOnBeginUpdateCallback(callback OnBeginUpdateCallbackEmitter2) {
+
+	e.onUpdateCallback = callback
+}
+
+// setIsEnabled enables or disables the emitter.
+func (e *PointParticleEmitter2) setIsEnabled(isEnabled bool) {
+
+	e.isEnabled = isEnabled
+}
+
+func (e *PointParticleEmitter2) setTarget(particles *ParticleSystemData3) {
+
+	e.particles = particles
+}
+
+func (e *PointParticleEmitter2) update(currentTimeInSeconds float64, timeIntervalInSeconds float64) {
+
+	if e.onUpdateCallback != nil {
+		e.onUpdateCallback(e, currentTimeInSeconds, timeIntervalInSeconds)
+	}
+
+	if e.particles == nil || !e.isEnabled {
+		return
+	}
+
+	if e.numberOfEmittedParticles == 0 {
+
+		e.firstFrameTimeInSeconds = currentTimeInSeconds
+	}
+
+	elapsedTimeInSeconds := currentTimeInSeconds - e.firstFrameTimeInSeconds
+
+	newMaxTotalNumberOfEmittedParticles := math.Ceil((elapsedTimeInSeconds + timeIntervalInSeconds) *
+		float64(e.maxNumberOfNewParticlesPerSecond))
+
+	newMaxTotalNumberOfEmittedParticles = math.Min(
+		newMaxTotalNumberOfEmittedParticles,
+		float64(e.maxNumberOfParticles),
+	)
+
+	maxNumberOfNewParticles := newMaxTotalNumberOfEmittedParticles - float64(e.numberOfEmittedParticles)
+
+	if maxNumberOfNewParticles > 0 {
+
+		newPositions := make([]*Vector3D.Vector3D, 0)
+		newVelocities := make([]*Vector3D.Vector3D, 0)
+
+		e.emit(&newPositions, &newVelocities, maxNumberOfNewParticles)
+
+		e.particles.addParticles(newPositions, newVelocities, nil)
+
+		e.numberOfEmittedParticles += len(newPositions)
+	}
+}
+
+func (e *PointParticleEmitter2) emit(
+	newPositions *[]*Vector3D.Vector3D,
+	newVelocities *[]*Vector3D.Vector3D,
+	maxNewNumberOfParticles float64,
+) {
+
+	for i := 0; i < int(maxNewNumberOfParticles); i++ {
+
+		// Rotates the direction within the spread angle around the z-axis.
+		newAngleInRadians := (e.rng.Float64() - 0.5) * e.spreadAngleInRadians
+		newDirection := NewMatrix2x2Rotation(newAngleInRadians).MulVector(e.direction)
+		*newPositions = append(*newPositions, e.origin)
+		*newVelocities = append(*newVelocities, newDirection.Multiply(e.speed))
+	}
+}
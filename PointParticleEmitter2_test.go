@@ -0,0 +1,43 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"testing"
+)
+
+func TestSphSolver2PointParticleEmitter2Spread(t *testing.T) {
+
+	emitter := NewPointParticleEmitter2()
+	emitter.withOrigin(Vector3D.NewVector(0.5, 0.5, 0))
+	emitter.withDirection(Vector3D.NewVector(1, 0, 0))
+	emitter.withSpeed(1)
+	emitter.withSpreadAngleInDegrees(45)
+	emitter.withMaxNumberOfNewParticlesPerSecond(20)
+
+	domain := NewBoundingBox2D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(2, 2, 0))
+	solver := NewSphSolver2()
+	solver.particleSystemData.setTargetSpacing(0.05)
+	solver.setEmitter(emitter)
+	solver.setCollider(NewRigidBodyCollider2(NewBox2(domain)))
+
+	frame := NewFrame()
+	for ; frame.index < 10; frame.advance() {
+		solver.onUpdate(frame)
+	}
+
+	particles := solver.particleSystemData.particleSystemData
+	if particles.numberOfParticles == 0 {
+		t.Fatalf("expected the point emitter to feed the SPH solver")
+	}
+	velocities := particles.velocities()
+	spread := false
+	for i, p := range particles.positions() {
+		if p.Z != 0 || velocities[i].Z != 0 {
+			t.Fatalf("expected particles in the xy-plane, got %v with %v", p, velocities[i])
+		}
+		spread = spread || velocities[i].Y != 0
+	}
+	if !spread {
+		t.Errorf("expected the spread angle to deflect the particles")
+	}
+}
@@ -12,7 +12,7 @@ type PointParticleEmitter3 struct {
 	isEnabled                        bool
 	particles                        *ParticleSystemData3
 	onUpdateCallback                 OnBeginUpdateCallbackEmitter
-	rng                              int
+	rng                              *rand.Rand
 	firstFrameTimeInSeconds          float64
 	numberOfEmittedParticles         int
 	maxNumberOfNewParticlesPerSecond int
@@ -38,7 +38,7 @@ func NewPointParticleEmitter3() *PointParticleEmitter3 {
 		isEnabled:                        true,
 		particles:                        nil,
 		onUpdateCallback:                 nil,
-		rng:                              0,
+		rng:                              rand.New(rand.NewSource(0)),
 		firstFrameTimeInSeconds:          0,
 		numberOfEmittedParticles:         0,
 		maxNumberOfNewParticlesPerSecond: 0,
@@ -76,6 +76,25 @@ func (e *PointParticleEmitter3) withMaxNumberOfNewParticlesPerSecond(m int) {
 	e.maxNumberOfNewParticlesPerSecond = m
 }
 
+// withSeed restarts the random sequence of the emitter from the given seed.
+func (e *PointParticleEmitter3) withSeed(seed uint32) {
+
+	e.seed = seed
+	e.rng = rand.New(rand.NewSource(int64(seed)))
+}
+
+// setOnBeginUpdateCallback sets the callback invoked at the beginning of each update.
+func (e *PointParticleEmitter3) setOnBeginUpdateCallback(callback OnBeginUpdateCallbackEmitter) {
+
+	e.onUpdateCallback = callback
+}
+
+// setIsEnabled enables or disables the emitter.
+func (e *PointParticleEmitter3) setIsEnabled(isEnabled bool) {
+
+	e.isEnabled = isEnabled
+}
+
 func (e *PointParticleEmitter3) setTarget(particles *ParticleSystemData3) {
 
 	e.particles = particles
@@ -89,7 +108,11 @@ func (e *PointParticleEmitter3) onSetTarget(particles *ParticleSystemData3) {
 
 func (e *PointParticleEmitter3) update(currentTimeInSeconds float64, timeIntervalInSeconds float64) {
 
-	if e.particles == nil {
+	if e.onUpdateCallback != nil {
+		e.onUpdateCallback(e, currentTimeInSeconds, timeIntervalInSeconds)
+	}
+
+	if e.particles == nil || !e.isEnabled {
 		return
 	}
 
@@ -138,7 +161,7 @@ func (e *PointParticleEmitter3) emit(
 
 	for i := 0; i < int(maxNewNumberOfParticles); i++ {
 
-		newDirection := e.uniformSampleCone(rand.Float64(), rand.Float64(), e.direction, e.spreadAngleInRadians)
+		newDirection := e.uniformSampleCone(e.rng.Float64(), e.rng.Float64(), e.direction, e.spreadAngleInRadians)
 		*newPositions = append(*newPositions, e.origin)
 		*newVelocities = append(*newVelocities, newDirection.Multiply(e.speed))
 	}
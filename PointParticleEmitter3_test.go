@@ -0,0 +1,71 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"testing"
+)
+
+func newTestPointParticleEmitter3() *PointParticleEmitter3 {
+
+	// One particle per spacing along the stream.
+	emitter := NewPointParticleEmitter3()
+	emitter.withOrigin(Vector3D.NewVector(0.5, 0.5, 0))
+	emitter.withDirection(Vector3D.NewVector(1, 0, 0))
+	emitter.withSpeed(1)
+	emitter.withMaxNumberOfNewParticlesPerSecond(20)
+	return emitter
+}
+
+func TestSphSolverPointParticleEmitter3(t *testing.T) {
+
+	domain3 := NewBoundingBox3D(Vector3D.NewVector(0, 0, -1), Vector3D.NewVector(2, 2, 1))
+	solver3 := NewSphSolver3()
+	solver3.particleSystemData.setTargetSpacing(0.05)
+	solver3.setEmitter(newTestPointParticleEmitter3())
+	solver3.setCollider(NewRigidBodyCollider3(NewBox3(domain3)))
+
+	domain2 := NewBoundingBox2D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(2, 2, 0))
+	solver2 := NewSphSolver2()
+	solver2.particleSystemData.setTargetSpacing(0.05)
+	solver2.setEmitter(newTestPointParticleEmitter3())
+	solver2.setCollider(NewRigidBodyCollider2(NewBox2(domain2)))
+
+	frame := NewFrame()
+	for ; frame.index < 10; frame.advance() {
+		solver3.onUpdate(frame)
+		solver2.onUpdate(frame)
+	}
+
+	for _, particles := range []*ParticleSystemData3{
+		solver3.particleSystemData.particleSystemData,
+		solver2.particleSystemData.particleSystemData,
+	} {
+		if particles.numberOfParticles == 0 {
+			t.Fatalf("expected the point emitter to feed the SPH solver")
+		}
+		for _, p := range particles.positions() {
+			if p.X <= 0.5 || p.X > 2 {
+				t.Errorf("expected particles streaming along +x from the origin, got %v", p)
+			}
+		}
+	}
+}
+
+func TestPointParticleEmitter3Seed(t *testing.T) {
+
+	emit := func(seed int64) []*Vector3D.Vector3D {
+		emitter := newTestPointParticleEmitter3()
+		emitter.withSpreadAngleInDegrees(45)
+		emitter.withSeed(uint32(seed))
+
+		particles := NewParticleSystemData3()
+		emitter.setTarget(particles)
+		emitter.update(0, 0.5)
+		return particles.velocities()
+	}
+
+	if velocities := emit(7); len(velocities) != 10 {
+		t.Fatalf("expected 10 particles, got %d", len(velocities))
+	}
+	checkSeedReproducible(t, emit)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
+
+// poissonDiskGrid2 is the background grid of a 2-D Poisson-disk sampler. Its
+// cells are small enough to hold at most one sample each, so the minimum
+// distance test only has to look at the surrounding 5x5 cells.
+type poissonDiskGrid2 struct {
+	minDistance float64
+	cellSize    float64
+	cells       map[[2]int64]*Vector3D.Vector3D
+}
+
+func newPoissonDiskGrid2(minDistance float64) *poissonDiskGrid2 {
+	return &poissonDiskGrid2{
+		minDistance: minDistance,
+		cellSize:    minDistance / math.Sqrt2,
+		cells:       make(map[[2]int64]*Vector3D.Vector3D),
+	}
+}
+
+func (g *poissonDiskGrid2) cellIndex(point *Vector3D.Vector3D) [2]int64 {
+
+	return [2]int64{
+		int64(math.Floor(point.X / g.cellSize)),
+		int64(math.Floor(point.Y / g.cellSize)),
+	}
+}
+
+// hasNearbyPoint returns true if a sample lies closer than the minimum distance.
+func (g *poissonDiskGrid2) hasNearbyPoint(point *Vector3D.Vector3D) bool {
+
+	c := g.cellIndex(point)
+	minDistanceSquared := g.minDistance * g.minDistance
+
+	for j := c[1] - 2; j <= c[1]+2; j++ {
+		for i := c[0] - 2; i <= c[0]+2; i++ {
+			if other, ok := g.cells[[2]int64{i, j}]; ok && other.Substract(point).Squared() < minDistanceSquared {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tryAdd adds the point unless it is too close to an existing sample.
+func (g *poissonDiskGrid2) tryAdd(point *Vector3D.Vector3D) bool {
+
+	if g.hasNearbyPoint(point) {
+		return false
+	}
+	g.cells[g.cellIndex(point)] = point
+	return true
+}
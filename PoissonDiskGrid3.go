@@ -0,0 +1,60 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
+
+// poissonDiskGrid3 is the background grid of a 3-D Poisson-disk sampler. Its
+// cells are small enough to hold at most one sample each, so the minimum
+// distance test only has to look at the surrounding 5x5x5 cells.
+type poissonDiskGrid3 struct {
+	minDistance float64
+	cellSize    float64
+	cells       map[[3]int64]*Vector3D.Vector3D
+}
+
+func newPoissonDiskGrid3(minDistance float64) *poissonDiskGrid3 {
+	return &poissonDiskGrid3{
+		minDistance: minDistance,
+		cellSize:    minDistance / math.Sqrt(3),
+		cells:       make(map[[3]int64]*Vector3D.Vector3D),
+	}
+}
+
+func (g *poissonDiskGrid3) cellIndex(point *Vector3D.Vector3D) [3]int64 {
+
+	return [3]int64{
+		int64(math.Floor(point.X / g.cellSize)),
+		int64(math.Floor(point.Y / g.cellSize)),
+		int64(math.Floor(point.Z / g.cellSize)),
+	}
+}
+
+// hasNearbyPoint returns true if a sample lies closer than the minimum distance.
+func (g *poissonDiskGrid3) hasNearbyPoint(point *Vector3D.Vector3D) bool {
+
+	c := g.cellIndex(point)
+	minDistanceSquared := g.minDistance * g.minDistance
+
+	for k := c[2] - 2; k <= c[2]+2; k++ {
+		for j := c[1] - 2; j <= c[1]+2; j++ {
+			for i := c[0] - 2; i <= c[0]+2; i++ {
+				if other, ok := g.cells[[3]int64{i, j, k}]; ok && other.Substract(point).Squared() < minDistanceSquared {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// tryAdd adds the point unless it is too close to an existing sample.
+func (g *poissonDiskGrid3) tryAdd(point *Vector3D.Vector3D) bool {
+
+	if g.hasNearbyPoint(point) {
+		return false
+	}
+	g.cells[g.cellIndex(point)] = point
+	return true
+}
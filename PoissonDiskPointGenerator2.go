@@ -0,0 +1,109 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"math/rand"
+)
+
+// kPoissonDiskRadiusScale2 is the minimum distance between samples in units
+// of the spacing, chosen so that the number density matches the triangle
+// lattice of the spacing that SphSystemData2 assumes.
+const kPoissonDiskRadiusScale2 = 0.735
+
+// PoissonDiskPointGenerator2 is a 2-D Poisson-disk (blue noise) points
+// generator. It implements Bridson's algorithm and yields as many points per
+// area as TrianglePointGenerator for the same spacing. Every iteration
+// restarts from the seed, so the same box and spacing always yield the same
+// points.
+type PoissonDiskPointGenerator2 struct {
+	seed int64
+}
+
+func NewPoissonDiskPointGenerator2() *PoissonDiskPointGenerator2 {
+	return &PoissonDiskPointGenerator2{
+		seed: 0,
+	}
+}
+
+// setSeed sets the seed of the random sequence.
+func (g *PoissonDiskPointGenerator2) setSeed(seed int64) {
+
+	g.seed = seed
+}
+
+func (g *PoissonDiskPointGenerator2) generate(
+	boundingBox *BoundingBox2D,
+	spacing float64,
+	points *([]*Vector3D.Vector3D),
+) {
+	g.forEachPoint(
+		boundingBox,
+		spacing,
+		points,
+		g.callback,
+	)
+}
+
+func (g *PoissonDiskPointGenerator2) callback(points *([]*Vector3D.Vector3D), v *Vector3D.Vector3D) bool {
+	*points = append(*points, Vector3D.NewVector(v.X, v.Y, v.Z))
+	return true
+}
+
+// forEachPoint iterates Poisson-disk samples inside boundingBox that are at
+// least kPoissonDiskRadiusScale2 times spacing apart.
+func (g *PoissonDiskPointGenerator2) forEachPoint(
+	boundingBox *BoundingBox2D,
+	spacing float64,
+	points *[]*Vector3D.Vector3D,
+	callback func(*([]*Vector3D.Vector3D), *Vector3D.Vector3D) bool,
+) {
+
+	rng := rand.New(rand.NewSource(g.seed))
+	minDistance := kPoissonDiskRadiusScale2 * spacing
+	grid := newPoissonDiskGrid2(minDistance)
+
+	first := Vector3D.NewVector(
+		boundingBox.lowerCorner.X+rng.Float64()*boundingBox.width(),
+		boundingBox.lowerCorner.Y+rng.Float64()*boundingBox.height(),
+		0,
+	)
+	grid.tryAdd(first)
+	if !callback(points, first) {
+		return
+	}
+	active := []*Vector3D.Vector3D{first}
+
+	for len(active) > 0 {
+		i := rng.Intn(len(active))
+		center := active[i]
+
+		found := false
+		for k := 0; k < kPoissonDiskCandidates; k++ {
+
+			// Random point in the annulus between the minimum distance and
+			// twice the minimum distance.
+			angle := math.Pi * 2 * rng.Float64()
+			distance := minDistance * (1 + rng.Float64())
+			candidate := Vector3D.NewVector(
+				center.X+distance*math.Cos(angle),
+				center.Y+distance*math.Sin(angle),
+				0,
+			)
+
+			if boundingBox.contains(candidate) && grid.tryAdd(candidate) {
+				if !callback(points, candidate) {
+					return
+				}
+				active = append(active, candidate)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			active[i] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+}
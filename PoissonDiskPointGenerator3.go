@@ -0,0 +1,111 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"math/rand"
+)
+
+const (
+	// Candidates tried around an active sample before it is retired.
+	kPoissonDiskCandidates = 30
+
+	// kPoissonDiskRadiusScale3 is the minimum distance between samples in
+	// units of the spacing. Bridson's samples are sparser than a lattice with
+	// the same minimum distance, so the distance is scaled down until the
+	// number density matches the BCC lattice of the spacing, which
+	// SphSystemData3 assumes when deriving the particle mass.
+	kPoissonDiskRadiusScale3 = 0.665
+)
+
+// PoissonDiskPointGenerator3 is a 3-D Poisson-disk (blue noise) points
+// generator. It implements Bridson's algorithm and yields as many points per
+// volume as BccLatticePointGenerator for the same spacing. Every iteration
+// restarts from the seed, so the same box and spacing always yield the same
+// points.
+// https://www.cs.ubc.ca/~rbridson/docs/bridson-siggraph07-poissondisk.pdf
+type PoissonDiskPointGenerator3 struct {
+	seed int64
+}
+
+func NewPoissonDiskPointGenerator3() *PoissonDiskPointGenerator3 {
+	return &PoissonDiskPointGenerator3{
+		seed: 0,
+	}
+}
+
+// setSeed sets the seed of the random sequence.
+func (g *PoissonDiskPointGenerator3) setSeed(seed int64) {
+
+	g.seed = seed
+}
+
+func (g *PoissonDiskPointGenerator3) generate(
+	boundingBox *BoundingBox3D,
+	spacing float64,
+	points *([]*Vector3D.Vector3D),
+) {
+	g.forEachPoint(
+		boundingBox,
+		spacing,
+		points,
+		g.callback,
+	)
+}
+
+func (g *PoissonDiskPointGenerator3) callback(points *([]*Vector3D.Vector3D), v *Vector3D.Vector3D) bool {
+	*points = append(*points, Vector3D.NewVector(v.X, v.Y, v.Z))
+	return true
+}
+
+// forEachPoint iterates Poisson-disk samples inside boundingBox that are at
+// least kPoissonDiskRadiusScale3 times spacing apart.
+func (g *PoissonDiskPointGenerator3) forEachPoint(
+	boundingBox *BoundingBox3D,
+	spacing float64,
+	points *[]*Vector3D.Vector3D,
+	callback func(*([]*Vector3D.Vector3D), *Vector3D.Vector3D) bool,
+) {
+
+	rng := rand.New(rand.NewSource(g.seed))
+	minDistance := kPoissonDiskRadiusScale3 * spacing
+	grid := newPoissonDiskGrid3(minDistance)
+
+	first := uniformSampleBox3(boundingBox, rng)
+	grid.tryAdd(first)
+	if !callback(points, first) {
+		return
+	}
+	active := []*Vector3D.Vector3D{first}
+
+	for len(active) > 0 {
+		i := rng.Intn(len(active))
+		center := active[i]
+
+		found := false
+		for k := 0; k < kPoissonDiskCandidates; k++ {
+
+			// Random point in the spherical shell between the minimum distance
+			// and twice the minimum distance.
+			y := 1 - 2*rng.Float64()
+			r := math.Sqrt(math.Max(0, 1-y*y))
+			phi := math.Pi * 2 * rng.Float64()
+			distance := minDistance * (1 + rng.Float64())
+			candidate := center.Add(Vector3D.NewVector(r*math.Cos(phi), y, r*math.Sin(phi)).Multiply(distance))
+
+			if boundingBox.contains(candidate) && grid.tryAdd(candidate) {
+				if !callback(points, candidate) {
+					return
+				}
+				active = append(active, candidate)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			active[i] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"testing"
+)
+
+func TestPoissonDiskPointGenerator3(t *testing.T) {
+
+	box := NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(1, 1, 1))
+	generator := NewPoissonDiskPointGenerator3()
+	generator.setSeed(3)
+
+	points := make([]*Vector3D.Vector3D, 0)
+	generator.generate(box, 0.1, &points)
+
+	for _, p := range points {
+		if !box.contains(p) {
+			t.Fatalf("expected points inside the box, got %v", p)
+		}
+	}
+	minDistance := kPoissonDiskRadiusScale3 * 0.1
+	if d := minimumPairDistance(points); d < minDistance {
+		t.Errorf("expected a minimum distance of %v, got %v", minDistance, d)
+	}
+
+	// The samples are maximal: no probe is further than twice the minimum
+	// distance away from a sample.
+	probes := make([]*Vector3D.Vector3D, 0)
+	NewBccLatticePointGenerator().generate(box, 0.1, &probes)
+	for _, probe := range probes {
+		covered := false
+		for _, p := range points {
+			if p.DistanceTo(probe) < 2*minDistance {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			t.Fatalf("expected the box covered, found a gap at %v", probe)
+		}
+	}
+
+	// As many points per volume as the lattice of the same spacing.
+	if ratio := float64(len(points)) / float64(len(probes)); math.Abs(ratio-1) > 0.1 {
+		t.Errorf("expected the density of the BCC lattice, got %d points for %d lattice points", len(points), len(probes))
+	}
+
+	checkSeedReproducible(t, func(seed int64) []*Vector3D.Vector3D {
+		generator.setSeed(seed)
+		result := make([]*Vector3D.Vector3D, 0)
+		generator.generate(box, 0.1, &result)
+		return result
+	})
+
+	count := 0
+	generator.forEachPoint(box, 0.1, nil, func(_ *([]*Vector3D.Vector3D), _ *Vector3D.Vector3D) bool {
+		count++
+		return count < 10
+	})
+	if count != 10 {
+		t.Errorf("expected the callback to stop the iteration after 10 points, got %d", count)
+	}
+}
+
+func TestPoissonDiskPointGenerator2(t *testing.T) {
+
+	box := NewBoundingBox2D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(2, 1, 0))
+	generator := NewPoissonDiskPointGenerator2()
+
+	points := make([]*Vector3D.Vector3D, 0)
+	generator.generate(box, 0.05, &points)
+
+	for _, p := range points {
+		if !box.contains(p) || p.Z != 0 {
+			t.Fatalf("expected points inside the box, got %v", p)
+		}
+	}
+	if d := minimumPairDistance(points); d < kPoissonDiskRadiusScale2*0.05 {
+		t.Errorf("expected a minimum distance of %v, got %v", kPoissonDiskRadiusScale2*0.05, d)
+	}
+
+	// As many points per area as the lattice of the same spacing.
+	lattice := make([]*Vector3D.Vector3D, 0)
+	NewTrianglePointGenerator().generate(box, 0.05, &lattice)
+	if ratio := float64(len(points)) / float64(len(lattice)); math.Abs(ratio-1) > 0.1 {
+		t.Errorf("expected the density of the triangle lattice, got %d points for %d lattice points", len(points), len(lattice))
+	}
+
+	checkSeedReproducible(t, func(seed int64) []*Vector3D.Vector3D {
+		generator.setSeed(seed)
+		result := make([]*Vector3D.Vector3D, 0)
+		generator.generate(box, 0.05, &result)
+		return result
+	})
+}
+
+func TestVolumeParticleEmitter3PoissonDisk(t *testing.T) {
+
+	fill := func(pointsGen PointGenerator3IF) []*Vector3D.Vector3D {
+		surfaceSet := NewImplicitSurfaceSet3()
+		surfaceSet.addExplicitSurface(NewSphere3(Vector3D.NewVector(0, 0, 0), 0.5))
+		bounds := NewBoundingBox3D(Vector3D.NewVector(-0.6, -0.6, -0.6), Vector3D.NewVector(0.6, 0.6, 0.6))
+
+		particles := NewParticleSystemData3()
+		emitter := NewVolumeParticleEmitter3(surfaceSet, bounds, 0.1, Vector3D.NewVector(0, 0, 0))
+		emitter.setPointGenerator(pointsGen)
+		emitter.setTarget(particles)
+		emitter.update(0, 0.1)
+		return particles.positions()
+	}
+
+	positions := fill(NewPoissonDiskPointGenerator3())
+	if len(positions) == 0 {
+		t.Fatalf("expected the volume filled")
+	}
+	for _, p := range positions {
+		if p.Length() > 0.5 {
+			t.Fatalf("expected particles inside the sphere, got %v", p)
+		}
+	}
+	if d := minimumPairDistance(positions); d < kPoissonDiskRadiusScale3*0.1 {
+		t.Errorf("expected a minimum distance of %v, got %v", kPoissonDiskRadiusScale3*0.1, d)
+	}
+
+	// A drop-in replacement of the lattice starts at the same density.
+	lattice := fill(NewBccLatticePointGenerator())
+	if ratio := float64(len(positions)) / float64(len(lattice)); math.Abs(ratio-1) > 0.1 {
+		t.Errorf("expected the density of the BCC lattice, got %d particles for %d lattice particles", len(positions), len(lattice))
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// Ray2 is a 2-D ray with an origin and a unit direction.
+type Ray2 struct {
+
+	// The origin of the ray.
+	origin *Vector3D.Vector3D
+
+	// The direction of the ray.
+	direction *Vector3D.Vector3D
+}
+
+// NewRay2 constructs a ray starting at origin. The direction is normalized.
+func NewRay2(origin, direction *Vector3D.Vector3D) *Ray2 {
+	return &Ray2{
+		origin:    origin,
+		direction: direction.Normalize(),
+	}
+}
+
+// pointAt returns the point on the ray at distance t from the origin.
+func (r *Ray2) pointAt(t float64) *Vector3D.Vector3D {
+
+	return r.origin.Add(r.direction.Multiply(t))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
+
+const (
+	// kMaxSphereTracingSteps bounds the ray marching of surfaces without an
+	// analytic ray intersection.
+	kMaxSphereTracingSteps = 512
+
+	// kSphereTracingEpsilon is the distance at which a marched ray counts as
+	// hitting the surface.
+	kSphereTracingEpsilon = 1e-10
+)
+
+// Ray3 is a 3-D ray with an origin and a unit direction.
+type Ray3 struct {
+
+	// The origin of the ray.
+	origin *Vector3D.Vector3D
+
+	// The direction of the ray.
+	direction *Vector3D.Vector3D
+}
+
+// NewRay3 constructs a ray starting at origin. The direction is normalized.
+func NewRay3(origin, direction *Vector3D.Vector3D) *Ray3 {
+	return &Ray3{
+		origin:    origin,
+		direction: direction.Normalize(),
+	}
+}
+
+// pointAt returns the point on the ray at distance t from the origin.
+func (r *Ray3) pointAt(t float64) *Vector3D.Vector3D {
+
+	return r.origin.Add(r.direction.Multiply(t))
+}
+
+// sphereTrace3 marches the ray by the distance to a surface, which never
+// steps over the surface as long as distanceFunc does not overestimate the
+// distance. The march is limited to the given bounds, or to
+// kMaxSphereTracingSteps steps if bound is nil. It returns the distance
+// along the ray to the hit and whether there is one.
+func sphereTrace3(ray *Ray3, bound *BoundingBox3D, distanceFunc func(point *Vector3D.Vector3D) float64) (float64, bool) {
+
+	t, tEnd := 0.0, math.MaxFloat64
+	if bound != nil {
+		bbRayIntersection := bound.closestIntersection(ray)
+		if !bbRayIntersection.isIntersecting {
+			return 0, false
+		}
+
+		t, tEnd = bbRayIntersection.tNear, bbRayIntersection.tFar
+		if bound.contains(ray.origin) {
+			t, tEnd = 0, bbRayIntersection.tNear
+		}
+	}
+
+	for i := 0; i < kMaxSphereTracingSteps && t <= tEnd+kSphereTracingEpsilon; i++ {
+
+		d := distanceFunc(ray.pointAt(t))
+		if d < kSphereTracingEpsilon {
+			return t, true
+		}
+		t += d
+	}
+	return 0, false
+}
@@ -9,41 +9,222 @@ import (
 // This struct implements 2-D rigid body collider. The collider can only take
 // rigid body motion with linear and rotational velocities.
 type RigidBodyCollider2 struct {
-	surface             Surface2IF
-	frictionCoefficient float64
+	surface Surface2IF
+	// Contact material of the surface.
+	material *ColliderMaterial
+	// Materials of parts of the surface looked up by the contact point.
+	surfaceMaterials []*surfaceMaterial2
 	// Angular velocity of the rigid body.
-	angularVelocity float64
-	linearVelocity  *Vector3D.Vector3D
+	angularVelocity          float64
+	linearVelocity           *Vector3D.Vector3D
+	onUpdateCallbackCollider OnBeginUpdateCallbackCollider2
+	// Keyframed poses driving the surface transform. Velocities are derived
+	// from the track when it is set.
+	keyframeTrack *KeyframeTrack2
+	// Contacts resolved since the last update and their accumulated impulse
+	// and angular impulse around the surface translation.
+	isRecordingContacts bool
+	recordedContacts    []*ColliderContact
+	totalImpulse        *Vector3D.Vector3D
+	totalAngularImpulse float64
+	lastTimeInterval    float64
 }
 
+// OnBeginUpdateCallbackCollider2 is a brief Callback function signature type for update calls.
+// This type of callback function will take the collider pointer, current
+// time, and time interval in seconds.
+type OnBeginUpdateCallbackCollider2 func(
+	rigidBodyCollider *RigidBodyCollider2,
+	currentTime float64,
+	timeInterval float64,
+)
+
 func NewRigidBodyCollider2(surface Surface2IF) *RigidBodyCollider2 {
 	return &RigidBodyCollider2{
-		surface:             surface,
-		frictionCoefficient: 0,
-		linearVelocity:      Vector3D.NewVector(0, 0, 0),
+		surface:          surface,
+		material:         NewColliderMaterial(),
+		surfaceMaterials: make([]*surfaceMaterial2, 0),
+		linearVelocity:   Vector3D.NewVector(0, 0, 0),
+		keyframeTrack:    nil,
+
+		isRecordingContacts: false,
+		recordedContacts:    make([]*ColliderContact, 0),
+		totalImpulse:        Vector3D.NewVector(0, 0, 0),
+		totalAngularImpulse: 0,
+		lastTimeInterval:    0,
+	}
+}
+
+// setFrictionCoefficient sets both the static and the dynamic friction coefficient.
+func (c *RigidBodyCollider2) setFrictionCoefficient(frictionCoefficient float64) {
+
+	c.material.setStaticFrictionCoefficient(frictionCoefficient)
+	c.material.setDynamicFrictionCoefficient(frictionCoefficient)
+}
+
+// setStaticFrictionCoefficient sets the friction coefficient below which contacts stick.
+func (c *RigidBodyCollider2) setStaticFrictionCoefficient(frictionCoefficient float64) {
+
+	c.material.setStaticFrictionCoefficient(frictionCoefficient)
+}
+
+// setDynamicFrictionCoefficient sets the friction coefficient of sliding contacts.
+func (c *RigidBodyCollider2) setDynamicFrictionCoefficient(frictionCoefficient float64) {
+
+	c.material.setDynamicFrictionCoefficient(frictionCoefficient)
+}
+
+// setRestitutionCoefficient overrides the solver restitution for this collider.
+func (c *RigidBodyCollider2) setRestitutionCoefficient(restitutionCoefficient float64) {
+
+	c.material.setNormalRestitutionCoefficient(restitutionCoefficient)
+}
+
+// setTangentialRestitutionCoefficient sets the fraction of the tangential
+// velocity kept after an impact.
+func (c *RigidBodyCollider2) setTangentialRestitutionCoefficient(restitutionCoefficient float64) {
+
+	c.material.setTangentialRestitutionCoefficient(restitutionCoefficient)
+}
+
+// setMaterial sets the contact material of the whole surface.
+func (c *RigidBodyCollider2) setMaterial(material *ColliderMaterial) {
+
+	c.material = material
+}
+
+// setSurfaceMaterial assigns a material to contacts on the given part of the
+// collider surface, given in the collider surface frame.
+func (c *RigidBodyCollider2) setSurfaceMaterial(surface ImplicitSurface2, material *ColliderMaterial) {
+
+	for _, surfaceMaterial := range c.surfaceMaterials {
+		if surfaceMaterial.surface == surface {
+			surfaceMaterial.material = material
+			return
+		}
+	}
+	c.surfaceMaterials = append(c.surfaceMaterials, &surfaceMaterial2{surface: surface, material: material})
+}
+
+// materialAt returns the material of the registered surface part closest to
+// the contact point, or the collider material if no part is within tolerance.
+func (c *RigidBodyCollider2) materialAt(point *Vector3D.Vector3D, tolerance float64) *ColliderMaterial {
+
+	if len(c.surfaceMaterials) == 0 {
+		return c.material
+	}
+
+	pointLocal := c.surface.getTransform().toLocal(point)
+	material := c.material
+	minDistance := tolerance
+	for _, surfaceMaterial := range c.surfaceMaterials {
+		if d := math.Abs(surfaceMaterial.surface.signedDistance(pointLocal)); d <= minDistance {
+			material = surfaceMaterial.material
+			minDistance = d
+		}
 	}
+	return material
 }
 
-func (c *RigidBodyCollider2) update(seconds float64) {
+// setOnBeginUpdateCallback sets the callback invoked at the beginning of each update.
+func (c *RigidBodyCollider2) setOnBeginUpdateCallback(callback OnBeginUpdateCallbackCollider2) {
 
-	// do nothing?
+	c.onUpdateCallbackCollider = callback
 }
 
-// Resolves collision for given point.
+// setLinearVelocity sets the linear velocity of the rigid body.
+func (c *RigidBodyCollider2) setLinearVelocity(linearVelocity *Vector3D.Vector3D) {
+
+	c.linearVelocity = Vector3D.NewVector(linearVelocity.X, linearVelocity.Y, 0)
+}
+
+// setAngularVelocity sets the angular velocity in radians per second around the
+// surface translation.
+func (c *RigidBodyCollider2) setAngularVelocity(angularVelocity float64) {
+
+	c.angularVelocity = angularVelocity
+}
+
+// setKeyframeTrack sets the keyframes driving the surface transform. A nil
+// track returns the collider to velocity integration.
+func (c *RigidBodyCollider2) setKeyframeTrack(track *KeyframeTrack2) {
+
+	c.keyframeTrack = track
+}
+
+// update invokes the update callback and then moves the surface to the end of
+// the time interval, either along the keyframe track or by integrating the
+// linear and angular velocities.
+func (c *RigidBodyCollider2) update(currentTime, timeIntervalInSeconds float64) {
+
+	c.clearContacts()
+	c.lastTimeInterval = timeIntervalInSeconds
+
+	if c.onUpdateCallbackCollider != nil {
+		c.onUpdateCallbackCollider(c, currentTime, timeIntervalInSeconds)
+	}
+
+	transform := c.surface.getTransform()
+
+	if c.keyframeTrack != nil && c.keyframeTrack.numberOfKeyframes() > 0 {
+		translation, orientation := c.keyframeTrack.sample(currentTime + timeIntervalInSeconds)
+
+		if timeIntervalInSeconds > 0 {
+			c.linearVelocity = translation.Substract(transform.translation).Divide(timeIntervalInSeconds)
+			c.angularVelocity = (orientation - transform.orientation) / timeIntervalInSeconds
+		}
+
+		transform.setTranslation(translation)
+		transform.setOrientation(orientation)
+		return
+	}
+
+	if c.linearVelocity.LengthSquared() > 0 {
+		transform.setTranslation(transform.translation.Add(c.linearVelocity.Multiply(timeIntervalInSeconds)))
+	}
+
+	if c.angularVelocity != 0 {
+		transform.setOrientation(transform.orientation + c.angularVelocity*timeIntervalInSeconds)
+	}
+}
+
+// Resolves collision for given point. The path from oldPosition to the new
+// position is swept first so that fast particles stop at the time of impact
+// instead of tunneling through thin surfaces.
 func (c *RigidBodyCollider2) resolveCollision(
+	particleIndex int64,
+	mass float64,
 	radius float64,
 	restitutionCoefficient float64,
+	oldPosition *Vector3D.Vector3D,
 	newPosition **Vector3D.Vector3D,
 	newVelocity **Vector3D.Vector3D,
 ) {
 
+	if impactPoint, ok := c.sweptImpactPoint(oldPosition, *newPosition, radius); ok {
+		(*newPosition).Set(impactPoint)
+	}
+
 	colliderPoint := c.NewColliderQueryResult()
 
 	c.getClosestPoint(c.surface, *newPosition, colliderPoint)
+	c.resolveCollisionWithQuery(particleIndex, mass, colliderPoint, radius, restitutionCoefficient, newPosition, newVelocity)
+}
+
+// resolveCollisionWithQuery resolves collision for given point against an
+// already computed closest point query.
+func (c *RigidBodyCollider2) resolveCollisionWithQuery(
+	particleIndex int64,
+	mass float64,
+	colliderPoint *ColliderQueryResult,
+	radius float64,
+	restitutionCoefficient float64,
+	newPosition **Vector3D.Vector3D,
+	newVelocity **Vector3D.Vector3D,
+) {
 
 	// Check if the new position is penetrating the surface.
-	x := c.isPenetrating(colliderPoint, *newPosition, radius)
-	if x {
+	if c.isPenetrating(colliderPoint, *newPosition, radius) {
 
 		// Target point is the closest non-penetrating position from the
 		// new position.
@@ -52,35 +233,31 @@ func (c *RigidBodyCollider2) resolveCollision(
 		targetPoint := colliderPoint.point.Add(rt)
 		colliderVelAtTargetPoint := colliderPoint.velocity
 
-		// Get new candidate relative velocity from the target point.
-
+		// Get new candidate relative velocity from the target point and
+		// apply the contact material to it.
 		relativeVel := (*newVelocity).Substract(colliderVelAtTargetPoint)
-		normalDotRelativeVel := targetNormal.DotProduct(relativeVel)
-		relativeVelN := targetNormal.Multiply(normalDotRelativeVel)
-		relativeVelT := relativeVel.Substract(relativeVelN)
-
-		// Check if the velocity is facing opposite direction of the surface normal.
-
-		if normalDotRelativeVel < 0.0 {
-
-			// Apply restitution coefficient to the surface normal component of the velocity.
-
-			deltaRelativeVelN := relativeVelN.Multiply(-restitutionCoefficient - 1.0)
-			relativeVelN := relativeVelN.Multiply(-restitutionCoefficient)
-
-			// Apply friction to the tangential component of the velocity From Bridson et
-			// al., Robust Treatment of Collisions, Contact and Friction for Cloth Animation,
-			// 2002. http://graphics.stanford.edu/papers/cloth-sig02/cloth.pdf
-
-			if relativeVelT.LengthSquared() > 0.0 {
+		var impulse *Vector3D.Vector3D
 
-				a := c.frictionCoefficient * deltaRelativeVelN.Length() / relativeVelT.Length()
-				frictionScale := math.Max(1-a, 0)
-				relativeVelT = relativeVelT.Multiply(frictionScale)
+		if targetNormal.DotProduct(relativeVel) < 0.0 {
+			material := c.materialAt(colliderPoint.point, radius)
+			newRelativeVel := material.relativeVelocityAfterImpact(relativeVel, targetNormal, restitutionCoefficient)
+			impulse = newRelativeVel.Substract(relativeVel).Multiply(mass)
+			c.accumulateImpulse(colliderPoint.point, impulse)
 
-			}
 			// Reassemble the components.
-			*newVelocity = relativeVelN.Add(relativeVelT).Add(colliderVelAtTargetPoint)
+			*newVelocity = newRelativeVel.Add(colliderVelAtTargetPoint)
+		}
+		if c.isRecordingContacts {
+			if impulse == nil {
+				impulse = Vector3D.NewVector(0, 0, 0)
+			}
+			c.recordedContacts = append(c.recordedContacts, &ColliderContact{
+				particleIndex:    particleIndex,
+				point:            colliderPoint.point,
+				normal:           targetNormal,
+				relativeVelocity: relativeVel,
+				impulse:          impulse,
+			})
 		}
 		// Geometric fix
 		//*newPosition = (*newPosition).Set(targetPoint)
@@ -106,6 +283,68 @@ func (c *RigidBodyCollider2) getClosestPoint(surface Surface2IF, queryPoint *Vec
 	result.velocity = c.velocityAt(queryPoint)
 }
 
+// sweptImpactPoint returns the first point where the segment from oldPosition
+// to newPosition crosses the surface. The segment is marched by the distance
+// to the surface, which never steps over it. Segments starting inside or on
+// the surface are left to the closest point resolution.
+func (c *RigidBodyCollider2) sweptImpactPoint(oldPosition, newPosition *Vector3D.Vector3D, radius float64) (*Vector3D.Vector3D, bool) {
+
+	displacement := newPosition.Substract(oldPosition)
+	length := displacement.Length()
+	if length == 0 || c.surface.isInside(oldPosition) {
+		return nil, false
+	}
+
+	ray := NewRay2(oldPosition, displacement)
+	t := c.surface.closestDistance(oldPosition)
+	if t <= kSphereTracingEpsilon {
+		return nil, false
+	}
+
+	for i := 0; i < kMaxSphereTracingSteps; i++ {
+		if t > length {
+			return nil, false
+		}
+		point := ray.pointAt(t)
+		distance := c.surface.closestDistance(point)
+		if distance <= kSphereTracingEpsilon {
+			return point, true
+		}
+		t += distance
+	}
+
+	// At shallow angles the march converges too slowly, so the rest of the
+	// segment is sampled at the particle radius and the first inside sample
+	// is bisected down to the surface.
+	spacing := math.Max(radius, length/kMaxSphereTracingSteps)
+	for t < length {
+		tNext := math.Min(t+spacing, length)
+		if c.surface.isInside(ray.pointAt(tNext)) {
+			return ray.pointAt(c.bisectSurface(ray, t, tNext)), true
+		}
+		t = tNext
+	}
+	return nil, false
+}
+
+// bisectSurface narrows the interval between tOutside and tInside down to
+// the surface crossing and returns the outside end.
+func (c *RigidBodyCollider2) bisectSurface(ray *Ray2, tOutside, tInside float64) float64 {
+
+	for tInside-tOutside > kSphereTracingEpsilon {
+		tMid := 0.5 * (tOutside + tInside)
+		if tMid <= tOutside || tMid >= tInside {
+			break
+		}
+		if c.surface.isInside(ray.pointAt(tMid)) {
+			tInside = tMid
+		} else {
+			tOutside = tMid
+		}
+	}
+	return tOutside
+}
+
 // Returns true if given point is in the opposite side of the surface.
 func (c *RigidBodyCollider2) isPenetrating(colliderPoint *ColliderQueryResult, position *Vector3D.Vector3D, radius float64) bool {
 
@@ -123,3 +362,55 @@ func (c *RigidBodyCollider2) velocityAt(point *Vector3D.Vector3D) *Vector3D.Vect
 	a := Vector3D.NewVector(-r.Y, r.X, 0).Multiply(c.angularVelocity)
 	return a.Add(c.linearVelocity)
 }
+
+// setIsRecordingContacts enables or disables keeping the individual contacts.
+// Recording is off by default; the total force and torque are accumulated
+// either way.
+func (c *RigidBodyCollider2) setIsRecordingContacts(isRecording bool) {
+
+	c.isRecordingContacts = isRecording
+}
+
+// contacts returns the contacts resolved since the last update.
+func (c *RigidBodyCollider2) contacts() []*ColliderContact {
+
+	return c.recordedContacts
+}
+
+// totalForce returns the force the particles exerted on the collider during
+// the last sub-timestep.
+func (c *RigidBodyCollider2) totalForce() *Vector3D.Vector3D {
+
+	if c.lastTimeInterval <= 0 {
+		return Vector3D.NewVector(0, 0, 0)
+	}
+	return c.totalImpulse.Multiply(-1 / c.lastTimeInterval)
+}
+
+// totalTorque returns the counter-clockwise torque around the surface
+// translation the particles exerted on the collider during the last
+// sub-timestep.
+func (c *RigidBodyCollider2) totalTorque() float64 {
+
+	if c.lastTimeInterval <= 0 {
+		return 0
+	}
+	return -c.totalAngularImpulse / c.lastTimeInterval
+}
+
+// clearContacts discards the contacts and accumulated impulses.
+func (c *RigidBodyCollider2) clearContacts() {
+
+	c.recordedContacts = c.recordedContacts[:0]
+	c.totalImpulse = Vector3D.NewVector(0, 0, 0)
+	c.totalAngularImpulse = 0
+}
+
+// accumulateImpulse adds an impulse applied at point to the total impulse and
+// to the angular impulse around the surface translation.
+func (c *RigidBodyCollider2) accumulateImpulse(point, impulse *Vector3D.Vector3D) {
+
+	center := c.surface.getTransform().translation
+	c.totalImpulse.AddInPlace(impulse)
+	c.totalAngularImpulse += (point.X-center.X)*impulse.Y - (point.Y-center.Y)*impulse.X
+}
@@ -23,8 +23,8 @@ type SPHParticleSystemSolver2 struct {
 	gravity                   *Vector3D.Vector3D
 	newPositions              []*Vector3D.Vector3D
 	newVelocities             []*Vector3D.Vector3D
-	collider                  *RigidBodyCollider2
-	emitter                   *VolumeParticleEmitter2
+	collider                  Collider2IF
+	emitter                   ParticleEmitter3IF
 	wind                      *ConstantVectorField3
 }
 
@@ -58,6 +58,6 @@ func (p *SPHParticleSystemSolver2) setIsUsingFixedSubTimeSteps(isUsing bool) {
 	p.isUsingFixedSubTimeSteps = isUsing
 }
 
-func (p *SPHParticleSystemSolver2) SetCollider(collider *RigidBodyCollider2) {
+func (p *SPHParticleSystemSolver2) SetCollider(collider Collider2IF) {
 	p.collider = collider
 }
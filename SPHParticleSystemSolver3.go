@@ -24,8 +24,8 @@ type SPHParticleSystemSolver3 struct {
 	gravity                   *Vector3D.Vector3D
 	newPositions              []*Vector3D.Vector3D
 	newVelocities             []*Vector3D.Vector3D
-	collider                  *RigidBodyCollider3
-	emitter                   *VolumeParticleEmitter3
+	collider                  Collider3IF
+	emitter                   ParticleEmitter3IF
 	wind                      *ConstantVectorField3
 }
 
@@ -60,6 +60,6 @@ func (p *SPHParticleSystemSolver3) setIsUsingFixedSubTimeSteps(isUsing bool) {
 	p.isUsingFixedSubTimeSteps = isUsing
 }
 
-func (p *SPHParticleSystemSolver3) SetCollider(collider *RigidBodyCollider3) {
+func (p *SPHParticleSystemSolver3) SetCollider(collider Collider3IF) {
 	p.collider = collider
 }
@@ -59,13 +59,13 @@ func (s *SphSolver2) setPseudoViscosityCoefficient(newPseudoViscosityCoefficient
 	s.pseudoViscosityCoefficient = math.Max(newPseudoViscosityCoefficient, 0)
 }
 
-func (s *SphSolver2) setEmitter(newEmitter *VolumeParticleEmitter2) {
+func (s *SphSolver2) setEmitter(newEmitter ParticleEmitter3IF) {
 
 	s.particleSystemSolver2.emitter = newEmitter
-	newEmitter.setTarget(s.particleSystemData)
+	newEmitter.setTarget(s.particleSystemData.particleSystemData)
 }
 
-func (s *SphSolver2) setCollider(collider *RigidBodyCollider2) {
+func (s *SphSolver2) setCollider(collider Collider2IF) {
 
 	s.particleSystemSolver2.SetCollider(collider)
 }
@@ -108,9 +108,9 @@ func (s *SphSolver2) numberOfSubTimeSteps(timeIntervalInSeconds float64) int64 {
 	return int64(math.Ceil(timeIntervalInSeconds / desiredTimeStep))
 }
 
-func (s *SphSolver2) updateEmitter(f float64) {
+func (s *SphSolver2) updateEmitter(timeStepInSeconds float64) {
 
-	s.particleSystemSolver2.emitter.onUpdate()
+	s.particleSystemSolver2.emitter.update(s.particleSystemSolver2.currentTime, timeStepInSeconds)
 }
 
 func (s *SphSolver2) advanceTimeStep(timeIntervalInSeconds float64) {
@@ -125,6 +125,7 @@ func (s *SphSolver2) advanceTimeStep(timeIntervalInSeconds float64) {
 
 		//println("numSteps:", numSteps)
 		s.onAdvanceTimeStep(actualTimeInterval)
+		s.particleSystemSolver2.currentTime += actualTimeInterval
 		remainingTime -= actualTimeInterval
 	}
 }
@@ -140,7 +141,7 @@ func (s *SphSolver2) onAdvanceTimeStep(timeStepInSeconds float64) {
 }
 
 func (s *SphSolver2) updateCollider(timeStepInSeconds float64) {
-	s.particleSystemSolver2.collider.update(timeStepInSeconds)
+	s.particleSystemSolver2.collider.update(s.particleSystemSolver2.currentTime, timeStepInSeconds)
 }
 
 func (s *SphSolver2) resize(size int64) {
@@ -158,12 +159,15 @@ func (s *SphSolver2) beginAdvanceTimeStep(timeStepInSeconds float64) {
 	// Clear forces.
 	forces := s.particleSystemData.forces()
 	for i := 0; i < len(forces); i++ {
-		forces[i] = Vector3D.NewVector(0, 0, 0)
+		*forces[i] = Vector3D.Vector3D{}
 	}
 
 	// Update collider and emitter.
 	s.updateCollider(timeStepInSeconds)
-	s.particleSystemSolver2.emitter.onUpdate()
+	s.updateEmitter(timeStepInSeconds)
+
+	// Discard particles that entered a kill zone.
+	s.particleSystemData.particleSystemData.removeParticlesInKillZones()
 
 	// Allocate buffers.
 	n := s.particleSystemData.particleSystemData.numberOfParticles
@@ -175,6 +179,7 @@ func (s *SphSolver2) beginAdvanceTimeStep(timeStepInSeconds float64) {
 func (s *SphSolver2) onBeginAdvanceTimeStep(seconds float64) {
 	particles := s.particleSystemData
 	particles.buildNeighborSearcher()
+	particles.particleSystemData.updateSpatialOrder()
 	particles.buildNeighborLists()
 	particles.updateDensities()
 }
@@ -205,9 +210,9 @@ func (s *SphSolver2) accumulateExternalForces(timeStepInSeconds float64) {
 		// Wind forces.
 		relativeVel := velocities[i].Substract(s.particleSystemSolver2.wind.value)
 		//force.Add(relativeVel.Multiply(-s.particleSystemSolver2.dragCoefficient))
-		force = force.Add(relativeVel.Multiply(-s.particleSystemSolver2.dragCoefficient))
+		force.AddScaledInPlace(relativeVel, -s.particleSystemSolver2.dragCoefficient)
 
-		forces[i] = forces[i].Add(force)
+		forces[i].AddInPlace(force)
 	}
 }
 
@@ -230,10 +235,10 @@ func (s *SphSolver2) accumulateViscosityForce() {
 		for _, j := range neighbors {
 			dist := x[i].DistanceTo(x[j])
 
-			a := s.viscosityCoefficient * massSquared * kernel.secondDerivative(dist)
-			b := v[j].Substract(v[i])
-			c := b.Divide(d[j])
-			f[i] = f[i].Add(c.Multiply(a))
+			// f[i] += (v[j] - v[i]) / d[j] * a, without temporary vectors.
+			a := s.viscosityCoefficient * massSquared * kernel.secondDerivative(dist) / d[j]
+			f[i].AddScaledInPlace(v[j], a)
+			f[i].AddScaledInPlace(v[i], -a)
 		}
 	}
 }
@@ -288,13 +293,14 @@ func (s *SphSolver2) accumulatePressureForceInternal(
 			dist := positions[i].DistanceTo(positions[j])
 
 			if dist > 0.0 {
-				a := positions[j].Substract(positions[i])
-				dir := a.Divide(dist)
 				b := massSquared * (pressures[i]/(densities[i]*densities[i]) +
 					pressures[j]/(densities[j]*densities[j]))
-				c := kernel.gradient(dist, dir)
-				d := c.Multiply(b)
-				pressureForces[i] = pressureForces[i].Substract(d)
+
+				// Same as pressureForces[i] -= kernel.gradient(dist, dir) * b with
+				// dir = (positions[j] - positions[i]) / dist, without temporary vectors.
+				a := kernel.firstDerivative(dist) * b / dist
+				pressureForces[i].AddScaledInPlace(positions[j], a)
+				pressureForces[i].AddScaledInPlace(positions[i], -a)
 			}
 		}
 	}
@@ -312,18 +318,13 @@ func (s *SphSolver2) timeIntegration(timeStepsInSeconds float64) {
 
 		// Integrate velocity first.
 		newVelocity := s.particleSystemSolver2.newVelocities[i]
-		forceMultiply := forces[i].Multiply(timeStepsInSeconds)
-		forceMultiplyDivide := forceMultiply.Divide(mass)
-		newVelocity = velocities[i].Add(forceMultiplyDivide)
-		s.particleSystemSolver2.newVelocities[i] = newVelocity
-		//s.particleSystemData.particleSystemData.vectorDataList[s.particleSystemData.particleSystemData.velocityIdx][i] = newVelocity
+		newVelocity.Set(velocities[i])
+		newVelocity.AddScaledInPlace(forces[i], timeStepsInSeconds/mass)
 
 		// Integrate position.
 		newPosition := s.particleSystemSolver2.newPositions[i]
-		newVelocityMultiply := newVelocity.Multiply(timeStepsInSeconds)
-		newPosition = positions[i].Add(newVelocityMultiply)
-		s.particleSystemSolver2.newPositions[i] = newPosition
-		//s.particleSystemData.particleSystemData.vectorDataList[s.particleSystemData.particleSystemData.positionIdx][i] = newPosition
+		newPosition.Set(positions[i])
+		newPosition.AddScaledInPlace(newVelocity, timeStepsInSeconds)
 	}
 }
 
@@ -331,11 +332,16 @@ func (s *SphSolver2) resolveCollision() {
 
 	numberOfParticles := s.particleSystemData.particleSystemData.numberOfParticles
 	radius := s.particleSystemData.particleSystemData.radius
+	mass := s.particleSystemData.particleSystemData.mass
+	positions := s.particleSystemData.positions()
 
 	for i := 0; i < int(numberOfParticles); i++ {
 		s.particleSystemSolver2.collider.resolveCollision(
+			int64(i),
+			mass,
 			radius,
 			s.particleSystemSolver2.restitutionCoefficient,
+			positions[i],
 			&s.particleSystemSolver2.newPositions[i],
 			&s.particleSystemSolver2.newVelocities[i],
 		)
@@ -356,8 +362,8 @@ func (s *SphSolver2) endAdvanceTimeStep(timeStepInSeconds float64) {
 
 	for i := 0; i < int(n); i++ {
 
-		positions[i] = s.particleSystemSolver2.newPositions[i]
-		velocities[i] = s.particleSystemSolver2.newVelocities[i]
+		positions[i].Set(s.particleSystemSolver2.newPositions[i])
+		velocities[i].Set(s.particleSystemSolver2.newVelocities[i])
 	}
 
 	s.onEndAdvanceTimeStep(timeStepInSeconds)
@@ -386,12 +392,12 @@ func (s *SphSolver2) computePseudoViscosity(timeStepInSeconds float64) {
 	mass := particles.particleSystemData.mass
 	kernel := NewSphSpikyKernel2(s.particleSystemData.kernelRadius)
 
-	smoothedVelocities := make([]*Vector3D.Vector3D, 0, 0)
+	smoothedVelocities := make([]Vector3D.Vector3D, numberOfParticles)
 
 	for i := 0; i < int(numberOfParticles); i++ {
 
 		weightSum := 0.0
-		smoothedVelocity := Vector3D.NewVector(0, 0, 0)
+		smoothedVelocity := &smoothedVelocities[i]
 		neighbors := s.particleSystemData.particleSystemData.neighborLists[i]
 
 		for _, j := range neighbors {
@@ -399,19 +405,16 @@ func (s *SphSolver2) computePseudoViscosity(timeStepInSeconds float64) {
 			wj := mass / d[j] * kernel.operatorKernel(dist)
 			weightSum += wj
 
-			a := v[j].Multiply(wj)
-			smoothedVelocity = smoothedVelocity.Add(a)
+			smoothedVelocity.AddScaledInPlace(v[j], wj)
 		}
 
 		wi := mass / d[i]
 		weightSum += wi
-		a := v[i].Multiply(wi)
-		smoothedVelocity = smoothedVelocity.Add(a)
+		smoothedVelocity.AddScaledInPlace(v[i], wi)
 
 		if weightSum > 0.0 {
-			smoothedVelocity = smoothedVelocity.Divide(weightSum)
+			smoothedVelocity.DivideInPlace(weightSum)
 		}
-		smoothedVelocities = append(smoothedVelocities, smoothedVelocity)
 	}
 
 	factor := timeStepInSeconds * s.pseudoViscosityCoefficient
@@ -423,7 +426,9 @@ func (s *SphSolver2) computePseudoViscosity(timeStepInSeconds float64) {
 
 	for i := int64(0); i < numberOfParticles; i++ {
 
-		v[i] = mathHelper.Lerp(v[i], smoothedVelocities[i], factor)
+		// Same as mathHelper.Lerp(v[i], smoothedVelocities[i], factor) in place.
+		v[i].MultiplyInPlace(1 - factor)
+		v[i].AddScaledInPlace(&smoothedVelocities[i], factor)
 	}
 }
 
@@ -433,11 +438,13 @@ func (p *SphSolver2) saveParticleDataXyUpdate(particles *ParticleSystemData3, fr
 
 	x := make([]float64, n)
 	y := make([]float64, n)
+	ids := make([]int64, n)
 
 	for i := int64(0); i < n; i++ {
 
 		x[i] = particles.positions()[i].X
 		y[i] = particles.positions()[i].Y
+		ids[i] = particles.ids()[i]
 	}
 
 	path, err := os.Getwd()
@@ -447,7 +454,9 @@ func (p *SphSolver2) saveParticleDataXyUpdate(particles *ParticleSystemData3, fr
 	const conf = "animation/WaterDrop"
 	fileNameX := fmt.Sprintf("data.#point2,%04d,x.npy", frame.index)
 	fileNameY := fmt.Sprintf("data.#point2,%04d,y.npy", frame.index)
+	fileNameId := fmt.Sprintf("data.#point2,%04d,id.npy", frame.index)
 
 	saveNpy(path, conf, fileNameX, x, frame)
 	saveNpy(path, conf, fileNameY, y, frame)
+	saveNpyInt64(path, conf, fileNameId, ids, frame)
 }
@@ -58,13 +58,13 @@ func (s *SphSolver3) setPseudoViscosityCoefficient(newPseudoViscosityCoefficient
 
 	s.pseudoViscosityCoefficient = math.Max(newPseudoViscosityCoefficient, 0)
 }
-func (s *SphSolver3) setEmitter(newEmitter *VolumeParticleEmitter3) {
+func (s *SphSolver3) setEmitter(newEmitter ParticleEmitter3IF) {
 
 	s.particleSystemSolver3.emitter = newEmitter
-	newEmitter.setTarget(s.particleSystemData)
+	newEmitter.setTarget(s.particleSystemData.particleSystemData)
 }
 
-func (s *SphSolver3) setCollider(collider *RigidBodyCollider3) {
+func (s *SphSolver3) setCollider(collider Collider3IF) {
 
 	s.particleSystemSolver3.SetCollider(collider)
 }
@@ -91,9 +91,9 @@ func (s *SphSolver3) onInitialize() {
 	s.updateEmitter(0.0)
 }
 
-func (s *SphSolver3) updateEmitter(f float64) {
+func (s *SphSolver3) updateEmitter(timeStepInSeconds float64) {
 
-	s.particleSystemSolver3.emitter.onUpdate()
+	s.particleSystemSolver3.emitter.update(s.particleSystemSolver3.currentTime, timeStepInSeconds)
 }
 
 func (p *SphSolver3) saveParticleDataXyUpdate(particles *ParticleSystemData3, frame *Frame) {
@@ -102,11 +102,13 @@ func (p *SphSolver3) saveParticleDataXyUpdate(particles *ParticleSystemData3, fr
 
 	x := make([]float64, n)
 	y := make([]float64, n)
+	ids := make([]int64, n)
 
 	for i := int64(0); i < n; i++ {
 
 		x[i] = particles.positions()[i].X
 		y[i] = particles.positions()[i].Y
+		ids[i] = particles.ids()[i]
 	}
 
 	path, err := os.Getwd()
@@ -116,9 +118,11 @@ func (p *SphSolver3) saveParticleDataXyUpdate(particles *ParticleSystemData3, fr
 	const conf = "animation/SphSolver3WaterDrop"
 	fileNameX := fmt.Sprintf("data.#point2,%04d,x.npy", frame.index)
 	fileNameY := fmt.Sprintf("data.#point2,%04d,y.npy", frame.index)
+	fileNameId := fmt.Sprintf("data.#point2,%04d,id.npy", frame.index)
 
 	saveNpy(path, conf, fileNameX, x, frame)
 	saveNpy(path, conf, fileNameY, y, frame)
+	saveNpyInt64(path, conf, fileNameId, ids, frame)
 }
 
 func (s *SphSolver3) advanceTimeStep(timeIntervalInSeconds float64) {
@@ -135,6 +139,7 @@ func (s *SphSolver3) advanceTimeStep(timeIntervalInSeconds float64) {
 		println("remainingTime:", remainingTime)
 		println("")
 		s.onAdvanceTimeStep(actualTimeInterval)
+		s.particleSystemSolver3.currentTime += actualTimeInterval
 		remainingTime -= actualTimeInterval
 	}
 }
@@ -185,16 +190,13 @@ func (s *SphSolver3) timeIntegration(timeStepsInSeconds float64) {
 
 		// Integrate velocity first.
 		newVelocity := s.particleSystemSolver3.newVelocities[i]
-		forceMultiply := forces[i].Multiply(timeStepsInSeconds)
-		forceMultiplyDivide := forceMultiply.Divide(mass)
-		newVelocity = velocities[i].Add(forceMultiplyDivide)
-		s.particleSystemSolver3.newVelocities[i] = newVelocity
+		newVelocity.Set(velocities[i])
+		newVelocity.AddScaledInPlace(forces[i], timeStepsInSeconds/mass)
 
 		// Integrate position.
 		newPosition := s.particleSystemSolver3.newPositions[i]
-		newVelocityMultiply := newVelocity.Multiply(timeStepsInSeconds)
-		newPosition = positions[i].Add(newVelocityMultiply)
-		s.particleSystemSolver3.newPositions[i] = newPosition
+		newPosition.Set(positions[i])
+		newPosition.AddScaledInPlace(newVelocity, timeStepsInSeconds)
 	}
 }
 
@@ -203,12 +205,15 @@ func (s *SphSolver3) beginAdvanceTimeStep(timeStepInSeconds float64) {
 	// Clear forces.
 	forces := s.particleSystemData.forces()
 	for i := 0; i < len(forces); i++ {
-		forces[i] = Vector3D.NewVector(0, 0, 0)
+		*forces[i] = Vector3D.Vector3D{}
 	}
 
 	// Update collider and emitter.
 	s.updateCollider(timeStepInSeconds)
-	s.particleSystemSolver3.emitter.onUpdate()
+	s.updateEmitter(timeStepInSeconds)
+
+	// Discard particles that entered a kill zone.
+	s.particleSystemData.particleSystemData.removeParticlesInKillZones()
 
 	// Allocate buffers.
 	n := s.particleSystemData.particleSystemData.numberOfParticles
@@ -218,7 +223,7 @@ func (s *SphSolver3) beginAdvanceTimeStep(timeStepInSeconds float64) {
 }
 
 func (s *SphSolver3) updateCollider(timeStepInSeconds float64) {
-	s.particleSystemSolver3.collider.update(timeStepInSeconds)
+	s.particleSystemSolver3.collider.update(s.particleSystemSolver3.currentTime, timeStepInSeconds)
 }
 
 func (s *SphSolver3) resize(size int64) {
@@ -233,6 +238,7 @@ func (s *SphSolver3) resize(size int64) {
 func (s *SphSolver3) onBeginAdvanceTimeStep(seconds float64) {
 	particles := s.particleSystemData
 	particles.buildNeighborSearcher()
+	particles.particleSystemData.updateSpatialOrder()
 	particles.buildNeighborLists()
 	particles.updateDensities()
 }
@@ -268,10 +274,10 @@ func (s *SphSolver3) accumulateViscosityForce() {
 		for _, j := range neighbors {
 			dist := x[i].DistanceTo(x[j])
 
-			a := s.viscosityCoefficient * massSquared * kernel.secondDerivative(dist)
-			b := v[j].Substract(v[i])
-			c := b.Divide(d[j])
-			f[i] = f[i].Add(c.Multiply(a))
+			// f[i] += (v[j] - v[i]) / d[j] * a, without temporary vectors.
+			a := s.viscosityCoefficient * massSquared * kernel.secondDerivative(dist) / d[j]
+			f[i].AddScaledInPlace(v[j], a)
+			f[i].AddScaledInPlace(v[i], -a)
 		}
 	}
 }
@@ -304,13 +310,14 @@ func (s *SphSolver3) accumulatePressureForceInternal(
 			dist := positions[i].DistanceTo(positions[j])
 
 			if dist > 0.0 {
-				a := positions[j].Substract(positions[i])
-				dir := a.Divide(dist)
 				b := massSquared * (pressures[i]/(densities[i]*densities[i]) +
 					pressures[j]/(densities[j]*densities[j]))
-				c := kernel.gradient(dist, dir)
-				d := c.Multiply(b)
-				pressureForces[i] = pressureForces[i].Substract(d)
+
+				// Same as pressureForces[i] -= kernel.gradient(dist, dir) * b with
+				// dir = (positions[j] - positions[i]) / dist, without temporary vectors.
+				a := kernel.firstDerivative(dist) * b / dist
+				pressureForces[i].AddScaledInPlace(positions[j], a)
+				pressureForces[i].AddScaledInPlace(positions[i], -a)
 			}
 		}
 	}
@@ -352,9 +359,9 @@ func (s *SphSolver3) accumulateExternalForces(timeStepInSeconds float64) {
 		// Wind forces.
 		relativeVel := velocities[i].Substract(s.particleSystemSolver3.wind.value)
 		//force.Add(relativeVel.Multiply(-s.particleSystemSolver2.dragCoefficient))
-		force = force.Add(relativeVel.Multiply(-s.particleSystemSolver3.dragCoefficient))
+		force.AddScaledInPlace(relativeVel, -s.particleSystemSolver3.dragCoefficient)
 
-		forces[i] = forces[i].Add(force)
+		forces[i].AddInPlace(force)
 	}
 }
 
@@ -362,12 +369,17 @@ func (s *SphSolver3) resolveCollision() {
 
 	numberOfParticles := s.particleSystemData.particleSystemData.numberOfParticles
 	radius := s.particleSystemData.particleSystemData.radius
+	mass := s.particleSystemData.particleSystemData.mass
+	positions := s.particleSystemData.positions()
 
 	for i := 0; i < int(numberOfParticles); i++ {
 
 		s.particleSystemSolver3.collider.resolveCollision(
+			int64(i),
+			mass,
 			radius,
 			s.particleSystemSolver3.restitutionCoefficient,
+			positions[i],
 			&s.particleSystemSolver3.newPositions[i],
 			&s.particleSystemSolver3.newVelocities[i],
 		)
@@ -382,8 +394,8 @@ func (s *SphSolver3) endAdvanceTimeStep(timeStepInSeconds float64) {
 
 	for i := 0; i < int(n); i++ {
 
-		positions[i] = s.particleSystemSolver3.newPositions[i]
-		velocities[i] = s.particleSystemSolver3.newVelocities[i]
+		positions[i].Set(s.particleSystemSolver3.newPositions[i])
+		velocities[i].Set(s.particleSystemSolver3.newVelocities[i])
 	}
 
 	s.onEndAdvanceTimeStep(timeStepInSeconds)
@@ -412,12 +424,12 @@ func (s *SphSolver3) computePseudoViscosity(timeStepInSeconds float64) {
 	mass := particles.particleSystemData.mass
 	kernel := NewSphSpikyKernel3(s.particleSystemData.kernelRadius)
 
-	smoothedVelocities := make([]*Vector3D.Vector3D, 0, 0)
+	smoothedVelocities := make([]Vector3D.Vector3D, numberOfParticles)
 
 	for i := 0; i < int(numberOfParticles); i++ {
 
 		weightSum := 0.0
-		smoothedVelocity := Vector3D.NewVector(0, 0, 0)
+		smoothedVelocity := &smoothedVelocities[i]
 		neighbors := s.particleSystemData.particleSystemData.neighborLists[i]
 
 		for _, j := range neighbors {
@@ -425,19 +437,16 @@ func (s *SphSolver3) computePseudoViscosity(timeStepInSeconds float64) {
 			wj := mass / d[j] * kernel.operatorKernel(dist)
 			weightSum += wj
 
-			a := v[j].Multiply(wj)
-			smoothedVelocity = smoothedVelocity.Add(a)
+			smoothedVelocity.AddScaledInPlace(v[j], wj)
 		}
 
 		wi := mass / d[i]
 		weightSum += wi
-		a := v[i].Multiply(wi)
-		smoothedVelocity = smoothedVelocity.Add(a)
+		smoothedVelocity.AddScaledInPlace(v[i], wi)
 
 		if weightSum > 0.0 {
-			smoothedVelocity = smoothedVelocity.Divide(weightSum)
+			smoothedVelocity.DivideInPlace(weightSum)
 		}
-		smoothedVelocities = append(smoothedVelocities, smoothedVelocity)
 	}
 
 	factor := timeStepInSeconds * s.pseudoViscosityCoefficient
@@ -449,6 +458,8 @@ func (s *SphSolver3) computePseudoViscosity(timeStepInSeconds float64) {
 
 	for i := int64(0); i < numberOfParticles; i++ {
 
-		v[i] = mathHelper.Lerp(v[i], smoothedVelocities[i], factor)
+		// Same as mathHelper.Lerp(v[i], smoothedVelocities[i], factor) in place.
+		v[i].MultiplyInPlace(1 - factor)
+		v[i].AddScaledInPlace(&smoothedVelocities[i], factor)
 	}
 }
@@ -22,8 +22,6 @@ type SphSystemData2 struct {
 	kernelRadiusOverTargetSpacing float64
 	//SPH kernel radius in meters.
 	kernelRadius float64
-	pressureIdx  int64
-	densityIdx   int64
 }
 
 func NewSphSystemData2() *SphSystemData2 {
@@ -33,12 +31,10 @@ func NewSphSystemData2() *SphSystemData2 {
 		targetSpacing:                 0.2,
 		kernelRadiusOverTargetSpacing: 1.8,
 		kernelRadius:                  1,
-		pressureIdx:                   0,
-		densityIdx:                    0,
 	}
 
-	s.densityIdx = (*s).particleSystemData.addScalarData()
-	s.pressureIdx = (*s).particleSystemData.addScalarData()
+	(*s).particleSystemData.addScalarData(kDensityChannel, 0)
+	(*s).particleSystemData.addScalarData(kPressureChannel, 0)
 	s.setTargetSpacing(s.targetSpacing)
 
 	return s
@@ -103,36 +99,7 @@ func (s *SphSystemData2) addParticle(newPosition, newVelocity, newForce *Vector3
 
 func (s *SphSystemData2) addParticles(newPositions, newVelocities, newForces []*Vector3D.Vector3D) {
 
-	var oldNumberOfParticles int64 = (*s).particleSystemData.numberOfParticles
-	var newNumberOfParticles int64 = oldNumberOfParticles + int64(len(newPositions))
-	(*s).particleSystemData.numberOfParticles = newNumberOfParticles
-
-	s.particleSystemData.resize(newNumberOfParticles)
-
-	pos := (*s).positions()
-	vel := (*s).velocities()
-	frc := (*s).forces()
-
-	if (len(newPositions)) > 0 {
-		for i := 0; i < len(newPositions); i++ {
-
-			pos[int64(i)+oldNumberOfParticles] = newPositions[i]
-		}
-	}
-
-	if (len(newVelocities)) > 0 {
-		for i := 0; i < len(newPositions); i++ {
-
-			vel[int64(i)+oldNumberOfParticles] = newVelocities[i]
-		}
-	}
-
-	if (len(newPositions)) > 0 {
-		for i := 0; i < len(newForces); i++ {
-
-			frc[int64(i)+oldNumberOfParticles] = newForces[i]
-		}
-	}
+	(*s).particleSystemData.addParticles(newPositions, newVelocities, newForces)
 }
 
 func (s *SphSystemData2) positions() []*Vector3D.Vector3D {
@@ -150,12 +117,17 @@ func (s *SphSystemData2) forces() []*Vector3D.Vector3D {
 	return (*s).particleSystemData.vectorDataList[s.particleSystemData.forceIdx]
 }
 
+func (s *SphSystemData2) ids() []int64 {
+
+	return (*s).particleSystemData.ids()
+}
+
 func (s *SphSystemData2) densities() []float64 {
-	return (*s).particleSystemData.scalarDataList[s.densityIdx]
+	return (*s).particleSystemData.scalarData(kDensityChannel)
 }
 
 func (s *SphSystemData2) pressures() []float64 {
-	return (*s).particleSystemData.scalarDataList[s.pressureIdx]
+	return (*s).particleSystemData.scalarData(kPressureChannel)
 }
 
 func (s *SphSystemData2) buildNeighborSearcher() {
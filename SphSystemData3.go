@@ -6,6 +6,12 @@ import (
 	"math"
 )
 
+// Names of the SPH particle channels shared by SphSystemData2 and SphSystemData3.
+const (
+	kDensityChannel  = "density"
+	kPressureChannel = "pressure"
+)
+
 // SphSystemData3 is a 3-D SPH particle system data.
 // Extends ParticleSystemData3 to specialize the data model for SPH.
 // It includes density and pressure array as a default particle attribute, and
@@ -22,8 +28,6 @@ type SphSystemData3 struct {
 	kernelRadiusOverTargetSpacing float64
 	//SPH kernel radius in meters.
 	kernelRadius float64
-	pressureIdx  int64
-	densityIdx   int64
 }
 
 func NewSphSystemData3() *SphSystemData3 {
@@ -33,12 +37,10 @@ func NewSphSystemData3() *SphSystemData3 {
 		targetSpacing:                 0.2,
 		kernelRadiusOverTargetSpacing: 1.8,
 		kernelRadius:                  1,
-		pressureIdx:                   0,
-		densityIdx:                    0,
 	}
 
-	s.densityIdx = (*s).particleSystemData.addScalarData()
-	s.pressureIdx = (*s).particleSystemData.addScalarData()
+	(*s).particleSystemData.addScalarData(kDensityChannel, 0)
+	(*s).particleSystemData.addScalarData(kPressureChannel, 0)
 	s.setTargetSpacing(s.targetSpacing)
 
 	return s
@@ -91,36 +93,7 @@ func (s *SphSystemData3) computeMass() {
 
 func (s *SphSystemData3) addParticles(newPositions, newVelocities, newForces []*Vector3D.Vector3D) {
 
-	var oldNumberOfParticles int64 = (*s).particleSystemData.numberOfParticles
-	var newNumberOfParticles int64 = oldNumberOfParticles + int64(len(newPositions))
-	(*s).particleSystemData.numberOfParticles = newNumberOfParticles
-
-	s.particleSystemData.resize(newNumberOfParticles)
-
-	pos := (*s).positions()
-	vel := (*s).velocities()
-	frc := (*s).forces()
-
-	if (len(newPositions)) > 0 {
-		for i := 0; i < len(newPositions); i++ {
-
-			pos[int64(i)+oldNumberOfParticles] = newPositions[i]
-		}
-	}
-
-	if (len(newVelocities)) > 0 {
-		for i := 0; i < len(newPositions); i++ {
-
-			vel[int64(i)+oldNumberOfParticles] = newVelocities[i]
-		}
-	}
-
-	if (len(newPositions)) > 0 {
-		for i := 0; i < len(newForces); i++ {
-
-			frc[int64(i)+oldNumberOfParticles] = newForces[i]
-		}
-	}
+	(*s).particleSystemData.addParticles(newPositions, newVelocities, newForces)
 }
 
 func (s *SphSystemData3) positions() []*Vector3D.Vector3D {
@@ -138,12 +111,17 @@ func (s *SphSystemData3) forces() []*Vector3D.Vector3D {
 	return (*s).particleSystemData.vectorDataList[s.particleSystemData.forceIdx]
 }
 
+func (s *SphSystemData3) ids() []int64 {
+
+	return (*s).particleSystemData.ids()
+}
+
 func (s *SphSystemData3) densities() []float64 {
-	return (*s).particleSystemData.scalarDataList[s.densityIdx]
+	return (*s).particleSystemData.scalarData(kDensityChannel)
 }
 
 func (s *SphSystemData3) pressures() []float64 {
-	return (*s).particleSystemData.scalarDataList[s.pressureIdx]
+	return (*s).particleSystemData.scalarData(kPressureChannel)
 }
 
 func (s *SphSystemData3) buildNeighborSearcher() {
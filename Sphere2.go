@@ -37,6 +37,11 @@ func (s *Sphere2) getTransform() *Transform2 {
 	return s.transform
 }
 
+// getIsNormalFlipped returns true if the normal is flipped.
+func (s *Sphere2) getIsNormalFlipped() bool {
+	return s.isNormalFlipped
+}
+
 // isBounded returns true if bounding box can be defined.
 func (s *Sphere2) isBounded() bool {
 
@@ -1,6 +1,9 @@
 package main
 
-import "jimmykiang/fluidengine/Vector3D"
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
 
 // Sphere3 is a 3-D sphere geometry.
 // Represents 3-D sphere geometry which extends Surface3 by
@@ -48,7 +51,7 @@ func (s *Sphere3) boundingBox() *BoundingBox3D {
 
 func (s *Sphere3) signedDistance(otherPoint *Vector3D.Vector3D) float64 {
 
-	x := s.closestPoint(s.transform.toLocal(otherPoint))
+	x := s.closestPoint(otherPoint)
 
 	inside := s.isInside(otherPoint)
 
@@ -75,6 +78,25 @@ func (s *Sphere3) closestPoint(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D
 	return s.transform.toWorld(d)
 }
 
+// Returns the closest distance from the given point otherPoint to the surface.
+func (s *Sphere3) closestDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	otherPointLocal := s.transform.toLocal(otherPoint)
+	d := s.closestPointLocal(otherPointLocal)
+	return otherPointLocal.Substract(d).Length()
+}
+
+// closestNormal returns the normal to the closest point on the surface from the given
+// point.
+func (s *Sphere3) closestNormal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	result := s.transform.toWorldDirection(s.closestNormalLocal(s.transform.toLocal(otherPoint)))
+	if s.isNormalFlipped {
+		result = result.Multiply(-1)
+	}
+	return result
+}
+
 // Returns true if otherPoint is inside the volume defined by the surface.
 func (s *Sphere3) isInside(otherPoint *Vector3D.Vector3D) bool {
 
@@ -114,3 +136,49 @@ func (s *Sphere3) isInsideLocal(otherPointLocal *Vector3D.Vector3D) bool {
 func (s *Sphere3) getTransform() *Transform3 {
 	return s.transform
 }
+
+// getIsNormalFlipped returns true if the normal is flipped.
+func (s *Sphere3) getIsNormalFlipped() bool {
+	return s.isNormalFlipped
+}
+
+// closestIntersection returns the first hit of the ray with the sphere.
+func (s *Sphere3) closestIntersection(ray *Ray3) *SurfaceRayIntersection3 {
+
+	return intersectionToWorld(s.transform, s.isNormalFlipped, s.closestIntersectionLocal(s.transform.toLocalRay(ray)))
+}
+
+// intersects returns true if the ray hits the sphere.
+func (s *Sphere3) intersects(ray *Ray3) bool {
+
+	return s.closestIntersectionLocal(s.transform.toLocalRay(ray)).isIntersecting
+}
+
+func (s *Sphere3) closestIntersectionLocal(ray *Ray3) *SurfaceRayIntersection3 {
+
+	intersection := NewSurfaceRayIntersection3()
+
+	r := ray.origin.Substract(s.center)
+	b := ray.direction.DotProduct(r)
+	c := r.Squared() - s.radius*s.radius
+	d := b*b - c
+	if d < 0 {
+		return intersection
+	}
+
+	// Take the entry point, or the exit point if the ray starts inside.
+	d = math.Sqrt(d)
+	t := -b - d
+	if t < 0 {
+		t = -b + d
+	}
+	if t < 0 {
+		return intersection
+	}
+
+	intersection.isIntersecting = true
+	intersection.distance = t
+	intersection.point = ray.pointAt(t)
+	intersection.normal = intersection.point.Substract(s.center).Normalize()
+	return intersection
+}
@@ -1,6 +1,9 @@
 package main
 
-import "jimmykiang/fluidengine/Vector3D"
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
 
 type Surface3IF interface {
 	closestPoint(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D
@@ -8,6 +11,8 @@ type Surface3IF interface {
 	closestNormal(point *Vector3D.Vector3D) *Vector3D.Vector3D
 	getTransform() *Transform3
 	isInside(position *Vector3D.Vector3D) bool
+	closestIntersection(ray *Ray3) *SurfaceRayIntersection3
+	intersects(ray *Ray3) bool
 }
 
 type Surface3 struct {
@@ -25,3 +30,45 @@ func NewSurface3() *Surface3 {
 		isNormalFlipped: false,
 	}
 }
+
+// SurfaceRayIntersection3 is the result of a ray/surface intersection query.
+type SurfaceRayIntersection3 struct {
+
+	// True if the ray hits the surface.
+	isIntersecting bool
+
+	// Distance along the ray to the hit.
+	distance float64
+
+	// Hit point in world space.
+	point *Vector3D.Vector3D
+
+	// Surface normal at the hit point in world space.
+	normal *Vector3D.Vector3D
+}
+
+// NewSurfaceRayIntersection3 returns an empty result for a ray that misses.
+func NewSurfaceRayIntersection3() *SurfaceRayIntersection3 {
+	return &SurfaceRayIntersection3{
+		isIntersecting: false,
+		distance:       math.MaxFloat64,
+		point:          Vector3D.NewVector(0, 0, 0),
+		normal:         Vector3D.NewVector(0, 0, 0),
+	}
+}
+
+// intersectionToWorld converts a hit computed in the local frame of a surface
+// to world space, flipping the normal if requested. Rigid transforms keep the
+// distance along the ray unchanged.
+func intersectionToWorld(transform *Transform3, isNormalFlipped bool, intersection *SurfaceRayIntersection3) *SurfaceRayIntersection3 {
+
+	if !intersection.isIntersecting {
+		return intersection
+	}
+	intersection.point = transform.toWorld(intersection.point)
+	intersection.normal = transform.toWorldDirection(intersection.normal)
+	if isNormalFlipped {
+		intersection.normal = intersection.normal.Multiply(-1)
+	}
+	return intersection
+}
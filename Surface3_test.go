@@ -0,0 +1,137 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"strings"
+	"testing"
+)
+
+func checkRayIntersection3(t *testing.T, name string, surface Surface3IF, ray *Ray3, distance float64, normal *Vector3D.Vector3D) {
+
+	t.Helper()
+	result := surface.closestIntersection(ray)
+	if !result.isIntersecting || !surface.intersects(ray) {
+		t.Fatalf("%s: expected the ray to hit", name)
+	}
+	if math.Abs(result.distance-distance) > 1e-9 {
+		t.Errorf("%s: expected distance %v, got %v", name, distance, result.distance)
+	}
+	if result.point.DistanceTo(ray.pointAt(distance)) > 1e-9 {
+		t.Errorf("%s: expected point %v, got %v", name, ray.pointAt(distance), result.point)
+	}
+	if result.normal.DistanceTo(normal) > 1e-9 {
+		t.Errorf("%s: expected normal %v, got %v", name, normal, result.normal)
+	}
+}
+
+func TestSurface3ClosestIntersection(t *testing.T) {
+
+	down := NewRay3(Vector3D.NewVector(0.5, 3, 0.5), Vector3D.NewVector(0, -1, 0))
+	miss := NewRay3(Vector3D.NewVector(0.5, 3, 0.5), Vector3D.NewVector(0, 1, 0))
+
+	sphere := NewSphere3(Vector3D.NewVector(0.5, 1, 0.5), 0.5)
+	checkRayIntersection3(t, "sphere", sphere, down, 1.5, Vector3D.NewVector(0, 1, 0))
+	checkRayIntersection3(t, "sphere from inside", sphere,
+		NewRay3(Vector3D.NewVector(0.5, 1, 0.5), Vector3D.NewVector(1, 0, 0)), 0.5, Vector3D.NewVector(1, 0, 0))
+
+	plane := NewPlane3D(Vector3D.NewVector(0, 1, 0), Vector3D.NewVector(0, 0.25, 0))
+	checkRayIntersection3(t, "plane", plane, down, 2.75, Vector3D.NewVector(0, 1, 0))
+
+	box := NewBox3(NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(1, 2, 1)))
+	box.isNormalFlipped = false
+	checkRayIntersection3(t, "box", box, down, 1, Vector3D.NewVector(0, 1, 0))
+	box.isNormalFlipped = true
+	checkRayIntersection3(t, "box from inside", box,
+		NewRay3(Vector3D.NewVector(0.5, 1, 0.5), Vector3D.NewVector(-1, 0, 0)), 0.5, Vector3D.NewVector(1, 0, 0))
+
+	mesh := NewTriangleMesh3(nil, nil)
+	if err := mesh.readObj(strings.NewReader(unitCubeObj)); err != nil {
+		t.Fatal(err)
+	}
+	checkRayIntersection3(t, "mesh", mesh, down, 2, Vector3D.NewVector(0, 1, 0))
+
+	for name, surface := range map[string]Surface3IF{"sphere": sphere, "plane": plane, "box": box, "mesh": mesh} {
+		if surface.intersects(miss) || surface.closestIntersection(miss).isIntersecting {
+			t.Errorf("%s: expected the ray to miss", name)
+		}
+	}
+}
+
+func TestImplicitSurfaceSet3ClosestIntersection(t *testing.T) {
+
+	surfaceSet := NewImplicitSurfaceSet3()
+	surfaceSet.addExplicitSurface(NewPlane3D(Vector3D.NewVector(0, 1, 0), Vector3D.NewVector(0, 0, 0)))
+	for i := 0; i < 10; i++ {
+		surfaceSet.addExplicitSurface(NewSphere3(Vector3D.NewVector(float64(i), 1, 0), 0.25))
+	}
+
+	result := surfaceSet.closestIntersection(NewRay3(Vector3D.NewVector(3, 5, 0), Vector3D.NewVector(0, -1, 0)))
+	if !result.isIntersecting || math.Abs(result.distance-3.75) > 1e-9 {
+		t.Errorf("expected to hit the sphere at distance 3.75, got %v", result.distance)
+	}
+
+	result = surfaceSet.closestIntersection(NewRay3(Vector3D.NewVector(3.5, 5, 0), Vector3D.NewVector(0, -1, 0)))
+	if !result.isIntersecting || math.Abs(result.distance-5) > 1e-9 || !result.normal.IsSimilar(Vector3D.NewVector(0, 1, 0)) {
+		t.Errorf("expected to hit the plane at distance 5, got %v", result.distance)
+	}
+
+	if surfaceSet.intersects(NewRay3(Vector3D.NewVector(3.5, 5, 0), Vector3D.NewVector(0, 1, 0))) {
+		t.Errorf("expected the ray to miss")
+	}
+}
+
+func TestImplicitSurfaceSet3SignedDistanceFlipped(t *testing.T) {
+
+	// The flipped box is negative outside of its bounds, so the sphere
+	// being closer must not hide it.
+	surfaceSet := NewImplicitSurfaceSet3()
+	surfaceSet.addExplicitSurface(NewBox3(NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(1, 1, 1))))
+	surfaceSet.addExplicitSurface(NewSphere3(Vector3D.NewVector(1.7, 0.5, 0.5), 0.1))
+
+	if d := surfaceSet.signedDistance(Vector3D.NewVector(1.5, 0.5, 0.5)); math.Abs(d+0.5) > 1e-9 {
+		t.Errorf("expected signed distance -0.5, got %v", d)
+	}
+}
+
+func TestBox3Oriented(t *testing.T) {
+
+	// Rotated a quarter turn around z, the box spans x in [4.5, 5.5] and
+	// y in [-1, 1].
+	box := NewBox3(NewBoundingBox3D(Vector3D.NewVector(-1, -0.5, -0.5), Vector3D.NewVector(1, 0.5, 0.5)))
+	box.isNormalFlipped = false
+	box.transform = NewTransform3WithTranslationAndOrientation(
+		Vector3D.NewVector(5, 0, 0), newQuaternionFromAxisAngle(Vector3D.NewVector(0, 0, 1), math.Pi/2))
+
+	cases := []struct {
+		name    string
+		point   *Vector3D.Vector3D
+		closest *Vector3D.Vector3D
+		normal  *Vector3D.Vector3D
+		signed  float64
+	}{
+		{"outside", Vector3D.NewVector(6, 0.2, 0), Vector3D.NewVector(5.5, 0.2, 0), Vector3D.NewVector(1, 0, 0), 0.5},
+		{"inside", Vector3D.NewVector(5, 0.9, 0), Vector3D.NewVector(5, 1, 0), Vector3D.NewVector(0, 1, 0), -0.1},
+	}
+	for _, c := range cases {
+		if p := box.closestPoint(c.point); p.DistanceTo(c.closest) > 1e-9 {
+			t.Errorf("%s: expected closest point %v, got %v", c.name, c.closest, p)
+		}
+		if n := box.closestNormal(c.point); n.DistanceTo(c.normal) > 1e-9 {
+			t.Errorf("%s: expected normal %v, got %v", c.name, c.normal, n)
+		}
+		if d := box.signedDistance(c.point); math.Abs(d-c.signed) > 1e-9 {
+			t.Errorf("%s: expected signed distance %v, got %v", c.name, c.signed, d)
+		}
+		if d := box.closestDistance(c.point); math.Abs(d-math.Abs(c.signed)) > 1e-9 {
+			t.Errorf("%s: expected distance %v, got %v", c.name, math.Abs(c.signed), d)
+		}
+	}
+
+	bound := box.boundingBox()
+	if !bound.lowerCorner.IsSimilar(Vector3D.NewVector(4.5, -1, -0.5)) || !bound.upperCorner.IsSimilar(Vector3D.NewVector(5.5, 1, 0.5)) {
+		t.Errorf("unexpected bounding box %v %v", bound.lowerCorner, bound.upperCorner)
+	}
+	checkRayIntersection3(t, "oriented box", box,
+		NewRay3(Vector3D.NewVector(5, 3, 0), Vector3D.NewVector(0, -1, 0)), 2, Vector3D.NewVector(0, 1, 0))
+}
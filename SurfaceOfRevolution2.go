@@ -0,0 +1,131 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
+
+// surfaceOfRevolution2 implements the surface queries shared by the 2-D
+// counterparts of the primitives of revolution. The 2-D shape is the cross
+// section of the 3-D one through its axis, that is the profile mirrored
+// about the y-axis of the local frame through center.
+type surfaceOfRevolution2 struct {
+
+	// Base struct for 2-D surface.
+	surface2 *Surface2
+
+	// Center of the primitive.
+	center *Vector3D.Vector3D
+
+	// Outline of the primitive.
+	profile revolutionProfile
+
+	// Local-to-world transform.
+	transform *Transform2
+
+	// Flips normal when calling Surface2::closestNormal(...).
+	isNormalFlipped bool
+}
+
+func newSurfaceOfRevolution2(center *Vector3D.Vector3D, profile revolutionProfile) *surfaceOfRevolution2 {
+	return &surfaceOfRevolution2{
+		surface2:        NewSurface2(),
+		center:          center,
+		profile:         profile,
+		transform:       NewTransform2(),
+		isNormalFlipped: false,
+	}
+}
+
+// queryLocal returns the closest point, the outward normal and the inside
+// flag for a point in local frame, by querying the profile on the side of
+// the axis that contains the point.
+func (s *surfaceOfRevolution2) queryLocal(otherPoint *Vector3D.Vector3D) (*Vector3D.Vector3D, *Vector3D.Vector3D, bool) {
+
+	q := otherPoint.Substract(s.center)
+	side := 1.0
+	if q.X < 0 {
+		side = -1
+	}
+
+	result := s.profile.closestProfilePoint(math.Abs(q.X), q.Y)
+
+	cp := Vector3D.NewVector(s.center.X+side*result.point.X, s.center.Y+result.point.Y, 0)
+	normal := Vector3D.NewVector(side*result.normal.X, result.normal.Y, 0)
+
+	return cp, normal, result.isInside
+}
+
+func (s *surfaceOfRevolution2) getTransform() *Transform2 {
+	return s.transform
+}
+
+// getIsNormalFlipped returns true if the normal is flipped.
+func (s *surfaceOfRevolution2) getIsNormalFlipped() bool {
+	return s.isNormalFlipped
+}
+
+// isBounded returns true if bounding box can be defined.
+func (s *surfaceOfRevolution2) isBounded() bool {
+
+	return true
+}
+
+// boundingBox returns the bounding box of this surface object.
+func (s *surfaceOfRevolution2) boundingBox() *BoundingBox2D {
+
+	rMax, yMin, yMax := s.profile.profileExtent()
+	return s.transform.toWorld(NewBoundingBox2D(
+		s.center.Add(Vector3D.NewVector(-rMax, yMin, 0)),
+		s.center.Add(Vector3D.NewVector(rMax, yMax, 0))))
+}
+
+func (s *surfaceOfRevolution2) signedDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	sd := s.signedDistanceLocal(s.transform.toLocal(otherPoint))
+	if s.isNormalFlipped {
+		sd = -sd
+	}
+	return sd
+}
+
+func (s *surfaceOfRevolution2) signedDistanceLocal(otherPoint *Vector3D.Vector3D) float64 {
+
+	cp, _, inside := s.queryLocal(otherPoint)
+	if inside {
+		return -cp.DistanceTo(otherPoint)
+	}
+	return cp.DistanceTo(otherPoint)
+}
+
+// Returns the closest point from the given point otherPoint to the surface.
+func (s *surfaceOfRevolution2) closestPoint(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	cp, _, _ := s.queryLocal(s.transform.toLocal(otherPoint))
+	return s.transform.toWorldPointInLocal(cp)
+}
+
+// Returns the closest distance from the given point otherPoint to the surface.
+func (s *surfaceOfRevolution2) closestDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	return math.Abs(s.signedDistanceLocal(s.transform.toLocal(otherPoint)))
+}
+
+// closestNormal returns the normal to the closest point on the surface from the given
+// point.
+func (s *surfaceOfRevolution2) closestNormal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	_, normal, _ := s.queryLocal(s.transform.toLocal(otherPoint))
+	result := s.transform.toWorldDirection(normal)
+	if s.isNormalFlipped {
+		result = result.Multiply(-1)
+	}
+	return result
+}
+
+// Returns true if otherPoint is inside the volume defined by the surface.
+func (s *surfaceOfRevolution2) isInside(otherPoint *Vector3D.Vector3D) bool {
+
+	_, _, inside := s.queryLocal(s.transform.toLocal(otherPoint))
+	return s.isNormalFlipped == !inside
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"jimmykiang/fluidengine/mathHelper"
+	"math"
+)
+
+// revolutionProfile describes a solid of revolution around the local y-axis
+// by its outline in the (r, y) half-plane, where r >= 0 is the distance to
+// the axis. Points are stored in the X and Y components of a Vector3D.
+type revolutionProfile interface {
+
+	// closestProfilePoint returns the point of the outline closest to (r, y),
+	// the outward normal there, and whether (r, y) is inside the solid.
+	closestProfilePoint(r, y float64) *profileQueryResult
+
+	// profileExtent returns the largest r and the range of y covered by the
+	// solid.
+	profileExtent() (rMax, yMin, yMax float64)
+}
+
+// profileQueryResult is the result of a revolutionProfile query.
+type profileQueryResult struct {
+	point    *Vector3D.Vector3D
+	normal   *Vector3D.Vector3D
+	isInside bool
+}
+
+// surfaceOfRevolution3 implements the surface queries shared by the 3-D
+// primitives that are symmetric around an axis. The axis is the y-axis of the
+// local frame through center.
+type surfaceOfRevolution3 struct {
+
+	// Base struct for 3-D surface.
+	surface3 *Surface3
+
+	// Center of the primitive.
+	center *Vector3D.Vector3D
+
+	// Outline of the primitive.
+	profile revolutionProfile
+
+	// Local-to-world transform.
+	transform *Transform3
+
+	// Flips normal when calling Surface3::closestNormal(...).
+	isNormalFlipped bool
+}
+
+func newSurfaceOfRevolution3(center *Vector3D.Vector3D, profile revolutionProfile) *surfaceOfRevolution3 {
+	return &surfaceOfRevolution3{
+		surface3:        NewSurface3(),
+		center:          center,
+		profile:         profile,
+		transform:       NewTransform3(),
+		isNormalFlipped: false,
+	}
+}
+
+// queryLocal returns the closest point, the outward normal and the inside
+// flag for a point in local frame, by querying the profile in the half-plane
+// that contains the point and the axis.
+func (s *surfaceOfRevolution3) queryLocal(otherPoint *Vector3D.Vector3D) (*Vector3D.Vector3D, *Vector3D.Vector3D, bool) {
+
+	q := otherPoint.Substract(s.center)
+	r := math.Sqrt(q.X*q.X + q.Z*q.Z)
+
+	// Any half-plane works for points on the axis.
+	dir := Vector3D.NewVector(1, 0, 0)
+	if r > 0 {
+		dir = Vector3D.NewVector(q.X/r, 0, q.Z/r)
+	}
+
+	result := s.profile.closestProfilePoint(r, q.Y)
+
+	cp := s.center.Add(dir.Multiply(result.point.X))
+	cp.Y += result.point.Y
+	normal := dir.Multiply(result.normal.X)
+	normal.Y += result.normal.Y
+
+	return cp, normal, result.isInside
+}
+
+func (s *surfaceOfRevolution3) getTransform() *Transform3 {
+	return s.transform
+}
+
+// getIsNormalFlipped returns true if the normal is flipped.
+func (s *surfaceOfRevolution3) getIsNormalFlipped() bool {
+	return s.isNormalFlipped
+}
+
+// isBounded returns true if bounding box can be defined.
+func (s *surfaceOfRevolution3) isBounded() bool {
+
+	return true
+}
+
+// boundingBox returns the bounding box of this surface object.
+func (s *surfaceOfRevolution3) boundingBox() *BoundingBox3D {
+
+	return s.transform.toWorldBoundingBox(s.boundingBoxLocal())
+}
+
+func (s *surfaceOfRevolution3) boundingBoxLocal() *BoundingBox3D {
+
+	rMax, yMin, yMax := s.profile.profileExtent()
+	return NewBoundingBox3D(
+		s.center.Add(Vector3D.NewVector(-rMax, yMin, -rMax)),
+		s.center.Add(Vector3D.NewVector(rMax, yMax, rMax)))
+}
+
+func (s *surfaceOfRevolution3) signedDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	sd := s.signedDistanceLocal(s.transform.toLocal(otherPoint))
+	if s.isNormalFlipped {
+		sd = -sd
+	}
+	return sd
+}
+
+func (s *surfaceOfRevolution3) signedDistanceLocal(otherPoint *Vector3D.Vector3D) float64 {
+
+	cp, _, inside := s.queryLocal(otherPoint)
+	if inside {
+		return -cp.DistanceTo(otherPoint)
+	}
+	return cp.DistanceTo(otherPoint)
+}
+
+// Returns the closest point from the given point otherPoint to the surface.
+func (s *surfaceOfRevolution3) closestPoint(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	return s.transform.toWorld(s.closestPointLocal(s.transform.toLocal(otherPoint)))
+}
+
+func (s *surfaceOfRevolution3) closestPointLocal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	cp, _, _ := s.queryLocal(otherPoint)
+	return cp
+}
+
+// Returns the closest distance from the given point otherPoint to the surface.
+func (s *surfaceOfRevolution3) closestDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	return math.Abs(s.signedDistanceLocal(s.transform.toLocal(otherPoint)))
+}
+
+// closestNormal returns the normal to the closest point on the surface from the given
+// point.
+func (s *surfaceOfRevolution3) closestNormal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	result := s.transform.toWorldDirection(s.closestNormalLocal(s.transform.toLocal(otherPoint)))
+	if s.isNormalFlipped {
+		result = result.Multiply(-1)
+	}
+	return result
+}
+
+func (s *surfaceOfRevolution3) closestNormalLocal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	_, normal, _ := s.queryLocal(otherPoint)
+	return normal
+}
+
+// Returns true if otherPoint is inside the volume defined by the surface.
+func (s *surfaceOfRevolution3) isInside(otherPoint *Vector3D.Vector3D) bool {
+
+	return s.isNormalFlipped == !s.isInsideLocal(s.transform.toLocal(otherPoint))
+}
+
+func (s *surfaceOfRevolution3) isInsideLocal(otherPointLocal *Vector3D.Vector3D) bool {
+
+	_, _, inside := s.queryLocal(otherPointLocal)
+	return inside
+}
+
+// closestIntersection returns the first hit of the ray with the surface.
+func (s *surfaceOfRevolution3) closestIntersection(ray *Ray3) *SurfaceRayIntersection3 {
+
+	return intersectionToWorld(s.transform, s.isNormalFlipped, s.closestIntersectionLocal(s.transform.toLocalRay(ray)))
+}
+
+// intersects returns true if the ray hits the surface.
+func (s *surfaceOfRevolution3) intersects(ray *Ray3) bool {
+
+	return s.closestIntersectionLocal(s.transform.toLocalRay(ray)).isIntersecting
+}
+
+func (s *surfaceOfRevolution3) closestIntersectionLocal(ray *Ray3) *SurfaceRayIntersection3 {
+
+	intersection := NewSurfaceRayIntersection3()
+
+	t, isIntersecting := sphereTrace3(ray, s.boundingBoxLocal(), func(point *Vector3D.Vector3D) float64 {
+		return math.Abs(s.signedDistanceLocal(point))
+	})
+	if isIntersecting {
+		intersection.isIntersecting = true
+		intersection.distance = t
+		intersection.point = ray.pointAt(t)
+		intersection.normal = s.closestNormalLocal(intersection.point)
+	}
+	return intersection
+}
+
+// closestPointOnSegment2 returns the point of segment (a, b) closest to p in
+// the XY plane.
+func closestPointOnSegment2(p, a, b *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	ab := b.Substract(a)
+	t := 0.0
+	if lengthSquared := ab.Squared(); lengthSquared > 0 {
+		t = mathHelper.Clamp(p.Substract(a).DotProduct(ab)/lengthSquared, 0, 1)
+	}
+	return a.Add(ab.Multiply(t))
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+type primitive3 interface {
+	Surface3IF
+	ImplicitSurface3
+}
+
+func TestSurfaceOfRevolution3SignedDistance(t *testing.T) {
+
+	center := Vector3D.NewVector(1, 2, 3)
+	cases := []struct {
+		name     string
+		surface  primitive3
+		point    *Vector3D.Vector3D
+		expected float64
+	}{
+		{"cylinder center", NewCylinder3(center, 0.5, 2), center, -0.5},
+		{"cylinder above", NewCylinder3(center, 0.5, 2), center.Add(Vector3D.NewVector(0.2, 1.5, 0)), 0.5},
+		{"cylinder rim", NewCylinder3(center, 0.5, 2), center.Add(Vector3D.NewVector(0, 4, 4.5)), 5},
+		{"capsule center", NewCapsule3(center, 0.5, 2), center, -0.5},
+		{"capsule above", NewCapsule3(center, 0.5, 2), center.Add(Vector3D.NewVector(0, 3, 0)), 1.5},
+		{"cone below", NewCone3(center, 1, 2), center.Add(Vector3D.NewVector(0, -1.5, 0)), 0.5},
+		{"cone above apex", NewCone3(center, 1, 2), center.Add(Vector3D.NewVector(0, 2, 0)), 1},
+		{"cone axis", NewCone3(center, 1, 2), center, -1 / math.Sqrt(5)},
+		{"torus hole", NewTorus3(center, 1, 0.25), center, 0.75},
+		{"torus tube", NewTorus3(center, 1, 0.25), center.Add(Vector3D.NewVector(0, 0, -1)), -0.25},
+	}
+	for _, c := range cases {
+		if sd := c.surface.signedDistance(c.point); math.Abs(sd-c.expected) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, sd)
+		}
+	}
+}
+
+func TestSurfaceOfRevolution3Consistency(t *testing.T) {
+
+	center := Vector3D.NewVector(0.5, 0.5, 0.5)
+	surfaces := map[string]primitive3{
+		"cylinder": NewCylinder3(center, 0.3, 0.6),
+		"capsule":  NewCapsule3(center, 0.2, 0.4),
+		"cone":     NewCone3(center, 0.4, 0.8),
+		"torus":    NewTorus3(center, 0.3, 0.1),
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for name, surface := range surfaces {
+		bound := surface.boundingBox()
+		for k := 0; k < 200; k++ {
+			p := Vector3D.NewVector(rng.Float64(), rng.Float64(), rng.Float64())
+			sd := surface.signedDistance(p)
+			cp := surface.closestPoint(p)
+
+			if math.Abs(math.Abs(sd)-cp.DistanceTo(p)) > 1e-9 || math.Abs(surface.closestDistance(p)-math.Abs(sd)) > 1e-9 {
+				t.Fatalf("%s: distance to the closest point of %v does not match %v", name, p, sd)
+			}
+			if math.Abs(surface.signedDistance(cp)) > 1e-9 {
+				t.Fatalf("%s: closest point %v of %v is not on the surface", name, cp, p)
+			}
+			if surface.isInside(p) != (sd < 0) {
+				t.Fatalf("%s: isInside(%v) does not match the signed distance %v", name, p, sd)
+			}
+			if sd < 0 && !bound.contains(p) {
+				t.Fatalf("%s: %v is inside but not in the bounding box", name, p)
+			}
+			if sd > 1e-6 && cp.Add(surface.closestNormal(p).Multiply(sd)).DistanceTo(p) > 1e-9 {
+				t.Fatalf("%s: normal at %v does not point to %v", name, cp, p)
+			}
+		}
+	}
+}
+
+func TestSurfaceOfRevolution3ClosestIntersection(t *testing.T) {
+
+	center := Vector3D.NewVector(0, 0, 0)
+	down := NewRay3(Vector3D.NewVector(0, 3, 0), Vector3D.NewVector(0, -1, 0))
+
+	checkRayIntersection3(t, "cylinder", NewCylinder3(center, 0.5, 2), down, 2, Vector3D.NewVector(0, 1, 0))
+	checkRayIntersection3(t, "capsule", NewCapsule3(center, 0.5, 2), down, 1.5, Vector3D.NewVector(0, 1, 0))
+	checkRayIntersection3(t, "cone", NewCone3(center, 1, 2),
+		NewRay3(Vector3D.NewVector(0.25, 3, 0), Vector3D.NewVector(0, -1, 0)), 2.5, Vector3D.NewVector(2, 1, 0).Normalize())
+	checkRayIntersection3(t, "cylinder from inside", NewCylinder3(center, 0.5, 2),
+		NewRay3(center, Vector3D.NewVector(1, 0, 0)), 0.5, Vector3D.NewVector(1, 0, 0))
+
+	torus := NewTorus3(center, 1, 0.25)
+	if torus.intersects(down) {
+		t.Errorf("expected the ray to pass through the hole of the torus")
+	}
+	checkRayIntersection3(t, "torus", torus,
+		NewRay3(Vector3D.NewVector(-3, 0, 0), Vector3D.NewVector(1, 0, 0)), 1.75, Vector3D.NewVector(-1, 0, 0))
+}
+
+func TestSurfaceOfRevolution2SignedDistance(t *testing.T) {
+
+	center := Vector3D.NewVector(1, 2, 0)
+	cases := []struct {
+		name     string
+		surface  ImplicitSurface2
+		point    *Vector3D.Vector3D
+		expected float64
+	}{
+		{"cylinder center", NewCylinder2(center, 0.5, 2), center, -0.5},
+		{"cylinder left", NewCylinder2(center, 0.5, 2), center.Add(Vector3D.NewVector(-1.5, 0.5, 0)), 1},
+		{"capsule above", NewCapsule2(center, 0.5, 2), center.Add(Vector3D.NewVector(0, 3, 0)), 1.5},
+		{"cone left corner", NewCone2(center, 1, 2), center.Add(Vector3D.NewVector(-1, -2, 0)), 1},
+		{"torus hole", NewTorus2(center, 1, 0.25), center, 0.75},
+		{"torus left tube", NewTorus2(center, 1, 0.25), center.Add(Vector3D.NewVector(-1, 0.1, 0)), -0.15},
+	}
+	for _, c := range cases {
+		if sd := c.surface.signedDistance(c.point); math.Abs(sd-c.expected) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, sd)
+		}
+	}
+
+	var surface Surface2IF = NewCone2(center, 1, 2)
+	normal := surface.closestNormal(center.Add(Vector3D.NewVector(-0.1, -1.5, 0)))
+	if !normal.IsSimilar(Vector3D.NewVector(0, -1, 0)) || !surface.isInside(center) {
+		t.Errorf("unexpected normal %v below the cone", normal)
+	}
+}
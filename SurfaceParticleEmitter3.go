@@ -0,0 +1,241 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"jimmykiang/fluidengine/constants"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// Consecutive rejected candidates after which Poisson-disk dart throwing
+	// on a surface considers it saturated.
+	kPoissonDiskMaxFailures = 1000
+
+	// Newton steps used to project candidates onto an implicit surface.
+	kSurfaceProjectionIterations = 8
+)
+
+// SurfaceParticleEmitter3 is a 3-D particle emitter that seeds particles on
+// the surface of a triangle mesh or an implicit surface. The particles are
+// Poisson-disk distributed, so there are no lattice artifacts, at the density
+// of a triangle lattice of the spacing laid on the surface. Particles are only
+// spawned inside the given region. The emitter is one-shot.
+type SurfaceParticleEmitter3 struct {
+	surface                  ImplicitSurface3
+	particles                *ParticleSystemData3
+	bounds                   *BoundingBox3D
+	spacing                  float64
+	initialVel               *Vector3D.Vector3D
+	maxNumberOfParticles     float64
+	numberOfEmittedParticles float64
+	seed                     int64
+	rng                      *rand.Rand
+	isEnabled                bool
+}
+
+func NewSurfaceParticleEmitter3(
+	surface ImplicitSurface3,
+	maxRegion *BoundingBox3D,
+	spacing float64,
+	initialVel *Vector3D.Vector3D,
+) *SurfaceParticleEmitter3 {
+	return &SurfaceParticleEmitter3{
+		surface:                  surface,
+		bounds:                   maxRegion,
+		spacing:                  spacing,
+		initialVel:               initialVel,
+		maxNumberOfParticles:     constants.KMaxSize,
+		numberOfEmittedParticles: 0,
+		seed:                     0,
+		rng:                      rand.New(rand.NewSource(0)),
+		isEnabled:                true,
+	}
+}
+
+// setSeed restarts the random sequence of the emitter from the given seed.
+func (e *SurfaceParticleEmitter3) setSeed(seed int64) {
+
+	e.seed = seed
+	e.rng = rand.New(rand.NewSource(seed))
+}
+
+// setMaxNumberOfParticles sets the total number of particles the emitter may spawn.
+func (e *SurfaceParticleEmitter3) setMaxNumberOfParticles(maxNumberOfParticles float64) {
+
+	e.maxNumberOfParticles = maxNumberOfParticles
+}
+
+func (e *SurfaceParticleEmitter3) setTarget(particles *ParticleSystemData3) {
+
+	e.particles = particles
+}
+
+func (e *SurfaceParticleEmitter3) update(currentTimeInSeconds, timeIntervalInSeconds float64) {
+
+	if e.particles == nil || !e.isEnabled {
+		return
+	}
+
+	newPositions := make([]*Vector3D.Vector3D, 0)
+	newVelocities := make([]*Vector3D.Vector3D, 0)
+
+	sampleSurfacePoissonDisk3(e.surface, e.bounds, e.spacing, e.rng, func(point *Vector3D.Vector3D) bool {
+		if e.numberOfEmittedParticles >= e.maxNumberOfParticles {
+			return false
+		}
+		newPositions = append(newPositions, point)
+		newVelocities = append(newVelocities, Vector3D.NewVector(e.initialVel.X, e.initialVel.Y, e.initialVel.Z))
+		e.numberOfEmittedParticles++
+		return true
+	})
+
+	e.particles.addParticles(newPositions, newVelocities, nil)
+	e.isEnabled = false
+}
+
+// sampleRegion3 returns the part of the region that can contain points of the
+// surface grown by margin, or false if there is none.
+func sampleRegion3(region *BoundingBox3D, surface ImplicitSurface3, margin float64) (*BoundingBox3D, bool) {
+
+	result := NewBoundingBox3D(region.lowerCorner, region.upperCorner)
+	if !surface.isBounded() {
+		return result, true
+	}
+
+	surfaceBox := surface.boundingBox()
+	surfaceBox.expand(margin)
+	if !result.overlaps(surfaceBox) {
+		return nil, false
+	}
+	return NewBoundingBox3D(
+		result.lowerCorner.Max(surfaceBox.lowerCorner),
+		result.upperCorner.Min(surfaceBox.upperCorner),
+	), true
+}
+
+// uniformSampleBox3 returns a uniformly distributed point in the box.
+func uniformSampleBox3(box *BoundingBox3D, rng *rand.Rand) *Vector3D.Vector3D {
+
+	return Vector3D.NewVector(
+		box.lowerCorner.X+rng.Float64()*box.width(),
+		box.lowerCorner.Y+rng.Float64()*box.height(),
+		box.lowerCorner.Z+rng.Float64()*box.depth(),
+	)
+}
+
+// sampleSurfacePoissonDisk3 throws random candidates on the surface and passes
+// those at least kPoissonDiskRadiusScale2 times spacing apart to the callback
+// until the callback returns false or the surface is saturated. Meshes are
+// sampled area-weighted on their triangles, other implicit surfaces by
+// projecting uniform random points of the region onto the zero level set.
+// Projected candidates are not uniform on the surface: parts facing more of
+// the region, such as the outside of convex bumps, get more of them. Once the
+// surface is saturated every free spot has been filled and the bias is gone,
+// but a sampling cut short by the callback is denser on those parts.
+func sampleSurfacePoissonDisk3(
+	surface ImplicitSurface3,
+	region *BoundingBox3D,
+	spacing float64,
+	rng *rand.Rand,
+	callback func(*Vector3D.Vector3D) bool,
+) {
+
+	box, ok := sampleRegion3(region, surface, spacing)
+	if !ok {
+		return
+	}
+
+	var candidate func() *Vector3D.Vector3D
+	if mesh, isMesh := surface.(*TriangleMesh3); isMesh {
+		candidate = meshSurfaceSampler3(mesh, rng)
+	} else {
+		candidate = func() *Vector3D.Vector3D {
+			return projectToImplicitSurface3(surface, uniformSampleBox3(box, rng), spacing)
+		}
+	}
+	if candidate == nil {
+		return
+	}
+
+	grid := newPoissonDiskGrid3(kPoissonDiskRadiusScale2 * spacing)
+	for failures := 0; failures < kPoissonDiskMaxFailures; {
+		point := candidate()
+		if point == nil || !region.contains(point) || !grid.tryAdd(point) {
+			failures++
+			continue
+		}
+		failures = 0
+		if !callback(point) {
+			return
+		}
+	}
+}
+
+// meshSurfaceSampler3 returns a function drawing area-weighted uniform points
+// on the triangles of the mesh in world space, or nil for an empty mesh.
+func meshSurfaceSampler3(mesh *TriangleMesh3, rng *rand.Rand) func() *Vector3D.Vector3D {
+
+	n := int(mesh.numberOfTriangles())
+	cumulativeAreas := make([]float64, n)
+	totalArea := 0.0
+	for i := 0; i < n; i++ {
+		a, b, c := mesh.triangle(int64(i))
+		totalArea += 0.5 * b.Substract(a).CrossProduct(c.Substract(a)).Length()
+		cumulativeAreas[i] = totalArea
+	}
+	if totalArea == 0 {
+		return nil
+	}
+
+	return func() *Vector3D.Vector3D {
+		i := sort.SearchFloat64s(cumulativeAreas, rng.Float64()*totalArea)
+		if i >= n {
+			i = n - 1
+		}
+		a, b, c := mesh.triangle(int64(i))
+
+		// Uniform barycentric coordinates.
+		su := math.Sqrt(rng.Float64())
+		v := rng.Float64()
+		point := a.Multiply(1 - su).Add(b.Multiply(su * (1 - v))).Add(c.Multiply(su * v))
+		return mesh.transform.toWorld(point)
+	}
+}
+
+// projectToImplicitSurface3 moves the point onto the zero level set with Newton
+// steps along the numerical gradient of the signed distance. It returns nil if
+// the projection does not converge.
+func projectToImplicitSurface3(surface ImplicitSurface3, point *Vector3D.Vector3D, spacing float64) *Vector3D.Vector3D {
+
+	h := 1e-3 * spacing
+	tolerance := 1e-3 * spacing
+
+	for i := 0; i < kSurfaceProjectionIterations; i++ {
+		d := surface.signedDistance(point)
+		if math.Abs(d) <= tolerance {
+			return point
+		}
+
+		gradient := Vector3D.NewVector(
+			surface.signedDistance(Vector3D.NewVector(point.X+h, point.Y, point.Z))-
+				surface.signedDistance(Vector3D.NewVector(point.X-h, point.Y, point.Z)),
+			surface.signedDistance(Vector3D.NewVector(point.X, point.Y+h, point.Z))-
+				surface.signedDistance(Vector3D.NewVector(point.X, point.Y-h, point.Z)),
+			surface.signedDistance(Vector3D.NewVector(point.X, point.Y, point.Z+h))-
+				surface.signedDistance(Vector3D.NewVector(point.X, point.Y, point.Z-h)),
+		).Divide(2 * h)
+
+		gradientLengthSquared := gradient.Squared()
+		if gradientLengthSquared == 0 {
+			return nil
+		}
+		point = point.Substract(gradient.Multiply(d / gradientLengthSquared))
+	}
+
+	if math.Abs(surface.signedDistance(point)) <= tolerance {
+		return point
+	}
+	return nil
+}
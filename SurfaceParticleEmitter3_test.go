@@ -0,0 +1,122 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"strings"
+	"testing"
+)
+
+// minimumPairDistance returns the smallest distance between any two points.
+func minimumPairDistance(points []*Vector3D.Vector3D) float64 {
+
+	result := math.Inf(1)
+	for i := range points {
+		for j := i + 1; j < len(points); j++ {
+			result = math.Min(result, points[i].DistanceTo(points[j]))
+		}
+	}
+	return result
+}
+
+// checkSeedReproducible checks that emit returns bit-identical points for the
+// same seed and different points for a different seed.
+func checkSeedReproducible(t *testing.T, emit func(seed int64) []*Vector3D.Vector3D) {
+
+	t.Helper()
+	points := emit(1)
+	if len(points) == 0 {
+		t.Fatalf("expected points to compare")
+	}
+	if !samePoints(emit(1), points) {
+		t.Errorf("expected bit-identical points for the same seed")
+	}
+	if samePoints(emit(2), points) {
+		t.Errorf("expected different points for a different seed")
+	}
+}
+
+// samePoints returns true if both lists hold exactly the same points in the
+// same order.
+func samePoints(a, b []*Vector3D.Vector3D) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].X != b[i].X || a[i].Y != b[i].Y || a[i].Z != b[i].Z {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSurfaceParticleEmitter3(t *testing.T) {
+
+	cube := NewTriangleMesh3(nil, nil)
+	if err := cube.readObj(strings.NewReader(unitCubeObj)); err != nil {
+		t.Fatal(err)
+	}
+	region := NewBoundingBox3D(Vector3D.NewVector(-1, -1, -1), Vector3D.NewVector(2, 2, 2))
+
+	areas := []float64{6, math.Pi}
+	for i, surface := range []ImplicitSurface3{cube, NewSphere3(Vector3D.NewVector(0.5, 0.5, 0.5), 0.5)} {
+		particles := NewParticleSystemData3()
+		emitter := NewSurfaceParticleEmitter3(surface, region, 0.1, Vector3D.NewVector(0, 1, 0))
+		emitter.setTarget(particles)
+		emitter.update(0, 0.1)
+		emitter.update(0.1, 0.1)
+
+		positions := particles.positions()
+		// As many particles as a triangle lattice of the spacing on the surface.
+		lattice := areas[i] * 2 / (math.Sqrt(3) * 0.1 * 0.1)
+		if ratio := float64(len(positions)) / lattice; math.Abs(ratio-1) > 0.15 {
+			t.Errorf("expected about %.0f particles on the surface, got %d", lattice, len(positions))
+		}
+		for _, p := range positions {
+			if d := math.Abs(surface.signedDistance(p)); d > 1e-3 {
+				t.Fatalf("expected particles on the surface, got %v at distance %v", p, d)
+			}
+		}
+		if d := minimumPairDistance(positions); d < kPoissonDiskRadiusScale2*0.1 {
+			t.Errorf("expected a minimum distance of %v, got %v", kPoissonDiskRadiusScale2*0.1, d)
+		}
+		if v := particles.velocities()[0]; v.DistanceTo(Vector3D.NewVector(0, 1, 0)) > 0 {
+			t.Errorf("expected the initial velocity, got %v", v)
+		}
+	}
+}
+
+func TestMeshVolumeParticleEmitter3(t *testing.T) {
+
+	cube := NewTriangleMesh3(nil, nil)
+	if err := cube.readObj(strings.NewReader(unitCubeObj)); err != nil {
+		t.Fatal(err)
+	}
+	region := NewBoundingBox3D(Vector3D.NewVector(-1, -1, -1), Vector3D.NewVector(2, 2, 2))
+
+	emit := func(seed int64) []*Vector3D.Vector3D {
+		particles := NewParticleSystemData3()
+		emitter := NewMeshVolumeParticleEmitter3(cube, region, 0.1, Vector3D.NewVector(0, 0, 0))
+		emitter.setSeed(seed)
+		emitter.setTarget(particles)
+		emitter.update(0, 0.1)
+		return particles.positions()
+	}
+
+	positions := emit(1)
+	lattice := make([]*Vector3D.Vector3D, 0)
+	NewBccLatticePointGenerator().generate(cube.boundingBox(), 0.1, &lattice)
+	if ratio := float64(len(positions)) / float64(len(lattice)); math.Abs(ratio-1) > 0.1 {
+		t.Errorf("expected the density of the BCC lattice, got %d particles for %d lattice points", len(positions), len(lattice))
+	}
+	for _, p := range positions {
+		if cube.signedDistance(p) > 0 {
+			t.Fatalf("expected particles inside the mesh, got %v", p)
+		}
+	}
+	if d := minimumPairDistance(positions); d < kPoissonDiskRadiusScale3*0.1 {
+		t.Errorf("expected a minimum distance of %v, got %v", kPoissonDiskRadiusScale3*0.1, d)
+	}
+	checkSeedReproducible(t, emit)
+}
@@ -0,0 +1,35 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// Torus2 is the 2-D counterpart of Torus3: the two disks of radius
+// minorRadius cut from the torus through its axis, centered majorRadius to
+// each side of center.
+type Torus2 struct {
+	*surfaceOfRevolution2
+
+	// Distance from center to the center of the tube.
+	majorRadius float64
+
+	// Radius of the tube.
+	minorRadius float64
+}
+
+func NewTorus2(center *Vector3D.Vector3D, majorRadius, minorRadius float64) *Torus2 {
+	t := &Torus2{
+		majorRadius: majorRadius,
+		minorRadius: minorRadius,
+	}
+	t.surfaceOfRevolution2 = newSurfaceOfRevolution2(center, t)
+	return t
+}
+
+func (t *Torus2) closestProfilePoint(r, y float64) *profileQueryResult {
+
+	return torusProfilePoint(t.majorRadius, t.minorRadius, r, y)
+}
+
+func (t *Torus2) profileExtent() (float64, float64, float64) {
+
+	return t.majorRadius + t.minorRadius, -t.minorRadius, t.minorRadius
+}
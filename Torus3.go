@@ -0,0 +1,56 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+)
+
+// Torus3 is a 3-D torus (ring) geometry.
+// The torus lies in the xz-plane of its local frame around center: its tube
+// of radius minorRadius follows a circle of radius majorRadius around the
+// y-axis. majorRadius is expected to be larger than minorRadius.
+type Torus3 struct {
+	*surfaceOfRevolution3
+
+	// Distance from center to the center of the tube.
+	majorRadius float64
+
+	// Radius of the tube.
+	minorRadius float64
+}
+
+func NewTorus3(center *Vector3D.Vector3D, majorRadius, minorRadius float64) *Torus3 {
+	t := &Torus3{
+		majorRadius: majorRadius,
+		minorRadius: minorRadius,
+	}
+	t.surfaceOfRevolution3 = newSurfaceOfRevolution3(center, t)
+	return t
+}
+
+func (t *Torus3) closestProfilePoint(r, y float64) *profileQueryResult {
+
+	return torusProfilePoint(t.majorRadius, t.minorRadius, r, y)
+}
+
+func (t *Torus3) profileExtent() (float64, float64, float64) {
+
+	return t.majorRadius + t.minorRadius, -t.minorRadius, t.minorRadius
+}
+
+// torusProfilePoint queries the circular outline of the tube of a torus.
+func torusProfilePoint(majorRadius, minorRadius, r, y float64) *profileQueryResult {
+
+	d := Vector3D.NewVector(r-majorRadius, y, 0)
+	length := d.Length()
+
+	normal := Vector3D.NewVector(1, 0, 0)
+	if length > 0 {
+		normal = d.Divide(length)
+	}
+
+	return &profileQueryResult{
+		point:    Vector3D.NewVector(majorRadius+normal.X*minorRadius, normal.Y*minorRadius, 0),
+		normal:   normal,
+		isInside: length < minorRadius,
+	}
+}
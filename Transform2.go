@@ -1,6 +1,9 @@
 package main
 
-import Vector3D "jimmykiang/fluidengine/Vector3D"
+import (
+	Vector3D "jimmykiang/fluidengine/Vector3D"
+	"math"
+)
 
 type Transform2 struct {
 	translation *Vector3D.Vector3D
@@ -64,3 +67,17 @@ func (t *Transform2) toWorldDirection(dirInLocal *Vector3D.Vector3D) *Vector3D.V
 	y := t.sinAngle*dirInLocal.X + t.cosAngle*dirInLocal.Y
 	return Vector3D.NewVector(x, y, 0)
 }
+
+// setTranslation sets the translation.
+func (t *Transform2) setTranslation(translation *Vector3D.Vector3D) {
+
+	t.translation = Vector3D.NewVector(translation.X, translation.Y, 0)
+}
+
+// setOrientation sets the orientation in radians.
+func (t *Transform2) setOrientation(orientation float64) {
+
+	t.orientation = orientation
+	t.cosAngle = math.Cos(orientation)
+	t.sinAngle = math.Sin(orientation)
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"jimmykiang/fluidengine/Vector3D"
+	"jimmykiang/fluidengine/constants"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TriangleMesh3 is a 3-D triangle mesh geometry.
+// Represents a closed 3-D triangle mesh which extends Surface3 by
+// overriding surface-related queries. The inside of the mesh is decided with
+// the generalized winding number, so small cracks or flipped faces in
+// artist-made meshes do not turn the whole volume inside out.
+type TriangleMesh3 struct {
+
+	// Base struct for 3-D surface.
+	surface3 *Surface3
+
+	// Vertex positions.
+	points []*Vector3D.Vector3D
+
+	// Vertex indices of each triangle.
+	pointIndices [][3]int64
+
+	// Local-to-world transform.
+	transform *Transform3
+
+	// Flips normal when calling Surface3::closestNormal(...).
+	isNormalFlipped bool
+}
+
+// NewTriangleMesh3 constructs a mesh from vertex positions and triangles
+// given as triplets of indices into points.
+func NewTriangleMesh3(points []*Vector3D.Vector3D, pointIndices [][3]int64) *TriangleMesh3 {
+	return &TriangleMesh3{
+		surface3:        NewSurface3(),
+		points:          points,
+		pointIndices:    pointIndices,
+		transform:       NewTransform3(),
+		isNormalFlipped: false,
+	}
+}
+
+// NewTriangleMesh3FromObjFile loads a mesh from a Wavefront OBJ file.
+func NewTriangleMesh3FromObjFile(fileName string) (*TriangleMesh3, error) {
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := NewTriangleMesh3(nil, nil)
+	if err := m.readObj(f); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	return m, nil
+}
+
+// readObj appends the vertices and faces of a Wavefront OBJ stream to the
+// mesh. Only "v" and "f" statements are used; polygons are fan-triangulated
+// and texture coordinates, normals, groups and materials are ignored.
+func (m *TriangleMesh3) readObj(r io.Reader) error {
+
+	base := int64(len(m.points))
+	numberOfObjPoints := int64(0)
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return fmt.Errorf("line %d: vertex needs 3 coordinates", lineNumber)
+			}
+			var xyz [3]float64
+			for i := 0; i < 3; i++ {
+				val, err := strconv.ParseFloat(fields[i+1], 64)
+				if err != nil {
+					return fmt.Errorf("line %d: %v", lineNumber, err)
+				}
+				xyz[i] = val
+			}
+			m.points = append(m.points, Vector3D.NewVector(xyz[0], xyz[1], xyz[2]))
+			numberOfObjPoints++
+
+		case "f":
+			if len(fields) < 4 {
+				return fmt.Errorf("line %d: face needs at least 3 vertices", lineNumber)
+			}
+			face := make([]int64, 0, len(fields)-1)
+			for _, field := range fields[1:] {
+
+				// Faces may be given as v, v/vt, v//vn or v/vt/vn.
+				idx, err := strconv.ParseInt(strings.SplitN(field, "/", 2)[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("line %d: %v", lineNumber, err)
+				}
+
+				// OBJ indices start at 1, negative indices count back from
+				// the last vertex read so far.
+				if idx < 0 {
+					idx += numberOfObjPoints
+				} else {
+					idx--
+				}
+				if idx < 0 || idx >= numberOfObjPoints {
+					return fmt.Errorf("line %d: vertex index %s out of range", lineNumber, field)
+				}
+				face = append(face, base+idx)
+			}
+			for i := 1; i+1 < len(face); i++ {
+				m.pointIndices = append(m.pointIndices, [3]int64{face[0], face[i], face[i+1]})
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// numberOfTriangles returns the number of triangles of the mesh.
+func (m *TriangleMesh3) numberOfTriangles() int64 {
+
+	return int64(len(m.pointIndices))
+}
+
+// triangle returns the three vertices of the i-th triangle.
+func (m *TriangleMesh3) triangle(i int64) (*Vector3D.Vector3D, *Vector3D.Vector3D, *Vector3D.Vector3D) {
+
+	idx := m.pointIndices[i]
+	return m.points[idx[0]], m.points[idx[1]], m.points[idx[2]]
+}
+
+// isBounded returns true if bounding box can be defined.
+func (m *TriangleMesh3) isBounded() bool {
+
+	return len(m.points) != 0
+}
+
+// boundingBox returns the bounding box of this surface object.
+func (m *TriangleMesh3) boundingBox() *BoundingBox3D {
+
+	return m.transform.toWorldBoundingBox(m.boundingBoxLocal())
+}
+
+func (m *TriangleMesh3) boundingBoxLocal() *BoundingBox3D {
+
+	bound := NewBoundingBox3DReset()
+	for _, point := range m.points {
+		bound.merge(NewBoundingBox3D(point, point))
+	}
+	return bound
+}
+
+func (m *TriangleMesh3) signedDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	otherPointLocal := m.transform.toLocal(otherPoint)
+	x := m.closestPointLocal(otherPointLocal)
+
+	sd := x.DistanceTo(otherPointLocal)
+	if m.isInsideLocal(otherPointLocal) {
+		sd = -sd
+	}
+	if m.isNormalFlipped {
+		sd = -sd
+	}
+	return sd
+}
+
+// Returns the closest point from the given point otherPoint to the surface.
+func (m *TriangleMesh3) closestPoint(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	otherPointLocal := m.transform.toLocal(otherPoint)
+	d := m.closestPointLocal(otherPointLocal)
+	return m.transform.toWorld(d)
+}
+
+// Returns the closest distance from the given point otherPoint to the surface.
+func (m *TriangleMesh3) closestDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	otherPointLocal := m.transform.toLocal(otherPoint)
+	d := m.closestPointLocal(otherPointLocal)
+	return otherPointLocal.Substract(d).Length()
+}
+
+// closestNormal returns the normal to the closest point on the surface from the given
+// point.
+func (m *TriangleMesh3) closestNormal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	result := m.transform.toWorldDirection(m.closestNormalLocal(m.transform.toLocal(otherPoint)))
+	if m.isNormalFlipped {
+		result = result.Multiply(-1)
+	}
+	return result
+}
+
+// Returns true if otherPoint is inside the volume defined by the surface.
+func (m *TriangleMesh3) isInside(otherPoint *Vector3D.Vector3D) bool {
+
+	return m.isNormalFlipped == !m.isInsideLocal(m.transform.toLocal(otherPoint))
+}
+
+func (m *TriangleMesh3) getTransform() *Transform3 {
+	return m.transform
+}
+
+// getIsNormalFlipped returns true if the normal is flipped.
+func (m *TriangleMesh3) getIsNormalFlipped() bool {
+	return m.isNormalFlipped
+}
+
+func (m *TriangleMesh3) closestPointLocal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	_, closestPoint := m.closestTriangleLocal(otherPoint)
+	return closestPoint
+}
+
+func (m *TriangleMesh3) closestNormalLocal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	i, _ := m.closestTriangleLocal(otherPoint)
+	if i < 0 {
+		return Vector3D.NewVector(1, 0, 0)
+	}
+	a, b, c := m.triangle(i)
+	return triangleNormal(a, b, c)
+}
+
+// closestTriangleLocal returns the index of the triangle closest to the given
+// point together with the closest point on it, or -1 for an empty mesh.
+func (m *TriangleMesh3) closestTriangleLocal(otherPoint *Vector3D.Vector3D) (int64, *Vector3D.Vector3D) {
+
+	closestIndex := int64(-1)
+	closestPoint := Vector3D.NewVector(math.MaxFloat64, math.MaxFloat64, math.MaxFloat64)
+	minDistanceSquared := math.MaxFloat64
+
+	for i := int64(0); i < m.numberOfTriangles(); i++ {
+
+		a, b, c := m.triangle(i)
+		localResult := closestPointOnTriangle(otherPoint, a, b, c)
+		localDistanceSquared := localResult.DistanceSquaredTo(otherPoint)
+
+		if localDistanceSquared < minDistanceSquared {
+			closestIndex = i
+			closestPoint = localResult
+			minDistanceSquared = localDistanceSquared
+		}
+	}
+	return closestIndex, closestPoint
+}
+
+// Returns true if otherPoint is inside the volume defined by the surface in
+// local frame.
+func (m *TriangleMesh3) isInsideLocal(otherPointLocal *Vector3D.Vector3D) bool {
+
+	return m.windingNumber(otherPointLocal) > 0.5
+}
+
+// windingNumber returns the generalized winding number of the mesh at the
+// given point in local frame: the sum of the signed solid angles subtended by
+// the triangles divided by 4*pi. It is 1 inside and 0 outside a closed,
+// outward-oriented mesh, and degrades smoothly for meshes with holes.
+func (m *TriangleMesh3) windingNumber(otherPointLocal *Vector3D.Vector3D) float64 {
+
+	sum := 0.0
+	for i := int64(0); i < m.numberOfTriangles(); i++ {
+
+		a, b, c := m.triangle(i)
+		sum += triangleSolidAngle(otherPointLocal, a, b, c)
+	}
+	return sum / (4 * constants.KPiD)
+}
+
+// triangleSolidAngle returns the signed solid angle subtended by the triangle
+// (a, b, c) as seen from point p, using the formula of Van Oosterom and
+// Strackee.
+func triangleSolidAngle(p, a, b, c *Vector3D.Vector3D) float64 {
+
+	pa := a.Substract(p)
+	pb := b.Substract(p)
+	pc := c.Substract(p)
+	la := pa.Length()
+	lb := pb.Length()
+	lc := pc.Length()
+
+	numerator := pa.DotProduct(pb.CrossProduct(pc))
+	denominator := la*lb*lc + pa.DotProduct(pb)*lc + pb.DotProduct(pc)*la + pc.DotProduct(pa)*lb
+	return 2 * math.Atan2(numerator, denominator)
+}
+
+// triangleNormal returns the unit normal of the counter-clockwise triangle
+// (a, b, c).
+func triangleNormal(a, b, c *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	ab := b.Substract(a)
+	ac := c.Substract(a)
+	return ab.CrossProduct(ac).Normalize()
+}
+
+// closestPointOnTriangle returns the point of triangle (a, b, c) closest to p,
+// by finding the Voronoi region of the triangle that contains p.
+func closestPointOnTriangle(p, a, b, c *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	ab := b.Substract(a)
+	ac := c.Substract(a)
+	ap := p.Substract(a)
+
+	d1 := ab.DotProduct(ap)
+	d2 := ac.DotProduct(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return Vector3D.NewVector(a.X, a.Y, a.Z)
+	}
+
+	bp := p.Substract(b)
+	d3 := ab.DotProduct(bp)
+	d4 := ac.DotProduct(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return Vector3D.NewVector(b.X, b.Y, b.Z)
+	}
+
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		v := d1 / (d1 - d3)
+		return a.Add(ab.Multiply(v))
+	}
+
+	cp := p.Substract(c)
+	d5 := ab.DotProduct(cp)
+	d6 := ac.DotProduct(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return Vector3D.NewVector(c.X, c.Y, c.Z)
+	}
+
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		w := d2 / (d2 - d6)
+		return a.Add(ac.Multiply(w))
+	}
+
+	va := d3*d6 - d5*d4
+	if va <= 0 && d4-d3 >= 0 && d5-d6 >= 0 {
+		w := (d4 - d3) / ((d4 - d3) + (d5 - d6))
+		bc := c.Substract(b)
+		return b.Add(bc.Multiply(w))
+	}
+
+	denominator := 1 / (va + vb + vc)
+	v := vb * denominator
+	w := vc * denominator
+	result := a.Add(ab.Multiply(v))
+	result.AddScaledInPlace(ac, w)
+	return result
+}
+
+// closestIntersection returns the first hit of the ray with the triangles of
+// the mesh.
+func (m *TriangleMesh3) closestIntersection(ray *Ray3) *SurfaceRayIntersection3 {
+
+	return intersectionToWorld(m.transform, m.isNormalFlipped, m.closestIntersectionLocal(m.transform.toLocalRay(ray)))
+}
+
+// intersects returns true if the ray hits the mesh.
+func (m *TriangleMesh3) intersects(ray *Ray3) bool {
+
+	rayLocal := m.transform.toLocalRay(ray)
+	for i := int64(0); i < m.numberOfTriangles(); i++ {
+
+		a, b, c := m.triangle(i)
+		if rayTriangleDistance(rayLocal, a, b, c) < math.MaxFloat64 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *TriangleMesh3) closestIntersectionLocal(ray *Ray3) *SurfaceRayIntersection3 {
+
+	intersection := NewSurfaceRayIntersection3()
+
+	for i := int64(0); i < m.numberOfTriangles(); i++ {
+
+		a, b, c := m.triangle(i)
+		if t := rayTriangleDistance(ray, a, b, c); t < intersection.distance {
+			intersection.isIntersecting = true
+			intersection.distance = t
+			intersection.normal = triangleNormal(a, b, c)
+		}
+	}
+
+	if intersection.isIntersecting {
+		intersection.point = ray.pointAt(intersection.distance)
+	}
+	return intersection
+}
+
+// rayTriangleDistance returns the distance along the ray to the triangle
+// (a, b, c), or math.MaxFloat64 if the ray misses it. Both sides of the
+// triangle are hit. Uses the Moller-Trumbore algorithm.
+func rayTriangleDistance(ray *Ray3, a, b, c *Vector3D.Vector3D) float64 {
+
+	ab := b.Substract(a)
+	ac := c.Substract(a)
+
+	pvec := ray.direction.CrossProduct(ac)
+	det := ab.DotProduct(pvec)
+	if math.Abs(det) < constants.KEpsilonD {
+		return math.MaxFloat64
+	}
+	invDet := 1 / det
+
+	tvec := ray.origin.Substract(a)
+	u := tvec.DotProduct(pvec) * invDet
+	if u < 0 || u > 1 {
+		return math.MaxFloat64
+	}
+
+	qvec := tvec.CrossProduct(ab)
+	v := ray.direction.DotProduct(qvec) * invDet
+	if v < 0 || u+v > 1 {
+		return math.MaxFloat64
+	}
+
+	t := ac.DotProduct(qvec) * invDet
+	if t < 0 {
+		return math.MaxFloat64
+	}
+	return t
+}
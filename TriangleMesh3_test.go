@@ -0,0 +1,79 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"strings"
+	"testing"
+)
+
+// unitCubeObj is an outward-oriented unit cube made of quads.
+const unitCubeObj = `# unit cube
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+v 0 0 1
+v 1 0 1
+v 1 1 1
+v 0 1 1
+f 1 4 3 2
+f 5 6 7 8
+f 1 2 6 5
+f 2/1 3/2 7/3 6/4
+f 3//1 4//1 8//1 7//1
+f -8 -4 -1 -5
+`
+
+func TestTriangleMesh3ReadObj(t *testing.T) {
+
+	mesh := NewTriangleMesh3(nil, nil)
+	if err := mesh.readObj(strings.NewReader(unitCubeObj)); err != nil {
+		t.Fatal(err)
+	}
+	if len(mesh.points) != 8 || mesh.numberOfTriangles() != 12 {
+		t.Fatalf("expected 8 points and 12 triangles, got %d and %d", len(mesh.points), mesh.numberOfTriangles())
+	}
+
+	if err := NewTriangleMesh3(nil, nil).readObj(strings.NewReader("v 0 0 0\nf 1 2 3\n")); err == nil {
+		t.Errorf("expected an error for an out of range face index")
+	}
+}
+
+func TestTriangleMesh3SignedDistance(t *testing.T) {
+
+	mesh := NewTriangleMesh3(nil, nil)
+	if err := mesh.readObj(strings.NewReader(unitCubeObj)); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		point    *Vector3D.Vector3D
+		expected float64
+	}{
+		{Vector3D.NewVector(0.5, 0.5, 0.5), -0.5},
+		{Vector3D.NewVector(0.5, 0.9, 0.5), -0.1},
+		{Vector3D.NewVector(0.5, 2, 0.5), 1},
+		{Vector3D.NewVector(2, 2, 0.5), math.Sqrt(2)},
+	}
+	for _, c := range cases {
+		if sd := mesh.signedDistance(c.point); math.Abs(sd-c.expected) > 1e-9 {
+			t.Errorf("signedDistance(%v): expected %v, got %v", c.point, c.expected, sd)
+		}
+	}
+
+	if w := mesh.windingNumber(Vector3D.NewVector(0.3, 0.6, 0.2)); math.Abs(w-1) > 1e-9 {
+		t.Errorf("expected winding number 1 inside, got %v", w)
+	}
+	if w := mesh.windingNumber(Vector3D.NewVector(3, 0.6, 0.2)); math.Abs(w) > 1e-9 {
+		t.Errorf("expected winding number 0 outside, got %v", w)
+	}
+
+	normal := mesh.closestNormal(Vector3D.NewVector(0.5, 2, 0.5))
+	if !normal.IsSimilar(Vector3D.NewVector(0, 1, 0)) {
+		t.Errorf("expected normal (0, 1, 0), got %v", normal)
+	}
+	if !mesh.isInside(Vector3D.NewVector(0.5, 0.5, 0.5)) || mesh.isInside(Vector3D.NewVector(0.5, 2, 0.5)) {
+		t.Errorf("unexpected isInside result")
+	}
+}
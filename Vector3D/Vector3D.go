@@ -130,17 +130,29 @@ func (v *Vector3D) Tangential() []*Vector3D {
 // Returns the distance to the other vector.
 func (v *Vector3D) DistanceTo(other *Vector3D) float64 {
 
-	return v.Substract(other).Length()
+	return math.Sqrt(v.DistanceSquaredTo(other))
 }
 
 func (v *Vector3D) Min(o *Vector3D) *Vector3D {
 
-	return NewVector(math.Min(v.X, o.X), math.Min(v.Y, o.Y), 0)
+	return NewVector(math.Min(v.X, o.X), math.Min(v.Y, o.Y), math.Min(v.Z, o.Z))
 }
 
 func (v *Vector3D) Max(o *Vector3D) *Vector3D {
 
-	return NewVector(math.Max(v.X, o.X), math.Max(v.Y, o.Y), 0)
+	return NewVector(math.Max(v.X, o.X), math.Max(v.Y, o.Y), math.Max(v.Z, o.Z))
+}
+
+// At returns the component along the given axis (0 for X, 1 for Y, 2 for Z).
+func (v *Vector3D) At(axis int) float64 {
+	switch axis {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	default:
+		return v.Z
+	}
 }
 
 func (v *Vector3D) IsSimilar(other *Vector3D) bool {
@@ -153,5 +165,40 @@ func (v *Vector3D) IsSimilar(other *Vector3D) bool {
 
 func (v *Vector3D) DistanceSquaredTo(other *Vector3D) float64 {
 
-	return v.Substract(other).Squared()
+	return square(v.X-other.X) + square(v.Y-other.Y) + square(v.Z-other.Z)
+}
+
+// AddInPlace adds i to this vector without allocating a new one.
+func (v *Vector3D) AddInPlace(i *Vector3D) {
+	v.X += i.X
+	v.Y += i.Y
+	v.Z += i.Z
+}
+
+// SubstractInPlace substracts i from this vector without allocating a new one.
+func (v *Vector3D) SubstractInPlace(i *Vector3D) {
+	v.X -= i.X
+	v.Y -= i.Y
+	v.Z -= i.Z
+}
+
+// MultiplyInPlace scales this vector without allocating a new one.
+func (v *Vector3D) MultiplyInPlace(i float64) {
+	v.X *= i
+	v.Y *= i
+	v.Z *= i
+}
+
+// DivideInPlace divides this vector without allocating a new one.
+func (v *Vector3D) DivideInPlace(i float64) {
+	v.X /= i
+	v.Y /= i
+	v.Z /= i
+}
+
+// AddScaledInPlace computes this += i * s without allocating a new vector.
+func (v *Vector3D) AddScaledInPlace(i *Vector3D, s float64) {
+	v.X += i.X * s
+	v.Y += i.Y * s
+	v.Z += i.Z * s
 }
@@ -0,0 +1,9 @@
+package main
+
+import "jimmykiang/fluidengine/Vector3D"
+
+// VectorField3IF is the interface shared by 3-D vector fields.
+type VectorField3IF interface {
+	// sample returns the value of the field at the given point.
+	sample(x *Vector3D.Vector3D) *Vector3D.Vector3D
+}
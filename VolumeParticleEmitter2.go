@@ -14,10 +14,11 @@ import (
 // the particle generation region.
 type VolumeParticleEmitter2 struct {
 	implicitSurface          *ImplicitSurfaceSet2
-	particles                *SphSystemData2
+	particles                *ParticleSystemData3
 	bounds                   *BoundingBox2D
 	spacing                  float64
 	initialVel               *Vector3D.Vector3D
+	initialVelField          VectorField3IF
 	linearVel                *Vector3D.Vector3D
 	angularVel               float64
 	maxNumberOfParticles     float64
@@ -25,8 +26,9 @@ type VolumeParticleEmitter2 struct {
 	isOneShot                bool
 	allowOverlapping         bool
 	seed                     int64
+	rng                      *rand.Rand
 	isEnabled                bool
-	pointsGen                *TrianglePointGenerator
+	pointsGen                PointGenerator2IF
 	numberOfEmittedParticles float64
 }
 
@@ -41,6 +43,7 @@ func NewVolumeParticleEmitter2(
 		bounds:                   maxRegion,
 		spacing:                  spacing,
 		initialVel:               initialVel,
+		initialVelField:          nil,
 		linearVel:                Vector3D.NewVector(0, 0, 0),
 		angularVel:               0,
 		maxNumberOfParticles:     constants.KMaxSize,
@@ -49,27 +52,71 @@ func NewVolumeParticleEmitter2(
 		isOneShot:                true,
 		allowOverlapping:         false,
 		seed:                     0,
+		rng:                      rand.New(rand.NewSource(0)),
 		isEnabled:                true,
 		pointsGen:                NewTrianglePointGenerator(),
 	}
 }
 
-func (e *VolumeParticleEmitter2) setTarget(particles *SphSystemData2) {
+// setInitialVelocityField samples the initial velocity of every particle from
+// the field instead of the constant initial velocity. A nil field restores the
+// constant.
+func (e *VolumeParticleEmitter2) setInitialVelocityField(field VectorField3IF) {
+
+	e.initialVelField = field
+}
+
+// setLinearVelocity sets the linear velocity added to every emitted particle.
+func (e *VolumeParticleEmitter2) setLinearVelocity(linearVel *Vector3D.Vector3D) {
+
+	e.linearVel = Vector3D.NewVector(linearVel.X, linearVel.Y, linearVel.Z)
+}
+
+// setAngularVelocity sets the counter-clockwise angular velocity in radians per
+// second of the rigid rotation added to every emitted particle. The rotation
+// is around the translation of the first surface of the set.
+func (e *VolumeParticleEmitter2) setAngularVelocity(angularVel float64) {
+
+	e.angularVel = angularVel
+}
+
+// setJitter sets the random displacement of the candidate points as a
+// fraction of the spacing, clamped to [0, 1].
+func (e *VolumeParticleEmitter2) setJitter(jitter float64) {
+
+	e.jitter = math.Min(math.Max(jitter, 0), 1)
+}
+
+// setSeed restarts the random sequence of the emitter from the given seed.
+func (e *VolumeParticleEmitter2) setSeed(seed int64) {
+
+	e.seed = seed
+	e.rng = rand.New(rand.NewSource(seed))
+}
+
+// setPointGenerator sets the generator of the candidate points, for example a
+// PoissonDiskPointGenerator2 instead of the default triangle lattice.
+func (e *VolumeParticleEmitter2) setPointGenerator(pointsGen PointGenerator2IF) {
+
+	e.pointsGen = pointsGen
+}
+
+func (e *VolumeParticleEmitter2) setTarget(particles *ParticleSystemData3) {
 
 	e.particles = particles
 	e.onSetTarget(particles)
 }
 
-func (e *VolumeParticleEmitter2) onSetTarget(particles *SphSystemData2) {
+func (e *VolumeParticleEmitter2) onSetTarget(particles *ParticleSystemData3) {
 
 	// Do nothing.
 }
 
-func (e *VolumeParticleEmitter2) onUpdate() {
+func (e *VolumeParticleEmitter2) update(currentTimeInSeconds, timeIntervalInSeconds float64) {
 
 	particles := e.particles
 
-	if !e.isEnabled {
+	if particles == nil || !e.isEnabled {
 		return
 	}
 
@@ -85,7 +132,7 @@ func (e *VolumeParticleEmitter2) onUpdate() {
 	}
 }
 
-func (e *VolumeParticleEmitter2) emit(particles *SphSystemData2, newPositions, newVelocities *[]*Vector3D.Vector3D) {
+func (e *VolumeParticleEmitter2) emit(particles *ParticleSystemData3, newPositions, newVelocities *[]*Vector3D.Vector3D) {
 
 	e.implicitSurface.updateQueryEngine()
 
@@ -95,15 +142,13 @@ func (e *VolumeParticleEmitter2) emit(particles *SphSystemData2, newPositions, n
 		//todo: surfaceBBox:=
 	}
 
-	// Reserving more space for jittering
-	j := e.jitter
-	maxJitterDist := 0.5 * j * e.spacing
+	// Candidates are displaced by up to jitter times the spacing.
+	maxJitterDist := e.jitter * e.spacing
 	numNewParticles := 0.0
 
 	callback := func(points *([]*Vector3D.Vector3D), point *Vector3D.Vector3D) bool {
-		newAngleInRadian := (rand.Float64() - 0.5) * math.Pi * 2
-		rotationMatrix := makeRotationMatrix(newAngleInRadian)
-		randomDir := rotationMatrix.MultiplyMatrixByTuple(Vector3D.NewVector(0, 0, 0))
+		newAngleInRadian := (e.rng.Float64() - 0.5) * math.Pi * 2
+		randomDir := NewMatrix2x2Rotation(newAngleInRadian).MulVector(Vector3D.NewVector(1, 0, 0))
 		offset := randomDir.Multiply(maxJitterDist)
 		candidate := point.Add(offset)
 
@@ -139,15 +184,24 @@ func (e *VolumeParticleEmitter2) emit(particles *SphSystemData2, newPositions, n
 
 func (e *VolumeParticleEmitter2) velocityAt(point *Vector3D.Vector3D) *Vector3D.Vector3D {
 
-	r := point.Substract(e.implicitSurface.surfaces[0].getTransform().translation)
+	r := point.Substract(e.implicitSurface.transform.translation)
 	a := Vector3D.NewVector(-r.Y, r.X, 0).Multiply(e.angularVel)
-	return a.Add(e.linearVel).Add(e.initialVel)
+	return a.Add(e.linearVel).Add(e.initialVelocityAt(point))
+}
+
+// initialVelocityAt returns the initial velocity from the field if one is set.
+func (e *VolumeParticleEmitter2) initialVelocityAt(point *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	if e.initialVelField != nil {
+		return e.initialVelField.sample(point)
+	}
+	return e.initialVel
 }
 
 func (e *VolumeParticleEmitter2) parallelForEachIndex(newVelocities, newPositions *[]*Vector3D.Vector3D) {
-	for i := 0.0; i < e.numberOfEmittedParticles; i++ {
+	for i := 0; i < len(*newPositions); i++ {
 
-		e.callback(i, newVelocities, newPositions)
+		e.callback(float64(i), newVelocities, newPositions)
 	}
 }
 
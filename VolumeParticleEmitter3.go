@@ -14,10 +14,11 @@ import (
 // the particle generation region.
 type VolumeParticleEmitter3 struct {
 	implicitSurface          *ImplicitSurfaceSet3
-	particles                *SphSystemData3
+	particles                *ParticleSystemData3
 	bounds                   *BoundingBox3D
 	spacing                  float64
 	initialVel               *Vector3D.Vector3D
+	initialVelField          VectorField3IF
 	linearVel                *Vector3D.Vector3D
 	angularVel               *Vector3D.Vector3D
 	maxNumberOfParticles     float64
@@ -25,9 +26,14 @@ type VolumeParticleEmitter3 struct {
 	isOneShot                bool
 	allowOverlapping         bool
 	seed                     int64
+	rng                      *rand.Rand
 	isEnabled                bool
-	pointsGen                *BccLatticePointGenerator
+	pointsGen                PointGenerator3IF
 	numberOfEmittedParticles float64
+	// Emission rate of a continuous emitter. Zero fills all free space of the
+	// volume every update.
+	maxNumberOfNewParticlesPerSecond float64
+	firstFrameTimeInSeconds          float64
 }
 
 func NewVolumeParticleEmitter3(
@@ -41,6 +47,7 @@ func NewVolumeParticleEmitter3(
 		bounds:                   maxRegion,
 		spacing:                  spacing,
 		initialVel:               initialVel,
+		initialVelField:          nil,
 		linearVel:                Vector3D.NewVector(0, 0, 0),
 		angularVel:               Vector3D.NewVector(0, 0, 0),
 		maxNumberOfParticles:     constants.KMaxSize,
@@ -49,43 +56,140 @@ func NewVolumeParticleEmitter3(
 		isOneShot:                true,
 		allowOverlapping:         false,
 		seed:                     0,
+		rng:                      rand.New(rand.NewSource(0)),
 		isEnabled:                true,
 		pointsGen:                NewBccLatticePointGenerator(),
+
+		maxNumberOfNewParticlesPerSecond: 0,
+		firstFrameTimeInSeconds:          0,
 	}
 }
 
-func (e *VolumeParticleEmitter3) setTarget(particles *SphSystemData3) {
+// setInitialVelocityField samples the initial velocity of every particle from
+// the field instead of the constant initial velocity. A nil field restores the
+// constant.
+func (e *VolumeParticleEmitter3) setInitialVelocityField(field VectorField3IF) {
+
+	e.initialVelField = field
+}
+
+// setLinearVelocity sets the linear velocity added to every emitted particle.
+func (e *VolumeParticleEmitter3) setLinearVelocity(linearVel *Vector3D.Vector3D) {
+
+	e.linearVel = Vector3D.NewVector(linearVel.X, linearVel.Y, linearVel.Z)
+}
+
+// setAngularVelocity sets the angular velocity of the rigid rotation added to
+// every emitted particle. The rotation is around the translation of the
+// implicit surface.
+func (e *VolumeParticleEmitter3) setAngularVelocity(angularVel *Vector3D.Vector3D) {
+
+	e.angularVel = Vector3D.NewVector(angularVel.X, angularVel.Y, angularVel.Z)
+}
+
+// setJitter sets the random displacement of the candidate points as a
+// fraction of the spacing, clamped to [0, 1].
+func (e *VolumeParticleEmitter3) setJitter(jitter float64) {
+
+	e.jitter = math.Min(math.Max(jitter, 0), 1)
+}
+
+// setSeed restarts the random sequence of the emitter from the given seed.
+func (e *VolumeParticleEmitter3) setSeed(seed int64) {
+
+	e.seed = seed
+	e.rng = rand.New(rand.NewSource(seed))
+}
+
+// setPointGenerator sets the generator of the candidate points, for example a
+// PoissonDiskPointGenerator3 instead of the default BCC lattice.
+func (e *VolumeParticleEmitter3) setPointGenerator(pointsGen PointGenerator3IF) {
+
+	e.pointsGen = pointsGen
+}
+
+// setIsOneShot sets whether the emitter disables itself after the first update.
+func (e *VolumeParticleEmitter3) setIsOneShot(isOneShot bool) {
+
+	e.isOneShot = isOneShot
+}
+
+// setAllowOverlapping sets whether new particles may be spawned closer than
+// the spacing to existing particles.
+func (e *VolumeParticleEmitter3) setAllowOverlapping(allowOverlapping bool) {
+
+	e.allowOverlapping = allowOverlapping
+}
+
+// setMaxNumberOfParticles sets the total number of particles the emitter may spawn.
+func (e *VolumeParticleEmitter3) setMaxNumberOfParticles(maxNumberOfParticles float64) {
+
+	e.maxNumberOfParticles = maxNumberOfParticles
+}
+
+// setMaxNumberOfNewParticlesPerSecond sets the emission rate of a continuous
+// emitter. Zero removes the limit.
+func (e *VolumeParticleEmitter3) setMaxNumberOfNewParticlesPerSecond(rate float64) {
+
+	e.maxNumberOfNewParticlesPerSecond = math.Max(rate, 0)
+}
+
+func (e *VolumeParticleEmitter3) setTarget(particles *ParticleSystemData3) {
 
 	e.particles = particles
 	e.onSetTarget(particles)
 }
 
-func (e *VolumeParticleEmitter3) onSetTarget(particles *SphSystemData3) {
+func (e *VolumeParticleEmitter3) onSetTarget(particles *ParticleSystemData3) {
 
 	// Do nothing.
 }
 
-func (e *VolumeParticleEmitter3) onUpdate() {
+// update emits new particles into the target. A one-shot emitter fills the
+// volume once and disables itself, a continuous emitter refills the free space
+// of the volume every update at up to its emission rate.
+func (e *VolumeParticleEmitter3) update(currentTimeInSeconds, timeIntervalInSeconds float64) {
 
 	particles := e.particles
 
-	if !e.isEnabled {
+	if particles == nil || !e.isEnabled {
 		return
 	}
 
-	newPositions := make([]*Vector3D.Vector3D, 0, 0)
-	newVelocities := make([]*Vector3D.Vector3D, 0, 0)
+	if e.numberOfEmittedParticles == 0 {
+		e.firstFrameTimeInSeconds = currentTimeInSeconds
+	}
 
-	e.emit(particles, &newPositions, &newVelocities)
+	maxNumberOfNewParticles := e.maxNumberOfParticles - e.numberOfEmittedParticles
+	if !e.isOneShot && e.maxNumberOfNewParticlesPerSecond > 0 {
+		elapsedTimeInSeconds := currentTimeInSeconds - e.firstFrameTimeInSeconds
+		newMaxTotalNumberOfEmittedParticles := math.Ceil((elapsedTimeInSeconds + timeIntervalInSeconds) *
+			e.maxNumberOfNewParticlesPerSecond)
+		maxNumberOfNewParticles = math.Min(
+			maxNumberOfNewParticles,
+			newMaxTotalNumberOfEmittedParticles-e.numberOfEmittedParticles,
+		)
+	}
+
+	if maxNumberOfNewParticles > 0 {
+		newPositions := make([]*Vector3D.Vector3D, 0, 0)
+		newVelocities := make([]*Vector3D.Vector3D, 0, 0)
+
+		e.emit(particles, maxNumberOfNewParticles, &newPositions, &newVelocities)
 
-	particles.addParticles(newPositions, newVelocities, nil)
+		particles.addParticles(newPositions, newVelocities, nil)
+	}
 
 	if e.isOneShot {
 		e.isEnabled = false
 	}
 }
 
-func (e *VolumeParticleEmitter3) emit(particles *SphSystemData3, newPositions, newVelocities *[]*Vector3D.Vector3D) {
+func (e *VolumeParticleEmitter3) emit(
+	particles *ParticleSystemData3,
+	maxNumberOfNewParticles float64,
+	newPositions, newVelocities *[]*Vector3D.Vector3D,
+) {
 
 	e.implicitSurface.updateQueryEngine()
 
@@ -95,47 +199,44 @@ func (e *VolumeParticleEmitter3) emit(particles *SphSystemData3, newPositions, n
 		//todo: surfaceBBox:=
 	}
 
-	// Reserving more space for jittering
-	j := e.jitter
-	maxJitterDist := 0.5 * j * e.spacing
+	// Candidates are displaced by up to jitter times the spacing.
+	maxJitterDist := e.jitter * e.spacing
 	numNewParticles := 0.0
 
+	// Only free space is filled unless overlapping is allowed. New candidates
+	// are checked against the particles that existed before this update; the
+	// point generator already keeps them apart from each other.
+	var neighborSearcher *PointParallelHashGridSearcher3
+	if !e.allowOverlapping && particles.numberOfParticles > 0 {
+		neighborSearcher = NewPointParallelHashGridSearcher3(
+			constants.KDefaultHashGridResolution,
+			constants.KDefaultHashGridResolution,
+			constants.KDefaultHashGridResolution,
+			2*e.spacing,
+		)
+		neighborSearcher.build(particles.positions())
+	}
+
 	callback := func(points *([]*Vector3D.Vector3D), point *Vector3D.Vector3D) bool {
 
-		randomDir := e.uniformSampleSphere(rand.Float64(), rand.Float64())
+		randomDir := e.uniformSampleSphere(e.rng.Float64(), e.rng.Float64())
 		offset := randomDir.Multiply(maxJitterDist)
 		candidate := point.Add(offset)
 
 		if e.implicitSurface.signedDistance(candidate) <= 0.0 {
-			if e.numberOfEmittedParticles < e.maxNumberOfParticles {
+			if numNewParticles >= maxNumberOfNewParticles {
+				return false
+			}
+			if neighborSearcher == nil || !neighborSearcher.hasNearbyPoint3(candidate, e.spacing) {
 				*newPositions = append(*newPositions, candidate)
 				e.numberOfEmittedParticles++
 				numNewParticles++
-			} else {
-				return false
 			}
 		}
 		return true
 	}
 
-	if e.allowOverlapping || e.isOneShot {
-
-		e.pointsGen.forEachPoint(region, e.spacing, nil, callback)
-	} else {
-		// Use serial hash grid searcher for continuous update.
-		// todo.
-	}
-
-	///////////////////////////
-	// not needed?
-	//*newVelocities = make([]*Vector3D.Vector3D, len(*newPositions), len(*newPositions))
-
-	// original code from: \FluidEngine\fluid-engine-dev\src\jet\volume_particle_emitter2.cpp
-	//newVelocities->parallelForEachIndex([&](size_t i) {
-	//	(*newVelocities)[i] = velocityAt((*newPositions)[i]);
-	//});
-
-	/////////////////////////////
+	e.pointsGen.forEachPoint(region, e.spacing, nil, callback)
 
 	e.parallelForEachIndex(newVelocities, newPositions)
 }
@@ -151,9 +252,9 @@ func (e *VolumeParticleEmitter3) uniformSampleSphere(u1 float64, u2 float64) *Ve
 }
 
 func (e *VolumeParticleEmitter3) parallelForEachIndex(newVelocities, newPositions *[]*Vector3D.Vector3D) {
-	for i := 0.0; i < e.numberOfEmittedParticles; i++ {
+	for i := 0; i < len(*newPositions); i++ {
 
-		e.callback(i, newVelocities, newPositions)
+		e.callback(float64(i), newVelocities, newPositions)
 	}
 }
 
@@ -167,5 +268,14 @@ func (e *VolumeParticleEmitter3) velocityAt(point *Vector3D.Vector3D) *Vector3D.
 	r := point.Substract(e.implicitSurface.transform.translation)
 	a := e.angularVel.CrossProduct(r)
 
-	return a.Add(e.linearVel).Add(e.initialVel)
+	return a.Add(e.linearVel).Add(e.initialVelocityAt(point))
+}
+
+// initialVelocityAt returns the initial velocity from the field if one is set.
+func (e *VolumeParticleEmitter3) initialVelocityAt(point *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	if e.initialVelField != nil {
+		return e.initialVelField.sample(point)
+	}
+	return e.initialVel
 }
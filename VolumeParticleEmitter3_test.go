@@ -0,0 +1,176 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"testing"
+)
+
+func newTestVolumeParticleEmitter3() (*VolumeParticleEmitter3, *SphSystemData3) {
+
+	surfaceSet := NewImplicitSurfaceSet3()
+	surfaceSet.addExplicitSurface(NewSphere3(Vector3D.NewVector(0, 0, 0), 0.2))
+	bounds := NewBoundingBox3D(Vector3D.NewVector(-0.3, -0.3, -0.3), Vector3D.NewVector(0.3, 0.3, 0.3))
+
+	particles := NewSphSystemData3()
+	emitter := NewVolumeParticleEmitter3(surfaceSet, bounds, 0.1, Vector3D.NewVector(0, 0, 0))
+	emitter.setIsOneShot(false)
+	emitter.setTarget(particles.particleSystemData)
+	return emitter, particles
+}
+
+func TestVolumeParticleEmitter3Continuous(t *testing.T) {
+
+	emitter, particles := newTestVolumeParticleEmitter3()
+
+	emitter.update(0, 0.1)
+	filled := particles.particleSystemData.numberOfParticles
+	if filled == 0 {
+		t.Fatalf("expected the first update to fill the volume")
+	}
+
+	emitter.update(0.1, 0.1)
+	if n := particles.particleSystemData.numberOfParticles; n != filled {
+		t.Errorf("expected no particles spawned into occupied space, got %d new", n-filled)
+	}
+
+	// Once the particles have left, the volume is refilled.
+	for _, p := range particles.positions() {
+		p.X += 10
+	}
+	emitter.update(0.2, 0.1)
+	if n := particles.particleSystemData.numberOfParticles; n != 2*filled {
+		t.Errorf("expected the vacated volume refilled with %d particles, got %d", filled, n-filled)
+	}
+
+	emitter, particles = newTestVolumeParticleEmitter3()
+	emitter.setMaxNumberOfNewParticlesPerSecond(50)
+	emitter.update(0, 0.1)
+	if n := particles.particleSystemData.numberOfParticles; n != 5 {
+		t.Errorf("expected 5 particles after the first step at 50 per second, got %d", n)
+	}
+	emitter.setAllowOverlapping(true)
+	emitter.update(0.1, 0.1)
+	if n := particles.particleSystemData.numberOfParticles; n != 10 {
+		t.Errorf("expected 10 particles after the second step at 50 per second, got %d", n)
+	}
+
+	emitter, particles = newTestVolumeParticleEmitter3()
+	emitter.setAllowOverlapping(true)
+	emitter.setMaxNumberOfParticles(7)
+	emitter.update(0, 0.1)
+	emitter.update(0.1, 0.1)
+	if n := particles.particleSystemData.numberOfParticles; n != 7 {
+		t.Errorf("expected the emitter to stop at 7 particles, got %d", n)
+	}
+}
+
+func TestVolumeParticleEmitter3Jitter(t *testing.T) {
+
+	// The surface covers the whole region, so every lattice point is kept.
+	emit := func(jitter float64, seed int64) []*Vector3D.Vector3D {
+		surfaceSet := NewImplicitSurfaceSet3()
+		surfaceSet.addExplicitSurface(NewSphere3(Vector3D.NewVector(0, 0, 0), 10))
+		bounds := NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(0.3, 0.3, 0.3))
+
+		particles := NewParticleSystemData3()
+		emitter := NewVolumeParticleEmitter3(surfaceSet, bounds, 0.1, Vector3D.NewVector(0, 0, 0))
+		emitter.setJitter(jitter)
+		emitter.setSeed(seed)
+		emitter.setTarget(particles)
+		emitter.update(0, 0.1)
+		return particles.positions()
+	}
+
+	lattice := emit(0, 1)
+	jittered := emit(0.25, 1)
+	if len(jittered) != len(lattice) {
+		t.Fatalf("expected %d particles, got %d", len(lattice), len(jittered))
+	}
+	for i := range lattice {
+		if d := jittered[i].DistanceTo(lattice[i]); d == 0 || d > 0.025+1e-12 {
+			t.Errorf("expected particle %d displaced by up to 0.025, got %v", i, d)
+		}
+	}
+	checkSeedReproducible(t, func(seed int64) []*Vector3D.Vector3D {
+		return emit(0.25, seed)
+	})
+}
+
+func TestVolumeParticleEmitter3Velocity(t *testing.T) {
+
+	// A column spinning around its own axis at x = 1 while moving up, on top
+	// of a swirl around the z-axis.
+	surfaceSet := NewImplicitSurfaceSet3()
+	surfaceSet.addExplicitSurface(NewSphere3(Vector3D.NewVector(0, 0, 0), 0.2))
+	surfaceSet.transform.setTranslation(Vector3D.NewVector(1, 0, 0))
+	bounds := NewBoundingBox3D(Vector3D.NewVector(0.7, -0.3, -0.3), Vector3D.NewVector(1.3, 0.3, 0.3))
+
+	swirl := func(x *Vector3D.Vector3D) *Vector3D.Vector3D {
+		return Vector3D.NewVector(-x.Y, x.X, 0)
+	}
+
+	particles := NewParticleSystemData3()
+	emitter := NewVolumeParticleEmitter3(surfaceSet, bounds, 0.1, Vector3D.NewVector(5, 5, 5))
+	emitter.setInitialVelocityField(NewCustomVectorField3(swirl))
+	emitter.setLinearVelocity(Vector3D.NewVector(0, 1, 0))
+	emitter.setAngularVelocity(Vector3D.NewVector(0, 2, 0))
+	emitter.setTarget(particles)
+	emitter.update(0, 0.1)
+
+	positions := particles.positions()
+	velocities := particles.velocities()
+	if len(positions) == 0 {
+		t.Fatalf("expected the column filled")
+	}
+	for i, p := range positions {
+		r := p.Substract(Vector3D.NewVector(1, 0, 0))
+		expected := swirl(p).Add(Vector3D.NewVector(0, 1, 0)).Add(Vector3D.NewVector(2*r.Z, 0, -2*r.X))
+		if d := velocities[i].DistanceTo(expected); d > 1e-12 {
+			t.Fatalf("expected velocity %v at %v, got %v", expected, p, velocities[i])
+		}
+	}
+
+	field := NewConstantVectorField3()
+	field.withValue(Vector3D.NewVector(0, 0, -1))
+	if v := field.sample(Vector3D.NewVector(3, 2, 1)); v.DistanceTo(Vector3D.NewVector(0, 0, -1)) > 0 {
+		t.Errorf("expected the constant field value, got %v", v)
+	}
+}
+
+func TestVolumeParticleEmitter2Velocity(t *testing.T) {
+
+	// Like in 3-D, the disk spins around the translation of the set.
+	surfaceSet := NewImplicitSurfaceSet2()
+	surfaceSet.addExplicitSurface(NewSphere2(Vector3D.NewVector(0, 0, 0), 0.2))
+	surfaceSet.transform.setTranslation(Vector3D.NewVector(1, 0, 0))
+	bounds := NewBoundingBox2D(Vector3D.NewVector(0.7, -0.3, 0), Vector3D.NewVector(1.3, 0.3, 0))
+
+	particles := NewParticleSystemData3()
+	emitter := NewVolumeParticleEmitter2(surfaceSet, bounds, 0.1, Vector3D.NewVector(0, 0, 0))
+	emitter.setLinearVelocity(Vector3D.NewVector(0, 1, 0))
+	emitter.setAngularVelocity(2)
+	emitter.setTarget(particles)
+	emitter.update(0, 0.1)
+
+	positions := particles.positions()
+	velocities := particles.velocities()
+	if len(positions) == 0 {
+		t.Fatalf("expected the disk filled")
+	}
+	for i, p := range positions {
+		if p.DistanceTo(Vector3D.NewVector(1, 0, 0)) > 0.2 {
+			t.Fatalf("expected the particles inside the translated disk, got %v", p)
+		}
+		expected := Vector3D.NewVector(-2*p.Y, 1+2*(p.X-1), 0)
+		if d := velocities[i].DistanceTo(expected); d > 1e-12 {
+			t.Fatalf("expected velocity %v at %v, got %v", expected, p, velocities[i])
+		}
+	}
+
+	// An empty set still has a pivot.
+	empty := NewVolumeParticleEmitter2(NewImplicitSurfaceSet2(), bounds, 0.1, Vector3D.NewVector(0, 0, 0))
+	empty.setAngularVelocity(1)
+	if v := empty.velocityAt(Vector3D.NewVector(0, 1, 0)); v.DistanceTo(Vector3D.NewVector(-1, 0, 0)) > 1e-12 {
+		t.Errorf("expected a rotation around the origin, got %v", v)
+	}
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"jimmykiang/fluidengine/Vector3D"
+	"jimmykiang/fluidengine/mathHelper"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// kVoxelSdf3Magic identifies files written by VoxelSdf3.serialize.
+const kVoxelSdf3Magic = "VSDF3\x00\x01\x00"
+
+const (
+	// kVoxelSdf3MaxSamples caps the grid size, so a tiny spacing or a corrupt
+	// header cannot request an enormous allocation.
+	kVoxelSdf3MaxSamples = 1 << 27
+
+	// kVoxelSdf3ReadChunk is the number of samples deserialize reads at a
+	// time. The grid only grows as its samples arrive, so a truncated file
+	// fails before a large allocation.
+	kVoxelSdf3ReadChunk = 1 << 16
+)
+
+// VoxelSdf3 is a 3-D signed distance field sampled on a regular grid.
+// Caches the signed distance of any ImplicitSurface3, such as a
+// TriangleMesh3 or a CsgSurface3, so that queries cost a trilinear
+// interpolation instead of an exact distance computation. Normals are the
+// gradient of the interpolated field and the closest point is found by
+// following it, so both are only as accurate as the grid spacing.
+type VoxelSdf3 struct {
+
+	// Base struct for 3-D surface.
+	surface3 *Surface3
+
+	// Position of the first sample in local frame.
+	origin *Vector3D.Vector3D
+
+	// Distance between two samples.
+	gridSpacing float64
+
+	// Number of samples along each axis.
+	resolution [3]int64
+
+	// Sampled signed distances, x varies fastest.
+	data []float64
+
+	// Local-to-world transform.
+	transform *Transform3
+
+	// Flips normal when calling Surface3::closestNormal(...).
+	isNormalFlipped bool
+}
+
+// NewVoxelSdf3 samples the signed distance of surface on a grid covering
+// domain with the given spacing. If domain is nil the bounding box of
+// surface grown by two grid cells is used, which requires a bounded surface.
+// The samples are computed in parallel, so surface must be safe for
+// concurrent signedDistance calls once the first one has returned.
+func NewVoxelSdf3(surface ImplicitSurface3, domain *BoundingBox3D, gridSpacing float64) (*VoxelSdf3, error) {
+
+	if !isFinitePositive(gridSpacing) {
+		return nil, fmt.Errorf("invalid grid spacing %v", gridSpacing)
+	}
+	if domain == nil {
+		if !surface.isBounded() {
+			return nil, fmt.Errorf("sampling an unbounded surface requires a domain")
+		}
+		domain = NewBoundingBox3DFromStruct(surface.boundingBox())
+		domain.expand(2 * gridSpacing)
+	}
+
+	// the resolution is checked as float64 first so a huge or infinite domain
+	// does not overflow the conversion.
+	var resolution [3]int64
+	for i, size := range []float64{domain.width(), domain.height(), domain.depth()} {
+		n := math.Ceil(size/gridSpacing) + 1
+		if !(n >= 1 && n <= kVoxelSdf3MaxSamples) {
+			return nil, fmt.Errorf("invalid domain %v for spacing %v", domain, gridSpacing)
+		}
+		resolution[i] = int64(n)
+	}
+	numberOfSamples, err := voxelSdf3NumberOfSamples(resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &VoxelSdf3{
+		surface3:        NewSurface3(),
+		origin:          Vector3D.NewVector(domain.lowerCorner.X, domain.lowerCorner.Y, domain.lowerCorner.Z),
+		gridSpacing:     gridSpacing,
+		resolution:      resolution,
+		transform:       NewTransform3(),
+		isNormalFlipped: false,
+	}
+	v.data = make([]float64, numberOfSamples)
+
+	// The first sample runs alone so surfaces can lazily build their query
+	// engines (e.g. ImplicitSurfaceSet3) before the workers start.
+	v.data[0] = surface.signedDistance(v.samplePosition(0, 0, 0))
+
+	var wg sync.WaitGroup
+	slices := make(chan int64, v.resolution[2])
+	for worker := 0; worker < runtime.NumCPU(); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range slices {
+				for j := int64(0); j < v.resolution[1]; j++ {
+					for i := int64(0); i < v.resolution[0]; i++ {
+						if i == 0 && j == 0 && k == 0 {
+							continue
+						}
+						v.data[v.index(i, j, k)] = surface.signedDistance(v.samplePosition(i, j, k))
+					}
+				}
+			}
+		}()
+	}
+	for k := int64(0); k < v.resolution[2]; k++ {
+		slices <- k
+	}
+	close(slices)
+	wg.Wait()
+
+	return v, nil
+}
+
+// isFinitePositive returns true if x is a positive finite number.
+func isFinitePositive(x float64) bool {
+
+	return x > 0 && !math.IsInf(x, 1)
+}
+
+// voxelSdf3NumberOfSamples returns the number of samples of a grid with the
+// given resolution, or an error if it exceeds kVoxelSdf3MaxSamples. The
+// product is checked one factor at a time so it cannot overflow.
+func voxelSdf3NumberOfSamples(resolution [3]int64) (int64, error) {
+
+	numberOfSamples := int64(1)
+	for _, n := range resolution {
+		if n < 1 {
+			return 0, fmt.Errorf("invalid grid %v", resolution)
+		}
+		if n > kVoxelSdf3MaxSamples/numberOfSamples {
+			return 0, fmt.Errorf("grid %v exceeds %d samples", resolution, kVoxelSdf3MaxSamples)
+		}
+		numberOfSamples *= n
+	}
+	return numberOfSamples, nil
+}
+
+// NewVoxelSdf3FromFile loads a field written by save.
+func NewVoxelSdf3FromFile(fileName string) (*VoxelSdf3, error) {
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	v := &VoxelSdf3{
+		surface3:        NewSurface3(),
+		transform:       NewTransform3(),
+		isNormalFlipped: false,
+	}
+	if err := v.deserialize(bufio.NewReader(f)); err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	return v, nil
+}
+
+// save writes the field to the given file.
+func (v *VoxelSdf3) save(fileName string) error {
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	if err := v.serialize(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// serialize writes the grid as a magic header, the origin, the spacing, the
+// resolution and the samples, all little-endian. The transform is not part
+// of the data.
+func (v *VoxelSdf3) serialize(w io.Writer) error {
+
+	if _, err := io.WriteString(w, kVoxelSdf3Magic); err != nil {
+		return err
+	}
+	header := []interface{}{
+		[4]float64{v.origin.X, v.origin.Y, v.origin.Z, v.gridSpacing},
+		v.resolution,
+		v.data,
+	}
+	for _, value := range header {
+		if err := binary.Write(w, binary.LittleEndian, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deserialize reads a grid written by serialize.
+func (v *VoxelSdf3) deserialize(r io.Reader) error {
+
+	magic := make([]byte, len(kVoxelSdf3Magic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return err
+	}
+	if string(magic) != kVoxelSdf3Magic {
+		return fmt.Errorf("not a voxel sdf file")
+	}
+
+	var originAndSpacing [4]float64
+	if err := binary.Read(r, binary.LittleEndian, &originAndSpacing); err != nil {
+		return err
+	}
+	var resolution [3]int64
+	if err := binary.Read(r, binary.LittleEndian, &resolution); err != nil {
+		return err
+	}
+	for _, x := range originAndSpacing[:3] {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return fmt.Errorf("invalid grid origin %v", originAndSpacing[:3])
+		}
+	}
+	if !isFinitePositive(originAndSpacing[3]) {
+		return fmt.Errorf("invalid grid spacing %v", originAndSpacing[3])
+	}
+	numberOfSamples, err := voxelSdf3NumberOfSamples(resolution)
+	if err != nil {
+		return err
+	}
+
+	data := make([]float64, 0, int64(math.Min(float64(numberOfSamples), kVoxelSdf3ReadChunk)))
+	for int64(len(data)) < numberOfSamples {
+		chunk := make([]float64, int64(math.Min(float64(numberOfSamples-int64(len(data))), kVoxelSdf3ReadChunk)))
+		if err := binary.Read(r, binary.LittleEndian, chunk); err != nil {
+			return err
+		}
+		data = append(data, chunk...)
+	}
+
+	v.origin = Vector3D.NewVector(originAndSpacing[0], originAndSpacing[1], originAndSpacing[2])
+	v.gridSpacing = originAndSpacing[3]
+	v.resolution = resolution
+	v.data = data
+	return nil
+}
+
+func (v *VoxelSdf3) index(i, j, k int64) int64 {
+
+	return i + v.resolution[0]*(j+v.resolution[1]*k)
+}
+
+func (v *VoxelSdf3) samplePosition(i, j, k int64) *Vector3D.Vector3D {
+
+	return v.origin.Add(Vector3D.NewVector(float64(i), float64(j), float64(k)).Multiply(v.gridSpacing))
+}
+
+func (v *VoxelSdf3) getTransform() *Transform3 {
+	return v.transform
+}
+
+// getIsNormalFlipped returns true if the normal is flipped.
+func (v *VoxelSdf3) getIsNormalFlipped() bool {
+	return v.isNormalFlipped
+}
+
+// isBounded returns true if bounding box can be defined.
+func (v *VoxelSdf3) isBounded() bool {
+
+	return true
+}
+
+// boundingBox returns the bounding box of this surface object.
+func (v *VoxelSdf3) boundingBox() *BoundingBox3D {
+
+	return v.transform.toWorldBoundingBox(v.boundingBoxLocal())
+}
+
+// boundingBoxLocal returns the region covered by the samples.
+func (v *VoxelSdf3) boundingBoxLocal() *BoundingBox3D {
+
+	return NewBoundingBox3D(v.origin, v.samplePosition(v.resolution[0]-1, v.resolution[1]-1, v.resolution[2]-1))
+}
+
+func (v *VoxelSdf3) signedDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	sd := v.signedDistanceLocal(v.transform.toLocal(otherPoint))
+	if v.isNormalFlipped {
+		sd = -sd
+	}
+	return sd
+}
+
+// signedDistanceLocal interpolates the samples trilinearly. Outside the grid
+// the value at the closest point of the grid is used, plus the distance to
+// it.
+func (v *VoxelSdf3) signedDistanceLocal(otherPoint *Vector3D.Vector3D) float64 {
+
+	var base [3]int64
+	var frac [3]float64
+	outside := 0.0
+
+	for axis := 0; axis < 3; axis++ {
+		n := v.resolution[axis]
+		x := (otherPoint.At(axis) - v.origin.At(axis)) / v.gridSpacing
+		clamped := mathHelper.Clamp(x, 0, float64(n-1))
+		outside += (x - clamped) * (x - clamped)
+
+		base[axis] = int64(math.Floor(clamped))
+		if base[axis] >= n-1 {
+			base[axis] = int64(math.Max(0, float64(n-2)))
+		}
+		frac[axis] = clamped - float64(base[axis])
+	}
+
+	result := 0.0
+	for corner := 0; corner < 8; corner++ {
+		weight := 1.0
+		var idx [3]int64
+		for axis := 0; axis < 3; axis++ {
+			offset := int64(corner>>uint(axis)) & 1
+			idx[axis] = base[axis] + offset
+			if idx[axis] >= v.resolution[axis] {
+				idx[axis] = v.resolution[axis] - 1
+			}
+			if offset == 1 {
+				weight *= frac[axis]
+			} else {
+				weight *= 1 - frac[axis]
+			}
+		}
+		if weight != 0 {
+			result += weight * v.data[v.index(idx[0], idx[1], idx[2])]
+		}
+	}
+
+	return result + math.Sqrt(outside)*v.gridSpacing
+}
+
+// Returns the closest point from the given point otherPoint to the surface.
+func (v *VoxelSdf3) closestPoint(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	return v.transform.toWorld(v.closestPointLocal(v.transform.toLocal(otherPoint)))
+}
+
+func (v *VoxelSdf3) closestPointLocal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	sd := v.signedDistanceLocal(otherPoint)
+	return otherPoint.Substract(v.closestNormalLocal(otherPoint).Multiply(sd))
+}
+
+// Returns the closest distance from the given point otherPoint to the surface.
+func (v *VoxelSdf3) closestDistance(otherPoint *Vector3D.Vector3D) float64 {
+
+	return math.Abs(v.signedDistanceLocal(v.transform.toLocal(otherPoint)))
+}
+
+// closestNormal returns the normal to the closest point on the surface from the given
+// point.
+func (v *VoxelSdf3) closestNormal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	result := v.transform.toWorldDirection(v.closestNormalLocal(v.transform.toLocal(otherPoint)))
+	if v.isNormalFlipped {
+		result = result.Multiply(-1)
+	}
+	return result
+}
+
+// closestNormalLocal returns the gradient of the interpolated field by
+// central differences over half a grid cell.
+func (v *VoxelSdf3) closestNormalLocal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	h := 0.5 * v.gridSpacing
+	gradient := Vector3D.NewVector(
+		v.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(h, 0, 0)))-
+			v.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(-h, 0, 0))),
+		v.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(0, h, 0)))-
+			v.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(0, -h, 0))),
+		v.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(0, 0, h)))-
+			v.signedDistanceLocal(otherPoint.Add(Vector3D.NewVector(0, 0, -h))))
+
+	if gradient.Length() == 0 {
+		return Vector3D.NewVector(1, 0, 0)
+	}
+	return gradient.Normalize()
+}
+
+// Returns true if otherPoint is inside the volume defined by the surface.
+func (v *VoxelSdf3) isInside(otherPoint *Vector3D.Vector3D) bool {
+
+	return v.signedDistance(otherPoint) < 0
+}
+
+// closestIntersection returns the first hit of the ray with the surface.
+func (v *VoxelSdf3) closestIntersection(ray *Ray3) *SurfaceRayIntersection3 {
+
+	return intersectionToWorld(v.transform, v.isNormalFlipped, v.closestIntersectionLocal(v.transform.toLocalRay(ray)))
+}
+
+// intersects returns true if the ray hits the surface.
+func (v *VoxelSdf3) intersects(ray *Ray3) bool {
+
+	return v.closestIntersectionLocal(v.transform.toLocalRay(ray)).isIntersecting
+}
+
+// closestIntersectionLocal sphere traces the interpolated field inside the
+// grid.
+func (v *VoxelSdf3) closestIntersectionLocal(ray *Ray3) *SurfaceRayIntersection3 {
+
+	intersection := NewSurfaceRayIntersection3()
+
+	t, isIntersecting := sphereTrace3(ray, v.boundingBoxLocal(), func(point *Vector3D.Vector3D) float64 {
+		return math.Abs(v.signedDistanceLocal(point))
+	})
+	if isIntersecting {
+		intersection.isIntersecting = true
+		intersection.distance = t
+		intersection.point = ray.pointAt(t)
+		intersection.normal = v.closestNormalLocal(intersection.point)
+	}
+	return intersection
+}
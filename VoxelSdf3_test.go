@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVoxelSdf3Sphere(t *testing.T) {
+
+	sphere := NewSphere3(Vector3D.NewVector(0.5, 0.5, 0.5), 0.3)
+	sdf, err := NewVoxelSdf3(sphere, nil, 0.02)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for k := 0; k < 200; k++ {
+		p := Vector3D.NewVector(0.2+0.6*rng.Float64(), 0.2+0.6*rng.Float64(), 0.2+0.6*rng.Float64())
+
+		expected := sphere.signedDistance(p)
+		if sd := sdf.signedDistance(p); math.Abs(sd-expected) > 0.01 {
+			t.Fatalf("signedDistance(%v): expected %v, got %v", p, expected, sd)
+		}
+		if math.Abs(expected) > 0.05 {
+			expectedNormal := p.Substract(sphere.center).Normalize()
+			if normal := sdf.closestNormal(p); normal.DistanceTo(expectedNormal) > 0.05 {
+				t.Fatalf("closestNormal(%v): expected %v, got %v", p, expectedNormal, normal)
+			}
+		}
+		if cp := sdf.closestPoint(p); math.Abs(sphere.signedDistance(cp)) > 0.01 {
+			t.Fatalf("closestPoint(%v) = %v is not on the sphere", p, cp)
+		}
+	}
+
+	// Outside the grid the distance to the grid is added.
+	if sd := sdf.signedDistance(Vector3D.NewVector(0.5, 2, 0.5)); math.Abs(sd-1.2) > 0.01 {
+		t.Errorf("expected about 1.2 outside the grid, got %v", sd)
+	}
+
+	ray := NewRay3(Vector3D.NewVector(0.5, 2, 0.5), Vector3D.NewVector(0, -1, 0))
+	if result := sdf.closestIntersection(ray); !result.isIntersecting || math.Abs(result.distance-1.2) > 0.01 {
+		t.Errorf("expected a hit at distance 1.2, got %v", result.distance)
+	}
+
+	// A particle falling through the top of the sphere stops on it.
+	collider := NewRigidBodyCollider3(sdf)
+	newPosition := Vector3D.NewVector(0.5, 0.7, 0.5)
+	velocity := Vector3D.NewVector(0, -5, 0)
+	collider.resolveCollision(0, 1, 0.05, 0, Vector3D.NewVector(0.5, 1.2, 0.5), &newPosition, &velocity)
+	if newPosition.DistanceTo(Vector3D.NewVector(0.5, 0.85, 0.5)) > 0.01 || velocity.Length() > 0.05 {
+		t.Errorf("expected the particle stopped at (0.5, 0.85, 0.5), got %v with %v", newPosition, velocity)
+	}
+}
+
+func TestVoxelSdf3Serialize(t *testing.T) {
+
+	mesh := NewTriangleMesh3(nil, nil)
+	if err := mesh.readObj(strings.NewReader(unitCubeObj)); err != nil {
+		t.Fatal(err)
+	}
+	sdf, err := NewVoxelSdf3(mesh, nil, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sd := sdf.signedDistance(Vector3D.NewVector(0.5, 0.5, 0.5)); math.Abs(sd+0.5) > 1e-9 {
+		t.Errorf("expected -0.5 at the center of the cube, got %v", sd)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "cube.sdf")
+	if err := sdf.save(fileName); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewVoxelSdf3FromFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.resolution != sdf.resolution || loaded.gridSpacing != sdf.gridSpacing || !loaded.origin.IsSimilar(sdf.origin) {
+		t.Fatalf("unexpected grid %v %v %v", loaded.resolution, loaded.gridSpacing, loaded.origin)
+	}
+	for i := range sdf.data {
+		if loaded.data[i] != sdf.data[i] {
+			t.Fatalf("sample %d: expected %v, got %v", i, sdf.data[i], loaded.data[i])
+		}
+	}
+
+	if err := loaded.deserialize(bytes.NewReader([]byte("not an sdf"))); err == nil {
+		t.Errorf("expected an error for a bad header")
+	}
+
+	// a valid magic followed by a huge resolution must fail before allocating.
+	var header bytes.Buffer
+	header.WriteString(kVoxelSdf3Magic)
+	binary.Write(&header, binary.LittleEndian, [4]float64{0, 0, 0, 1})
+	binary.Write(&header, binary.LittleEndian, [3]int64{1 << 40, 1 << 40, 1 << 40})
+	if err := loaded.deserialize(&header); err == nil {
+		t.Errorf("expected an error for an oversized grid")
+	}
+}
+
+func TestVoxelSdf3InvalidGrid(t *testing.T) {
+
+	sphere := NewSphere3(Vector3D.NewVector(0.5, 0.5, 0.5), 0.3)
+	for _, spacing := range []float64{0, -0.1, math.NaN(), math.Inf(1), 1e-9} {
+		if _, err := NewVoxelSdf3(sphere, nil, spacing); err == nil {
+			t.Errorf("expected an error for spacing %v", spacing)
+		}
+	}
+
+	plane := NewPlane3D(Vector3D.NewVector(0, 1, 0), Vector3D.NewVector(0, 0, 0))
+	if _, err := NewVoxelSdf3(plane, nil, 0.1); err == nil {
+		t.Errorf("expected an error for an unbounded surface without a domain")
+	}
+	domain := NewBoundingBox3D(Vector3D.NewVector(-1, -1, -1), Vector3D.NewVector(1, 1, 1))
+	if _, err := NewVoxelSdf3(plane, domain, 0.1); err != nil {
+		t.Errorf("expected an unbounded surface to be sampled in the domain, got %v", err)
+	}
+
+	header := func(originAndSpacing [4]float64, resolution [3]int64) *bytes.Buffer {
+		var b bytes.Buffer
+		b.WriteString(kVoxelSdf3Magic)
+		binary.Write(&b, binary.LittleEndian, originAndSpacing)
+		binary.Write(&b, binary.LittleEndian, resolution)
+		return &b
+	}
+	loaded := &VoxelSdf3{}
+	for _, originAndSpacing := range [][4]float64{{0, 0, 0, math.NaN()}, {0, 0, 0, math.Inf(1)}, {math.NaN(), 0, 0, 1}, {0, math.Inf(-1), 0, 1}} {
+		if err := loaded.deserialize(header(originAndSpacing, [3]int64{2, 2, 2})); err == nil {
+			t.Errorf("expected an error for origin and spacing %v", originAndSpacing)
+		}
+	}
+
+	// A header announcing the largest grid without its samples fails while
+	// reading them.
+	if err := loaded.deserialize(header([4]float64{0, 0, 0, 1}, [3]int64{512, 512, 512})); err == nil {
+		t.Errorf("expected an error for a truncated file")
+	}
+}
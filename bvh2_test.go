@@ -0,0 +1,115 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func newRandomSpheres2(rng *rand.Rand, n int) ([]ImplicitSurface2, []*BoundingBox2D) {
+
+	items := make([]ImplicitSurface2, 0, n)
+	bounds := make([]*BoundingBox2D, 0, n)
+	for i := 0; i < n; i++ {
+		sphere := NewSphere2(Vector3D.NewVector(rng.Float64(), rng.Float64(), 0), 0.02+0.1*rng.Float64())
+		items = append(items, sphere)
+		bounds = append(bounds, sphere.boundingBox())
+	}
+	return items, bounds
+}
+
+// raySphere2Distance returns the distance along the ray to the circle.
+func raySphere2Distance(item ImplicitSurface2, ray *Ray2) float64 {
+
+	sphere := item.(*Sphere2)
+	r := ray.origin.Substract(sphere.center)
+	b := ray.direction.DotProduct(r)
+	c := r.Squared() - sphere.radius*sphere.radius
+	d := b*b - c
+	if d < 0 {
+		return math.MaxFloat64
+	}
+	for _, t := range []float64{-b - math.Sqrt(d), -b + math.Sqrt(d)} {
+		if t >= 0 {
+			return t
+		}
+	}
+	return math.MaxFloat64
+}
+
+func TestBvh2Nearest(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1))
+	items, bounds := newRandomSpheres2(rng, 200)
+	bvh := NewBvh2()
+	bvh.build(items, bounds)
+
+	signedDistance := func(item ImplicitSurface2, point *Vector3D.Vector3D) float64 {
+		return item.signedDistance(point)
+	}
+
+	for k := 0; k < 100; k++ {
+		point := Vector3D.NewVector(1.4*rng.Float64()-0.2, 1.4*rng.Float64()-0.2, 0)
+
+		expected := math.MaxFloat64
+		for _, item := range items {
+			expected = math.Min(expected, item.signedDistance(point))
+		}
+
+		result := bvh.nearest(point, signedDistance)
+		if result.item == nil || result.distance != expected {
+			t.Fatalf("nearest(%v): expected %v, got %v", point, expected, result.distance)
+		}
+	}
+}
+
+func TestBvh2Intersections(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(2))
+	items, bounds := newRandomSpheres2(rng, 100)
+	bvh := NewBvh2()
+	bvh.build(items, bounds)
+
+	for k := 0; k < 100; k++ {
+		ray := NewRay2(
+			Vector3D.NewVector(rng.Float64(), -0.5, 0),
+			Vector3D.NewVector(rng.Float64()-0.5, 1, 0))
+
+		expected := math.MaxFloat64
+		for _, item := range items {
+			expected = math.Min(expected, raySphere2Distance(item, ray))
+		}
+		if result := bvh.closestIntersection(ray, raySphere2Distance); result.distance != expected {
+			t.Fatalf("closestIntersection: expected %v, got %v", expected, result.distance)
+		}
+
+		hit := bvh.intersectsRay(ray, func(item ImplicitSurface2, ray *Ray2) bool {
+			return raySphere2Distance(item, ray) < math.MaxFloat64
+		})
+		if hit != (expected < math.MaxFloat64) {
+			t.Fatalf("intersectsRay: expected %v, got %v", expected < math.MaxFloat64, hit)
+		}
+	}
+
+	box := NewBoundingBox2D(Vector3D.NewVector(0.2, 0.2, 0), Vector3D.NewVector(0.5, 0.6, 0))
+	overlapping := func(item ImplicitSurface2, box *BoundingBox2D) bool {
+		return item.boundingBox().overlaps(box)
+	}
+	expected := 0
+	for _, item := range items {
+		if overlapping(item, box) {
+			expected++
+		}
+	}
+	count := 0
+	bvh.forEachIntersectingItemBox(box, overlapping, func(item ImplicitSurface2) {
+		count++
+	})
+	if count == 0 || count != expected {
+		t.Errorf("forEachIntersectingItemBox: expected %d items, got %d", expected, count)
+	}
+	if !bvh.intersectsBox(box, overlapping) {
+		t.Errorf("expected the box to intersect")
+	}
+}
@@ -1,4 +1,45 @@
 package main
 
+// BoxIntersectionTestFunc3 returns true if the given item intersects the box.
+type BoxIntersectionTestFunc3 func(item ImplicitSurface3, box *BoundingBox3D) bool
+
+// RayIntersectionTestFunc3 returns true if the given item intersects the ray.
+type RayIntersectionTestFunc3 func(item ImplicitSurface3, ray *Ray3) bool
+
+// GetRayIntersectionFunc3 returns the distance along the ray to the first
+// intersection with the given item, or math.MaxFloat64 if there is none.
+type GetRayIntersectionFunc3 func(item ImplicitSurface3, ray *Ray3) float64
+
+// IntersectionVisitorFunc3 is called for each item found by an intersection
+// query.
+type IntersectionVisitorFunc3 func(item ImplicitSurface3)
+
+// ClosestIntersectionQueryResult3 is the result of a first-hit ray query.
+type ClosestIntersectionQueryResult3 struct {
+
+	// The item hit first, nil if the ray hits nothing.
+	item ImplicitSurface3
+
+	// Distance along the ray to the hit.
+	distance float64
+}
+
+// IntersectionQueryEngine3 is the interface for intersection queries over a
+// collection of items.
 type IntersectionQueryEngine3 interface {
+
+	// intersectsBox returns true if any item intersects the box.
+	intersectsBox(box *BoundingBox3D, testFunc BoxIntersectionTestFunc3) bool
+
+	// intersectsRay returns true if any item intersects the ray.
+	intersectsRay(ray *Ray3, testFunc RayIntersectionTestFunc3) bool
+
+	// forEachIntersectingItemBox visits every item intersecting the box.
+	forEachIntersectingItemBox(box *BoundingBox3D, testFunc BoxIntersectionTestFunc3, visitorFunc IntersectionVisitorFunc3)
+
+	// forEachIntersectingItemRay visits every item intersecting the ray.
+	forEachIntersectingItemRay(ray *Ray3, testFunc RayIntersectionTestFunc3, visitorFunc IntersectionVisitorFunc3)
+
+	// closestIntersection returns the item hit first by the ray.
+	closestIntersection(ray *Ray3, testFunc GetRayIntersectionFunc3) *ClosestIntersectionQueryResult3
 }
@@ -1,31 +1,58 @@
 package main
 
 import (
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 
 	"github.com/sbinet/npyio"
 )
 
 func saveNpy(path, conf, fileName string, results []float64, frame *Frame) {
 
-	f, err := os.Create(filepath.Join(path, conf, fileName))
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer f.Close()
-
 	// write to .npy with the history of past values.
 	// m := results[:frame.index]
 	m := results
-	if frame.index == 0 {
+	if frame.index == 0 && len(results) > 0 {
 
 		// must always pass a slice with the single element of interest when index == 0.
-		m = []float64{results[frame.index]}
+		m = results[:1]
+	}
+	writeNpy(filepath.Join(path, conf, fileName), m)
+}
+
+// saveNpyInt64 is the integer counterpart of saveNpy, used for particle IDs.
+func saveNpyInt64(path, conf, fileName string, results []int64, frame *Frame) {
+
+	m := results
+	if frame.index == 0 && len(results) > 0 {
+
+		// must always pass a slice with the single element of interest when index == 0.
+		m = results[:1]
+	}
+	writeNpy(filepath.Join(path, conf, fileName), m)
+}
+
+// writeNpy writes the slice m to a .npy file.
+func writeNpy(fileName string, m interface{}) {
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		log.Fatal(err)
 	}
+	defer f.Close()
 
-	err = npyio.Write(f, m)
+	// npyio cannot infer the shape of an empty slice, so the header of an
+	// empty array is written directly.
+	if reflect.ValueOf(m).Len() == 0 {
+		err = writeEmptyNpy(f, m)
+	} else {
+		err = npyio.Write(f, m)
+	}
 	if err != nil {
 		log.Fatalf("error writing to file: %v\n", err)
 	}
@@ -35,3 +62,31 @@ func saveNpy(path, conf, fileName string, results []float64, frame *Frame) {
 		log.Fatalf("error closing file: %v\n", err)
 	}
 }
+
+// writeEmptyNpy writes a version 1.0 .npy header for an empty 1-D array
+// with the element type of m.
+func writeEmptyNpy(w io.Writer, m interface{}) error {
+
+	var descr string
+	switch m.(type) {
+	case []float64:
+		descr = "<f8"
+	case []int64:
+		descr = "<i8"
+	default:
+		return fmt.Errorf("unsupported type %T", m)
+	}
+
+	// The header is padded with spaces so that the data starts on a 64 byte
+	// boundary, and terminated by a newline.
+	dict := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (0,), }", descr)
+	prefixLen := len(npyio.Magic) + 2 + 2
+	padding := 63 - (prefixLen+len(dict))%64
+	dict += strings.Repeat(" ", padding) + "\n"
+
+	header := append([]byte(npyio.Magic[:]), 1, 0)
+	header = append(header, byte(len(dict)), byte(len(dict)>>8))
+	header = append(header, dict...)
+	_, err := w.Write(header)
+	return err
+}
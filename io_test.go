@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbinet/npyio"
+)
+
+func TestSaveNpyEmptyFrame(t *testing.T) {
+
+	// An empty particle set at the first frame writes an empty array.
+	path := t.TempDir()
+	frame := NewFrame()
+	saveNpy(path, "", "x.npy", []float64{}, frame)
+	saveNpyInt64(path, "", "id.npy", []int64{}, frame)
+
+	for fileName, descr := range map[string]string{"x.npy": "<f8", "id.npy": "<i8"} {
+		f, err := os.Open(filepath.Join(path, fileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := npyio.NewReader(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", fileName, err)
+		}
+		if r.Header.Descr.Type != descr || len(r.Header.Descr.Shape) != 1 || r.Header.Descr.Shape[0] != 0 {
+			t.Errorf("%s: expected an empty %s array, got %+v", fileName, descr, r.Header.Descr)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
+
+// Matrix2x2 is a fixed-size 2x2 matrix stored in row-major order.
+type Matrix2x2 [2][2]float64
+
+// NewMatrix2x2Identity returns the 2x2 identity matrix.
+func NewMatrix2x2Identity() Matrix2x2 {
+
+	return NewMatrix2x2Diagonal(1, 1)
+}
+
+// NewMatrix2x2Diagonal returns a 2x2 matrix with the given diagonal elements.
+func NewMatrix2x2Diagonal(d0, d1 float64) Matrix2x2 {
+
+	return Matrix2x2{
+		{d0, 0},
+		{0, d1},
+	}
+}
+
+// NewMatrix2x2Rotation returns the matrix rotating by the given angle in radians.
+func NewMatrix2x2Rotation(radian float64) Matrix2x2 {
+
+	c, s := math.Cos(radian), math.Sin(radian)
+	return Matrix2x2{
+		{c, -s},
+		{s, c},
+	}
+}
+
+// Add returns m + o.
+func (m Matrix2x2) Add(o Matrix2x2) Matrix2x2 {
+
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			m[i][j] += o[i][j]
+		}
+	}
+	return m
+}
+
+// Sub returns m - o.
+func (m Matrix2x2) Sub(o Matrix2x2) Matrix2x2 {
+
+	return m.Add(o.Scale(-1))
+}
+
+// Scale returns m * s.
+func (m Matrix2x2) Scale(s float64) Matrix2x2 {
+
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			m[i][j] *= s
+		}
+	}
+	return m
+}
+
+// Mul returns the matrix product m * o.
+func (m Matrix2x2) Mul(o Matrix2x2) Matrix2x2 {
+
+	return Matrix2x2{
+		{m[0][0]*o[0][0] + m[0][1]*o[1][0], m[0][0]*o[0][1] + m[0][1]*o[1][1]},
+		{m[1][0]*o[0][0] + m[1][1]*o[1][0], m[1][0]*o[0][1] + m[1][1]*o[1][1]},
+	}
+}
+
+// MulVector returns the matrix-vector product m * v in the xy-plane.
+func (m Matrix2x2) MulVector(v *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	return Vector3D.NewVector(m[0][0]*v.X+m[0][1]*v.Y, m[1][0]*v.X+m[1][1]*v.Y, 0)
+}
+
+// Transpose returns the transposed matrix.
+func (m Matrix2x2) Transpose() Matrix2x2 {
+
+	return Matrix2x2{
+		{m[0][0], m[1][0]},
+		{m[0][1], m[1][1]},
+	}
+}
+
+// Determinant returns the determinant of the matrix.
+func (m Matrix2x2) Determinant() float64 {
+
+	return m[0][0]*m[1][1] - m[0][1]*m[1][0]
+}
+
+// Inverse returns the inverse matrix. The result is not finite if the matrix
+// is singular.
+func (m Matrix2x2) Inverse() Matrix2x2 {
+
+	invDet := 1 / m.Determinant()
+	return Matrix2x2{
+		{m[1][1] * invDet, -m[0][1] * invDet},
+		{-m[1][0] * invDet, m[0][0] * invDet},
+	}
+}
+
+// EigenSymmetric returns the eigenvalues in descending order and the rotation
+// matrix whose columns are the corresponding unit eigenvectors. Only the upper
+// triangle is read; the matrix is assumed to be symmetric.
+func (m Matrix2x2) EigenSymmetric() ([2]float64, Matrix2x2) {
+
+	mean := 0.5 * (m[0][0] + m[1][1])
+	radius := math.Hypot(0.5*(m[0][0]-m[1][1]), m[0][1])
+	angle := 0.5 * math.Atan2(2*m[0][1], m[0][0]-m[1][1])
+
+	return [2]float64{mean + radius, mean - radius}, NewMatrix2x2Rotation(angle)
+}
+
+// SVD returns the singular value decomposition m = u * diag(sigma) * v^T with
+// orthogonal u and v and non-negative singular values in descending order.
+func (m Matrix2x2) SVD() (Matrix2x2, [2]float64, Matrix2x2) {
+
+	_, v := m.Transpose().Mul(m).EigenSymmetric()
+	av := m.Mul(v)
+
+	var sigma [2]float64
+	sigma[0] = math.Hypot(av[0][0], av[1][0])
+	u0x, u0y := 1.0, 0.0
+	if sigma[0] > 0 {
+		u0x, u0y = av[0][0]/sigma[0], av[1][0]/sigma[0]
+	}
+
+	// The second column is perpendicular to the first.
+	u1x, u1y := -u0y, u0x
+	sigma[1] = u1x*av[0][1] + u1y*av[1][1]
+	if sigma[1] < 0 {
+		u1x, u1y = -u1x, -u1y
+		sigma[1] = -sigma[1]
+	}
+
+	return Matrix2x2{{u0x, u1x}, {u0y, u1y}}, sigma, v
+}
+
+// PolarDecomposition returns the rotation r and the symmetric matrix s with
+// m = r * s.
+func (m Matrix2x2) PolarDecomposition() (Matrix2x2, Matrix2x2) {
+
+	u, sigma, v := m.SVD()
+
+	// Flip the smallest singular direction to turn a reflection into a rotation.
+	if u.Determinant()*v.Determinant() < 0 {
+		u[0][1], u[1][1] = -u[0][1], -u[1][1]
+		sigma[1] = -sigma[1]
+	}
+
+	r := u.Mul(v.Transpose())
+	s := v.Mul(NewMatrix2x2Diagonal(sigma[0], sigma[1])).Mul(v.Transpose())
+	return r, s
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
+
+const (
+	kMaxJacobiSweeps = 50
+)
+
+// Matrix3x3 is a fixed-size 3x3 matrix stored in row-major order.
+type Matrix3x3 [3][3]float64
+
+// NewMatrix3x3Identity returns the 3x3 identity matrix.
+func NewMatrix3x3Identity() Matrix3x3 {
+
+	return NewMatrix3x3Diagonal(1, 1, 1)
+}
+
+// NewMatrix3x3Diagonal returns a 3x3 matrix with the given diagonal elements.
+func NewMatrix3x3Diagonal(d0, d1, d2 float64) Matrix3x3 {
+
+	return Matrix3x3{
+		{d0, 0, 0},
+		{0, d1, 0},
+		{0, 0, d2},
+	}
+}
+
+// NewMatrix3x3FromColumns returns a 3x3 matrix with the given column vectors.
+func NewMatrix3x3FromColumns(c0, c1, c2 *Vector3D.Vector3D) Matrix3x3 {
+
+	return Matrix3x3{
+		{c0.X, c1.X, c2.X},
+		{c0.Y, c1.Y, c2.Y},
+		{c0.Z, c1.Z, c2.Z},
+	}
+}
+
+// Column returns the j-th column as a vector.
+func (m Matrix3x3) Column(j int) *Vector3D.Vector3D {
+
+	return Vector3D.NewVector(m[0][j], m[1][j], m[2][j])
+}
+
+// Add returns m + o.
+func (m Matrix3x3) Add(o Matrix3x3) Matrix3x3 {
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] += o[i][j]
+		}
+	}
+	return m
+}
+
+// Sub returns m - o.
+func (m Matrix3x3) Sub(o Matrix3x3) Matrix3x3 {
+
+	return m.Add(o.Scale(-1))
+}
+
+// Scale returns m * s.
+func (m Matrix3x3) Scale(s float64) Matrix3x3 {
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m[i][j] *= s
+		}
+	}
+	return m
+}
+
+// Mul returns the matrix product m * o.
+func (m Matrix3x3) Mul(o Matrix3x3) Matrix3x3 {
+
+	var result Matrix3x3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			result[i][j] = m[i][0]*o[0][j] + m[i][1]*o[1][j] + m[i][2]*o[2][j]
+		}
+	}
+	return result
+}
+
+// MulVector returns the matrix-vector product m * v.
+func (m Matrix3x3) MulVector(v *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	return Vector3D.NewVector(
+		m[0][0]*v.X+m[0][1]*v.Y+m[0][2]*v.Z,
+		m[1][0]*v.X+m[1][1]*v.Y+m[1][2]*v.Z,
+		m[2][0]*v.X+m[2][1]*v.Y+m[2][2]*v.Z,
+	)
+}
+
+// Transpose returns the transposed matrix.
+func (m Matrix3x3) Transpose() Matrix3x3 {
+
+	return Matrix3x3{
+		{m[0][0], m[1][0], m[2][0]},
+		{m[0][1], m[1][1], m[2][1]},
+		{m[0][2], m[1][2], m[2][2]},
+	}
+}
+
+// Trace returns the sum of the diagonal elements.
+func (m Matrix3x3) Trace() float64 {
+
+	return m[0][0] + m[1][1] + m[2][2]
+}
+
+// FrobeniusNorm returns the square root of the sum of the squared elements.
+func (m Matrix3x3) FrobeniusNorm() float64 {
+
+	sum := 0.0
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			sum += m[i][j] * m[i][j]
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+// Determinant returns the determinant of the matrix.
+func (m Matrix3x3) Determinant() float64 {
+
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+// Inverse returns the inverse matrix. The result is not finite if the matrix
+// is singular.
+func (m Matrix3x3) Inverse() Matrix3x3 {
+
+	invDet := 1 / m.Determinant()
+
+	return Matrix3x3{
+		{
+			(m[1][1]*m[2][2] - m[1][2]*m[2][1]) * invDet,
+			(m[0][2]*m[2][1] - m[0][1]*m[2][2]) * invDet,
+			(m[0][1]*m[1][2] - m[0][2]*m[1][1]) * invDet,
+		},
+		{
+			(m[1][2]*m[2][0] - m[1][0]*m[2][2]) * invDet,
+			(m[0][0]*m[2][2] - m[0][2]*m[2][0]) * invDet,
+			(m[0][2]*m[1][0] - m[0][0]*m[1][2]) * invDet,
+		},
+		{
+			(m[1][0]*m[2][1] - m[1][1]*m[2][0]) * invDet,
+			(m[0][1]*m[2][0] - m[0][0]*m[2][1]) * invDet,
+			(m[0][0]*m[1][1] - m[0][1]*m[1][0]) * invDet,
+		},
+	}
+}
+
+// ToMatrix converts to the generic Matrix type.
+func (m Matrix3x3) ToMatrix() Matrix {
+
+	result := NewMatrix(3, 3)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			result[i][j] = m[i][j]
+		}
+	}
+	return result
+}
+
+// EigenSymmetric returns the eigenvalues in descending order and the matrix
+// whose columns are the corresponding unit eigenvectors. Only the upper
+// triangle is read; the matrix is assumed to be symmetric.
+func (m Matrix3x3) EigenSymmetric() ([3]float64, Matrix3x3) {
+
+	a := m
+	a[1][0], a[2][0], a[2][1] = a[0][1], a[0][2], a[1][2]
+	v := NewMatrix3x3Identity()
+
+	// Cyclic Jacobi rotations until the off-diagonal part vanishes.
+	tolerance := 1e-30 * a.FrobeniusNorm() * a.FrobeniusNorm()
+	for sweep := 0; sweep < kMaxJacobiSweeps; sweep++ {
+		off := a[0][1]*a[0][1] + a[0][2]*a[0][2] + a[1][2]*a[1][2]
+		if off <= tolerance {
+			break
+		}
+
+		for p := 0; p < 2; p++ {
+			for q := p + 1; q < 3; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+
+				for k := 0; k < 3; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < 3; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+				for k := 0; k < 3; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+
+	// Sort eigenpairs by descending eigenvalue.
+	order := [3]int{0, 1, 2}
+	for i := 0; i < 3; i++ {
+		for j := i + 1; j < 3; j++ {
+			if a[order[j]][order[j]] > a[order[i]][order[i]] {
+				order[i], order[j] = order[j], order[i]
+			}
+		}
+	}
+
+	var values [3]float64
+	var vectors Matrix3x3
+	for j := 0; j < 3; j++ {
+		values[j] = a[order[j]][order[j]]
+		for i := 0; i < 3; i++ {
+			vectors[i][j] = v[i][order[j]]
+		}
+	}
+	return values, vectors
+}
+
+// SVD returns the singular value decomposition m = u * diag(sigma) * v^T with
+// orthogonal u and v and non-negative singular values in descending order.
+func (m Matrix3x3) SVD() (Matrix3x3, [3]float64, Matrix3x3) {
+
+	_, v := m.Transpose().Mul(m).EigenSymmetric()
+	av := m.Mul(v)
+	av0, av1, av2 := av.Column(0), av.Column(1), av.Column(2)
+
+	var sigma [3]float64
+
+	// Build u column by column from m * v, completing an orthonormal basis
+	// where the singular values vanish.
+	sigma[0] = av0.Length()
+	u0 := Vector3D.NewVector(1, 0, 0)
+	if sigma[0] > 0 {
+		u0 = av0.Divide(sigma[0])
+	}
+
+	w := av1.Substract(u0.Multiply(u0.DotProduct(av1)))
+	sigma[1] = w.Length()
+	var u1 *Vector3D.Vector3D
+	if sigma[1] > 1e-12*sigma[0] {
+		u1 = w.Divide(sigma[1])
+	} else {
+		sigma[1] = 0
+		u1 = u0.Tangential()[0]
+	}
+
+	u2 := u0.CrossProduct(u1)
+	sigma[2] = u2.DotProduct(av2)
+	if sigma[2] < 0 {
+		u2 = u2.Multiply(-1)
+		sigma[2] = -sigma[2]
+	}
+
+	return NewMatrix3x3FromColumns(u0, u1, u2), sigma, v
+}
+
+// PolarDecomposition returns the rotation r and the symmetric matrix s with
+// m = r * s.
+func (m Matrix3x3) PolarDecomposition() (Matrix3x3, Matrix3x3) {
+
+	u, sigma, v := m.SVD()
+
+	// Flip the smallest singular direction to turn a reflection into a rotation.
+	if u.Determinant()*v.Determinant() < 0 {
+		for i := 0; i < 3; i++ {
+			u[i][2] = -u[i][2]
+		}
+		sigma[2] = -sigma[2]
+	}
+
+	r := u.Mul(v.Transpose())
+	s := v.Mul(NewMatrix3x3Diagonal(sigma[0], sigma[1], sigma[2])).Mul(v.Transpose())
+	return r, s
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+)
+
+// Matrix4x4 is a fixed-size 4x4 matrix stored in row-major order.
+type Matrix4x4 [4][4]float64
+
+// NewMatrix4x4Identity returns the 4x4 identity matrix.
+func NewMatrix4x4Identity() Matrix4x4 {
+
+	return Matrix4x4{
+		{1, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// NewMatrix4x4FromRotationAndTranslation returns the affine transform that
+// rotates by r and then translates by t.
+func NewMatrix4x4FromRotationAndTranslation(r Matrix3x3, t *Vector3D.Vector3D) Matrix4x4 {
+
+	return Matrix4x4{
+		{r[0][0], r[0][1], r[0][2], t.X},
+		{r[1][0], r[1][1], r[1][2], t.Y},
+		{r[2][0], r[2][1], r[2][2], t.Z},
+		{0, 0, 0, 1},
+	}
+}
+
+// Mul returns the matrix product m * o.
+func (m Matrix4x4) Mul(o Matrix4x4) Matrix4x4 {
+
+	var result Matrix4x4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				result[i][j] += m[i][k] * o[k][j]
+			}
+		}
+	}
+	return result
+}
+
+// TransformPoint applies the matrix to a point with homogeneous coordinate 1.
+func (m Matrix4x4) TransformPoint(p *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	x := m[0][0]*p.X + m[0][1]*p.Y + m[0][2]*p.Z + m[0][3]
+	y := m[1][0]*p.X + m[1][1]*p.Y + m[1][2]*p.Z + m[1][3]
+	z := m[2][0]*p.X + m[2][1]*p.Y + m[2][2]*p.Z + m[2][3]
+	w := m[3][0]*p.X + m[3][1]*p.Y + m[3][2]*p.Z + m[3][3]
+	return Vector3D.NewVector(x/w, y/w, z/w)
+}
+
+// TransformDirection applies the matrix to a direction with homogeneous
+// coordinate 0.
+func (m Matrix4x4) TransformDirection(d *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	return Vector3D.NewVector(
+		m[0][0]*d.X+m[0][1]*d.Y+m[0][2]*d.Z,
+		m[1][0]*d.X+m[1][1]*d.Y+m[1][2]*d.Z,
+		m[2][0]*d.X+m[2][1]*d.Y+m[2][2]*d.Z,
+	)
+}
+
+// Transpose returns the transposed matrix.
+func (m Matrix4x4) Transpose() Matrix4x4 {
+
+	var result Matrix4x4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			result[i][j] = m[j][i]
+		}
+	}
+	return result
+}
+
+// subDeterminants returns the 2x2 minors of the upper (s) and lower (c) two
+// rows used by both Determinant and Inverse.
+func (m Matrix4x4) subDeterminants() (s, c [6]float64) {
+
+	s[0] = m[0][0]*m[1][1] - m[1][0]*m[0][1]
+	s[1] = m[0][0]*m[1][2] - m[1][0]*m[0][2]
+	s[2] = m[0][0]*m[1][3] - m[1][0]*m[0][3]
+	s[3] = m[0][1]*m[1][2] - m[1][1]*m[0][2]
+	s[4] = m[0][1]*m[1][3] - m[1][1]*m[0][3]
+	s[5] = m[0][2]*m[1][3] - m[1][2]*m[0][3]
+
+	c[0] = m[2][0]*m[3][1] - m[3][0]*m[2][1]
+	c[1] = m[2][0]*m[3][2] - m[3][0]*m[2][2]
+	c[2] = m[2][0]*m[3][3] - m[3][0]*m[2][3]
+	c[3] = m[2][1]*m[3][2] - m[3][1]*m[2][2]
+	c[4] = m[2][1]*m[3][3] - m[3][1]*m[2][3]
+	c[5] = m[2][2]*m[3][3] - m[3][2]*m[2][3]
+	return s, c
+}
+
+// Determinant returns the determinant of the matrix.
+func (m Matrix4x4) Determinant() float64 {
+
+	s, c := m.subDeterminants()
+	return s[0]*c[5] - s[1]*c[4] + s[2]*c[3] + s[3]*c[2] - s[4]*c[1] + s[5]*c[0]
+}
+
+// Inverse returns the inverse matrix. The result is not finite if the matrix
+// is singular.
+func (m Matrix4x4) Inverse() Matrix4x4 {
+
+	s, c := m.subDeterminants()
+	invDet := 1 / (s[0]*c[5] - s[1]*c[4] + s[2]*c[3] + s[3]*c[2] - s[4]*c[1] + s[5]*c[0])
+
+	return Matrix4x4{
+		{
+			(m[1][1]*c[5] - m[1][2]*c[4] + m[1][3]*c[3]) * invDet,
+			(-m[0][1]*c[5] + m[0][2]*c[4] - m[0][3]*c[3]) * invDet,
+			(m[3][1]*s[5] - m[3][2]*s[4] + m[3][3]*s[3]) * invDet,
+			(-m[2][1]*s[5] + m[2][2]*s[4] - m[2][3]*s[3]) * invDet,
+		},
+		{
+			(-m[1][0]*c[5] + m[1][2]*c[2] - m[1][3]*c[1]) * invDet,
+			(m[0][0]*c[5] - m[0][2]*c[2] + m[0][3]*c[1]) * invDet,
+			(-m[3][0]*s[5] + m[3][2]*s[2] - m[3][3]*s[1]) * invDet,
+			(m[2][0]*s[5] - m[2][2]*s[2] + m[2][3]*s[1]) * invDet,
+		},
+		{
+			(m[1][0]*c[4] - m[1][1]*c[2] + m[1][3]*c[0]) * invDet,
+			(-m[0][0]*c[4] + m[0][1]*c[2] - m[0][3]*c[0]) * invDet,
+			(m[3][0]*s[4] - m[3][1]*s[2] + m[3][3]*s[0]) * invDet,
+			(-m[2][0]*s[4] + m[2][1]*s[2] - m[2][3]*s[0]) * invDet,
+		},
+		{
+			(-m[1][0]*c[3] + m[1][1]*c[1] - m[1][2]*c[0]) * invDet,
+			(m[0][0]*c[3] - m[0][1]*c[1] + m[0][2]*c[0]) * invDet,
+			(-m[3][0]*s[3] + m[3][1]*s[1] - m[3][2]*s[0]) * invDet,
+			(m[2][0]*s[3] - m[2][1]*s[1] + m[2][2]*s[0]) * invDet,
+		},
+	}
+}
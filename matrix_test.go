@@ -0,0 +1,169 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"testing"
+)
+
+func matrix3x3Distance(a, b Matrix3x3) float64 {
+
+	return a.Sub(b).FrobeniusNorm()
+}
+
+func TestMatrix3x3Inverse(t *testing.T) {
+
+	m := Matrix3x3{
+		{2, -1, 0.5},
+		{0.3, 4, 1},
+		{-1, 0.2, 3},
+	}
+
+	if d := matrix3x3Distance(m.Mul(m.Inverse()), NewMatrix3x3Identity()); d > 1e-12 {
+		t.Errorf("expected m * m^-1 = I, off by %v", d)
+	}
+	if d := math.Abs(m.Determinant() - m.Transpose().Determinant()); d > 1e-12 {
+		t.Errorf("expected det(m) = det(m^T), off by %v", d)
+	}
+	if d := math.Abs(m.Mul(m).Determinant() - m.Determinant()*m.Determinant()); d > 1e-9 {
+		t.Errorf("expected det(m * m) = det(m)^2, off by %v", d)
+	}
+
+	v := Vector3D.NewVector(1, -2, 0.5)
+	if d := m.Inverse().MulVector(m.MulVector(v)).DistanceTo(v); d > 1e-12 {
+		t.Errorf("expected m^-1 * m * v = v, off by %v", d)
+	}
+
+	m2 := Matrix2x2{{3, 1}, {-2, 0.5}}
+	if d := m2.Mul(m2.Inverse()).Sub(NewMatrix2x2Identity()); math.Abs(d[0][0])+math.Abs(d[0][1])+math.Abs(d[1][0])+math.Abs(d[1][1]) > 1e-12 {
+		t.Errorf("expected 2x2 m * m^-1 = I, got %v", m2.Mul(m2.Inverse()))
+	}
+}
+
+func TestMatrix4x4Inverse(t *testing.T) {
+
+	q := newQuaternionFromEuler(0.3, -0.2, 1.1)
+	affine := NewMatrix4x4FromRotationAndTranslation(q.toMatrix(), Vector3D.NewVector(1, 2, 3))
+	m := affine.Mul(Matrix4x4{
+		{2, 0, 0, 0},
+		{0.5, 1, 0, 0},
+		{0, 0, 3, 0},
+		{0.1, 0.2, 0.3, 1},
+	})
+
+	product := m.Mul(m.Inverse())
+	identity := NewMatrix4x4Identity()
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if math.Abs(product[i][j]-identity[i][j]) > 1e-12 {
+				t.Fatalf("expected m * m^-1 = I, got %v", product)
+			}
+		}
+	}
+
+	if d := math.Abs(affine.Determinant() - 1); d > 1e-12 {
+		t.Errorf("expected rigid transform determinant 1, off by %v", d)
+	}
+
+	p := Vector3D.NewVector(0.5, -1, 2)
+	if d := affine.TransformPoint(p).DistanceTo(q.rotate(p).Add(Vector3D.NewVector(1, 2, 3))); d > 1e-12 {
+		t.Errorf("expected affine point transform, off by %v", d)
+	}
+	if d := affine.Inverse().TransformPoint(affine.TransformPoint(p)).DistanceTo(p); d > 1e-12 {
+		t.Errorf("expected inverse point transform, off by %v", d)
+	}
+	if d := affine.TransformDirection(p).DistanceTo(q.rotate(p)); d > 1e-12 {
+		t.Errorf("expected direction transform to ignore translation, off by %v", d)
+	}
+}
+
+func TestMatrix3x3EigenSymmetric(t *testing.T) {
+
+	r := newQuaternionFromEuler(0.4, 1.2, -0.3).toMatrix()
+	m := r.Mul(NewMatrix3x3Diagonal(-1, 5, 2)).Mul(r.Transpose())
+
+	values, vectors := m.EigenSymmetric()
+	expected := [3]float64{5, 2, -1}
+	for i := 0; i < 3; i++ {
+		if math.Abs(values[i]-expected[i]) > 1e-12 {
+			t.Errorf("expected eigenvalue %v, got %v", expected[i], values[i])
+		}
+		v := vectors.Column(i)
+		if d := m.MulVector(v).DistanceTo(v.Multiply(values[i])); d > 1e-12 {
+			t.Errorf("expected m * v = lambda * v for eigenpair %d, off by %v", i, d)
+		}
+	}
+	if d := matrix3x3Distance(vectors.Transpose().Mul(vectors), NewMatrix3x3Identity()); d > 1e-12 {
+		t.Errorf("expected orthonormal eigenvectors, off by %v", d)
+	}
+
+	values2, vectors2 := Matrix2x2{{2, 1}, {1, 2}}.EigenSymmetric()
+	if math.Abs(values2[0]-3) > 1e-12 || math.Abs(values2[1]-1) > 1e-12 {
+		t.Errorf("expected eigenvalues 3 and 1, got %v", values2)
+	}
+	if math.Abs(vectors2[0][0]-vectors2[1][0]) > 1e-12 {
+		t.Errorf("expected eigenvector along (1, 1), got %v", vectors2)
+	}
+}
+
+func TestMatrix3x3SVD(t *testing.T) {
+
+	matrices := []Matrix3x3{
+		{{2, -1, 0.5}, {0.3, 4, 1}, {-1, 0.2, 3}},
+		{{1, 2, 3}, {2, 4, 6}, {0, 0, 1}},
+		{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}},
+		{{-1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+	}
+
+	for _, m := range matrices {
+		u, sigma, v := m.SVD()
+		if d := matrix3x3Distance(u.Mul(NewMatrix3x3Diagonal(sigma[0], sigma[1], sigma[2])).Mul(v.Transpose()), m); d > 1e-9 {
+			t.Errorf("%v: expected u * sigma * v^T = m, off by %v", m, d)
+		}
+		if d := matrix3x3Distance(u.Transpose().Mul(u), NewMatrix3x3Identity()); d > 1e-9 {
+			t.Errorf("%v: expected orthogonal u, off by %v", m, d)
+		}
+		if sigma[0] < sigma[1] || sigma[1] < sigma[2] || sigma[2] < 0 {
+			t.Errorf("%v: expected sorted non-negative singular values, got %v", m, sigma)
+		}
+
+		r, s := m.PolarDecomposition()
+		if d := matrix3x3Distance(r.Mul(s), m); d > 1e-9 {
+			t.Errorf("%v: expected r * s = m, off by %v", m, d)
+		}
+		if d := math.Abs(r.Determinant() - 1); d > 1e-9 {
+			t.Errorf("%v: expected a proper rotation, det = %v", m, r.Determinant())
+		}
+		if d := matrix3x3Distance(s, s.Transpose()); d > 1e-9 {
+			t.Errorf("%v: expected symmetric s, off by %v", m, d)
+		}
+	}
+
+	m2 := Matrix2x2{{1, 2}, {-0.5, 3}}
+	u2, sigma2, v2 := m2.SVD()
+	reconstructed := u2.Mul(NewMatrix2x2Diagonal(sigma2[0], sigma2[1])).Mul(v2.Transpose())
+	r2, s2 := m2.PolarDecomposition()
+	product := r2.Mul(s2)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			if math.Abs(reconstructed[i][j]-m2[i][j]) > 1e-12 || math.Abs(product[i][j]-m2[i][j]) > 1e-12 {
+				t.Fatalf("expected 2x2 decompositions to reconstruct %v, got %v and %v", m2, reconstructed, product)
+			}
+		}
+	}
+}
+
+func TestMatrix3x3Quaternion(t *testing.T) {
+
+	for _, q := range []*Quaternion{
+		newQuaternionFromEuler(0.4, 1.2, -0.3),
+		newQuaternionFromAxisAngle(Vector3D.NewVector(1, 0, 0), math.Pi),
+		newQuaternionFromAxisAngle(Vector3D.NewVector(0, 1, 1), 3),
+		newQuaternion(),
+	} {
+		r := newQuaternionFromMatrix(q.toMatrix())
+		if math.Abs(math.Abs(r.dot(q))-1) > 1e-12 {
+			t.Errorf("expected round trip to %v, got %v", q, r)
+		}
+	}
+}
@@ -6,63 +6,228 @@ import (
 	"math"
 )
 
+// Names of the built-in particle channels.
+const (
+	kPositionChannel = "position"
+	kVelocityChannel = "velocity"
+	kForceChannel    = "force"
+	kIdChannel       = "id"
+)
+
 // ParticleSystemData3 is the key data structure for storing particle system data. A
 // single particle has position, velocity, and force attributes by default. But
-// it can also have additional custom scalar or vector attributes.
+// it can also have additional custom scalar, vector or integer attributes which
+// are registered and looked up by name.
+//
+// By default every vector entry is a separately allocated Vector3D. With value
+// storage enabled, the vectors of each channel live in one contiguous
+// []Vector3D.Vector3D and the pointer slices returned by positions(),
+// velocities(), forces() and vectorData() point into that array. In that mode
+// callers must write through the returned pointers (Set, AddInPlace, ...)
+// instead of replacing them.
 type ParticleSystemData3 struct {
-	radius            float64
-	mass              float64
-	numberOfParticles int64
-	positionIdx       int64
-	velocityIdx       int64
-	forceIdx          int64
-	scalarDataList    [][]float64
-	vectorDataList    [][]*Vector3D.Vector3D
-	neighborSearcher  *PointParallelHashGridSearcher3
-	neighborLists     [][]int64
+	radius             float64
+	mass               float64
+	numberOfParticles  int64
+	positionIdx        int64
+	velocityIdx        int64
+	forceIdx           int64
+	idIdx              int64
+	nextParticleId     int64
+	scalarDataList     [][]float64
+	vectorDataList     [][]*Vector3D.Vector3D
+	intDataList        [][]int64
+	scalarDataNames    map[string]int64
+	vectorDataNames    map[string]int64
+	intDataNames       map[string]int64
+	scalarDataDefaults []float64
+	vectorDataDefaults []*Vector3D.Vector3D
+	intDataDefaults    []int64
+	neighborSearcher   *PointParallelHashGridSearcher3
+	neighborLists      [][]int64
+	killZones          []KillZone3
+	// Number of neighbor searcher builds between two spatial sorts. Zero
+	// disables the spatial sort.
+	spatialSortInterval int64
+	buildsSinceLastSort int64
+	// Contiguous backing arrays of the vector channels in value storage mode.
+	vectorValueList     [][]Vector3D.Vector3D
+	isUsingValueStorage bool
 }
 
 func NewParticleSystemData3() *ParticleSystemData3 {
 
 	p := &ParticleSystemData3{
-		radius:            0.001,
-		mass:              0.001,
-		numberOfParticles: 0,
-		positionIdx:       0,
-		velocityIdx:       0,
-		forceIdx:          0,
-		scalarDataList:    make([][]float64, 0),
-		vectorDataList:    make([][]*Vector3D.Vector3D, 0),
+		radius:             0.001,
+		mass:               0.001,
+		numberOfParticles:  0,
+		positionIdx:        0,
+		velocityIdx:        0,
+		forceIdx:           0,
+		idIdx:              0,
+		nextParticleId:     0,
+		scalarDataList:     make([][]float64, 0),
+		vectorDataList:     make([][]*Vector3D.Vector3D, 0),
+		intDataList:        make([][]int64, 0),
+		scalarDataNames:    make(map[string]int64),
+		vectorDataNames:    make(map[string]int64),
+		intDataNames:       make(map[string]int64),
+		scalarDataDefaults: make([]float64, 0),
+		vectorDataDefaults: make([]*Vector3D.Vector3D, 0),
+		intDataDefaults:    make([]int64, 0),
 		neighborSearcher: NewPointParallelHashGridSearcher3(
 			constants.KDefaultHashGridResolution,
 			constants.KDefaultHashGridResolution,
 			constants.KDefaultHashGridResolution,
 			0.002,
 		),
-		neighborLists: make([][]int64, 0, 0),
+		neighborLists:       make([][]int64, 0, 0),
+		killZones:           make([]KillZone3, 0),
+		spatialSortInterval: 0,
+		buildsSinceLastSort: 0,
+		vectorValueList:     make([][]Vector3D.Vector3D, 0),
+		isUsingValueStorage: false,
 	}
 
-	(*p).positionIdx = (*p).addVectorData()
-	(*p).velocityIdx = (*p).addVectorData()
-	(*p).forceIdx = (*p).addVectorData()
+	(*p).positionIdx = (*p).addVectorData(kPositionChannel, Vector3D.NewVector(0, 0, 0))
+	(*p).velocityIdx = (*p).addVectorData(kVelocityChannel, Vector3D.NewVector(0, 0, 0))
+	(*p).forceIdx = (*p).addVectorData(kForceChannel, Vector3D.NewVector(0, 0, 0))
+	(*p).idIdx = (*p).addIntData(kIdChannel, -1)
 
 	return p
 }
 
-func (p *ParticleSystemData3) addVectorData() int64 {
+// addVectorData registers a vector channel with the given name and returns its
+// index. New particles are initialized with a copy of initialVal. If a vector
+// channel with the same name exists already, its index is returned instead.
+func (p *ParticleSystemData3) addVectorData(name string, initialVal *Vector3D.Vector3D) int64 {
+
+	if attrIdx, ok := p.vectorDataNames[name]; ok {
+		return attrIdx
+	}
+
+	attrIdx := int64(len(p.vectorDataList))
+	defaultVal := Vector3D.NewVector(initialVal.X, initialVal.Y, initialVal.Z)
 
-	attrIdx := len((*p).vectorDataList)
-	(*p).vectorDataList = append((*p).vectorDataList, []*Vector3D.Vector3D{Vector3D.NewVector(0, 0, 0)})
-	return int64(attrIdx)
+	data := make([]*Vector3D.Vector3D, p.numberOfParticles)
+	values := make([]Vector3D.Vector3D, 0)
+
+	if p.isUsingValueStorage {
+		values = make([]Vector3D.Vector3D, p.numberOfParticles)
+		for i := range values {
+			values[i] = *defaultVal
+		}
+	} else {
+		for i := range data {
+			data[i] = Vector3D.NewVector(defaultVal.X, defaultVal.Y, defaultVal.Z)
+		}
+	}
 
+	p.vectorDataList = append(p.vectorDataList, data)
+	p.vectorValueList = append(p.vectorValueList, values)
+	p.vectorDataDefaults = append(p.vectorDataDefaults, defaultVal)
+	p.vectorDataNames[name] = attrIdx
+
+	if p.isUsingValueStorage {
+		p.bindVectorValues(attrIdx)
+	}
+	return attrIdx
 }
 
-func (p *ParticleSystemData3) addScalarData() int64 {
+// addScalarData registers a scalar channel with the given name and returns its
+// index. New particles are initialized with initialVal. If a scalar channel
+// with the same name exists already, its index is returned instead.
+func (p *ParticleSystemData3) addScalarData(name string, initialVal float64) int64 {
+
+	if attrIdx, ok := p.scalarDataNames[name]; ok {
+		return attrIdx
+	}
+
+	attrIdx := int64(len(p.scalarDataList))
 
-	attrIdx := len((*p).scalarDataList)
-	(*p).scalarDataList = append((*p).scalarDataList, []float64{0})
-	return int64(attrIdx)
+	data := make([]float64, p.numberOfParticles)
+	for i := range data {
+		data[i] = initialVal
+	}
 
+	p.scalarDataList = append(p.scalarDataList, data)
+	p.scalarDataDefaults = append(p.scalarDataDefaults, initialVal)
+	p.scalarDataNames[name] = attrIdx
+	return attrIdx
+}
+
+// addIntData registers an integer channel, such as IDs or phases, with the
+// given name and returns its index. New particles are initialized with
+// initialVal. If an integer channel with the same name exists already, its
+// index is returned instead.
+func (p *ParticleSystemData3) addIntData(name string, initialVal int64) int64 {
+
+	if attrIdx, ok := p.intDataNames[name]; ok {
+		return attrIdx
+	}
+
+	attrIdx := int64(len(p.intDataList))
+
+	data := make([]int64, p.numberOfParticles)
+	for i := range data {
+		data[i] = initialVal
+	}
+
+	p.intDataList = append(p.intDataList, data)
+	p.intDataDefaults = append(p.intDataDefaults, initialVal)
+	p.intDataNames[name] = attrIdx
+	return attrIdx
+}
+
+// hasScalarData returns true if a scalar channel with the given name exists.
+func (p *ParticleSystemData3) hasScalarData(name string) bool {
+
+	_, ok := p.scalarDataNames[name]
+	return ok
+}
+
+// hasVectorData returns true if a vector channel with the given name exists.
+func (p *ParticleSystemData3) hasVectorData(name string) bool {
+
+	_, ok := p.vectorDataNames[name]
+	return ok
+}
+
+// hasIntData returns true if an integer channel with the given name exists.
+func (p *ParticleSystemData3) hasIntData(name string) bool {
+
+	_, ok := p.intDataNames[name]
+	return ok
+}
+
+// scalarData returns the scalar channel with the given name, or nil if no
+// such channel has been registered.
+func (p *ParticleSystemData3) scalarData(name string) []float64 {
+
+	if attrIdx, ok := p.scalarDataNames[name]; ok {
+		return p.scalarDataList[attrIdx]
+	}
+	return nil
+}
+
+// vectorData returns the vector channel with the given name, or nil if no
+// such channel has been registered.
+func (p *ParticleSystemData3) vectorData(name string) []*Vector3D.Vector3D {
+
+	if attrIdx, ok := p.vectorDataNames[name]; ok {
+		return p.vectorDataList[attrIdx]
+	}
+	return nil
+}
+
+// intData returns the integer channel with the given name, or nil if no such
+// channel has been registered.
+func (p *ParticleSystemData3) intData(name string) []int64 {
+
+	if attrIdx, ok := p.intDataNames[name]; ok {
+		return p.intDataList[attrIdx]
+	}
+	return nil
 }
 
 func (p *ParticleSystemData3) addParticle(newPosition, newVelocity, newForce *Vector3D.Vector3D) {
@@ -89,25 +254,33 @@ func (p *ParticleSystemData3) addParticles(newPositions, newVelocities, newForce
 	pos := (*p).positions()
 	vel := (*p).velocities()
 	frc := (*p).forces()
+	ids := (*p).ids()
+
+	// Every new particle gets a unique ID which it keeps until it is removed.
+	for i := oldNumberOfParticles; i < newNumberOfParticles; i++ {
+		ids[i] = p.nextParticleId
+		p.nextParticleId++
+	}
 
+	// Copy the values so that the stored vectors never alias the caller's.
 	if (len(newPositions)) > 0 {
 		for i := 0; i < len(newPositions); i++ {
 
-			pos[int64(i)+oldNumberOfParticles] = newPositions[i]
+			pos[int64(i)+oldNumberOfParticles].Set(newPositions[i])
 		}
 	}
 
 	if (len(newVelocities)) > 0 {
 		for i := 0; i < len(newPositions); i++ {
 
-			vel[int64(i)+oldNumberOfParticles] = newVelocities[i]
+			vel[int64(i)+oldNumberOfParticles].Set(newVelocities[i])
 		}
 	}
 
 	if (len(newPositions)) > 0 {
 		for i := 0; i < len(newForces); i++ {
 
-			frc[int64(i)+oldNumberOfParticles] = newForces[i]
+			frc[int64(i)+oldNumberOfParticles].Set(newForces[i])
 		}
 	}
 }
@@ -127,6 +300,13 @@ func (p *ParticleSystemData3) forces() []*Vector3D.Vector3D {
 	return (*p).vectorDataList[p.forceIdx]
 }
 
+// ids returns the persistent particle IDs. An ID is assigned once when the
+// particle is added and follows the particle through removal and reordering.
+func (p *ParticleSystemData3) ids() []int64 {
+
+	return (*p).intDataList[p.idIdx]
+}
+
 func (p *ParticleSystemData3) Mass() float64 {
 
 	return (*p).mass
@@ -137,23 +317,261 @@ func (p *ParticleSystemData3) setMass(newMass float64) {
 	(*p).mass = math.Max(newMass, 0)
 }
 
+// resize sets every scalar, vector and integer channel to exactly
+// newNumberOfParticles entries. New entries get the default value of their
+// channel and extra entries are dropped.
 func (p *ParticleSystemData3) resize(newNumberOfParticles int64) {
 
-	for idx, _ := range p.scalarDataList {
-		for i := int64(0); i < newNumberOfParticles-1; i++ {
-			p.scalarDataList[idx] = append(p.scalarDataList[idx], 0)
+	for idx, data := range p.scalarDataList {
+		for int64(len(data)) < newNumberOfParticles {
+			data = append(data, p.scalarDataDefaults[idx])
 		}
+		p.scalarDataList[idx] = data[:newNumberOfParticles]
 	}
 
-	for idx, _ := range p.vectorDataList {
+	for idx, data := range p.vectorDataList {
+		defaultVal := p.vectorDataDefaults[idx]
+
+		if p.isUsingValueStorage {
+			values := p.vectorValueList[idx]
+			for int64(len(values)) < newNumberOfParticles {
+				values = append(values, *defaultVal)
+			}
+			p.vectorValueList[idx] = values[:newNumberOfParticles]
+			p.bindVectorValues(int64(idx))
+			continue
+		}
+
+		for int64(len(data)) < newNumberOfParticles {
+			data = append(data, Vector3D.NewVector(defaultVal.X, defaultVal.Y, defaultVal.Z))
+		}
+		p.vectorDataList[idx] = data[:newNumberOfParticles]
+	}
+
+	for idx, data := range p.intDataList {
+		for int64(len(data)) < newNumberOfParticles {
+			data = append(data, p.intDataDefaults[idx])
+		}
+		p.intDataList[idx] = data[:newNumberOfParticles]
+	}
+}
 
-		for i := int64(0); i < newNumberOfParticles-1; i++ {
-			p.vectorDataList[idx] = append(p.vectorDataList[idx], Vector3D.NewVector(0, 0, 0))
+// removeParticles deletes the particles at the given indices. Out-of-range
+// indices are ignored and the remaining particles keep their relative order.
+func (p *ParticleSystemData3) removeParticles(indices []int64) int64 {
+
+	shouldRemove := make([]bool, p.numberOfParticles)
+	for _, i := range indices {
+		if i >= 0 && i < p.numberOfParticles {
+			shouldRemove[i] = true
 		}
 	}
+
+	return p.compact(shouldRemove)
+}
+
+// removeParticlesIf deletes every particle for which predicate returns true
+// and returns the number of removed particles.
+func (p *ParticleSystemData3) removeParticlesIf(predicate func(i int64) bool) int64 {
+
+	shouldRemove := make([]bool, p.numberOfParticles)
+	for i := int64(0); i < p.numberOfParticles; i++ {
+		shouldRemove[i] = predicate(i)
+	}
+
+	return p.compact(shouldRemove)
+}
+
+// compact moves the surviving particles of every channel to the front of the
+// channel and shrinks all channels to the new particle count.
+func (p *ParticleSystemData3) compact(shouldRemove []bool) int64 {
+
+	n := p.numberOfParticles
+	kept := int64(0)
+
+	for i := int64(0); i < n; i++ {
+		if shouldRemove[i] {
+			continue
+		}
+
+		if kept != i {
+			for _, data := range p.scalarDataList {
+				data[kept] = data[i]
+			}
+			for _, data := range p.vectorDataList {
+				*data[kept] = *data[i]
+			}
+			for _, data := range p.intDataList {
+				data[kept] = data[i]
+			}
+		}
+		kept++
+	}
+
+	removed := n - kept
+	if removed == 0 {
+		return 0
+	}
+
+	p.numberOfParticles = kept
+	p.resize(kept)
+
+	// Neighbor data refers to the old indices and has to be rebuilt.
+	p.neighborLists = make([][]int64, 0, 0)
+
+	return removed
+}
+
+// addKillZone registers a region where particles get removed at every time-step.
+func (p *ParticleSystemData3) addKillZone(zone KillZone3) {
+
+	p.killZones = append(p.killZones, zone)
+}
+
+// removeParticlesInKillZones deletes every particle that lies in any of the
+// registered kill zones and returns the number of removed particles.
+func (p *ParticleSystemData3) removeParticlesInKillZones() int64 {
+
+	if len(p.killZones) == 0 {
+		return 0
+	}
+
+	positions := p.positions()
+
+	return p.removeParticlesIf(func(i int64) bool {
+		for _, zone := range p.killZones {
+			if zone.contains(positions[i]) {
+				return true
+			}
+		}
+		return false
+	})
 }
 
 func (p *ParticleSystemData3) setRadius(newRadius float64) {
 
 	p.radius = newRadius
 }
+
+// setSpatialSortInterval enables the periodic spatial sort of all particle
+// channels every interval neighbor searcher builds. Zero disables it.
+func (p *ParticleSystemData3) setSpatialSortInterval(interval int64) {
+
+	p.spatialSortInterval = int64(math.Max(float64(interval), 0))
+	p.buildsSinceLastSort = 0
+}
+
+// updateSpatialOrder should be called right after the neighbor searcher has
+// been built and sorts the particles whenever the sort interval has elapsed.
+func (p *ParticleSystemData3) updateSpatialOrder() {
+
+	if p.spatialSortInterval == 0 {
+		return
+	}
+
+	p.buildsSinceLastSort++
+	if p.buildsSinceLastSort >= p.spatialSortInterval {
+		p.sortBySpatialOrder()
+		p.buildsSinceLastSort = 0
+	}
+}
+
+// sortBySpatialOrder reorders every particle channel by the Morton order of
+// the hash-grid bucket of each particle, so that particles close in space are
+// also close in memory. The neighbor searcher must have been built from the
+// current positions and is remapped to the new order, but neighbor lists have
+// to be rebuilt.
+func (p *ParticleSystemData3) sortBySpatialOrder() {
+
+	if int64(len(p.neighborSearcher.sortedIndices)) != p.numberOfParticles {
+		return
+	}
+
+	order := p.neighborSearcher.mortonOrder()
+	p.reorder(order)
+
+	newIndexOf := make([]int64, len(order))
+	for newIdx, oldIdx := range order {
+		newIndexOf[oldIdx] = int64(newIdx)
+	}
+	p.neighborSearcher.remapIndices(newIndexOf, p.positions())
+	p.neighborLists = make([][]int64, 0, 0)
+}
+
+// reorder permutes every channel so that the new i-th particle is the old
+// order[i]-th particle.
+func (p *ParticleSystemData3) reorder(order []int64) {
+
+	n := len(order)
+
+	scalarBuffer := make([]float64, n)
+	for _, data := range p.scalarDataList {
+		for i, oldIdx := range order {
+			scalarBuffer[i] = data[oldIdx]
+		}
+		copy(data, scalarBuffer)
+	}
+
+	vectorBuffer := make([]Vector3D.Vector3D, n)
+	for _, data := range p.vectorDataList {
+		for i, oldIdx := range order {
+			vectorBuffer[i] = *data[oldIdx]
+		}
+		for i := range vectorBuffer {
+			*data[i] = vectorBuffer[i]
+		}
+	}
+
+	intBuffer := make([]int64, n)
+	for _, data := range p.intDataList {
+		for i, oldIdx := range order {
+			intBuffer[i] = data[oldIdx]
+		}
+		copy(data, intBuffer)
+	}
+}
+
+// setIsUsingValueStorage switches the vector channels between separately
+// allocated vectors and contiguous value-typed arrays. The particle data is
+// preserved, but pointers obtained before the switch become stale.
+func (p *ParticleSystemData3) setIsUsingValueStorage(isUsing bool) {
+
+	if isUsing == p.isUsingValueStorage {
+		return
+	}
+	p.isUsingValueStorage = isUsing
+
+	for idx, data := range p.vectorDataList {
+		if isUsing {
+			values := make([]Vector3D.Vector3D, len(data))
+			for i, v := range data {
+				values[i] = *v
+			}
+			p.vectorValueList[idx] = values
+			p.bindVectorValues(int64(idx))
+		} else {
+			for i, v := range data {
+				data[i] = Vector3D.NewVector(v.X, v.Y, v.Z)
+			}
+			p.vectorValueList[idx] = make([]Vector3D.Vector3D, 0)
+		}
+	}
+}
+
+// bindVectorValues points every entry of a vector channel to its slot in the
+// contiguous backing array of the channel.
+func (p *ParticleSystemData3) bindVectorValues(idx int64) {
+
+	values := p.vectorValueList[idx]
+	data := p.vectorDataList[idx]
+
+	if cap(data) < len(values) {
+		data = make([]*Vector3D.Vector3D, len(values))
+	}
+	data = data[:len(values)]
+
+	for i := range values {
+		data[i] = &values[i]
+	}
+	p.vectorDataList[idx] = data
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"testing"
+)
+
+func TestParticleSystemData3RemoveParticles(t *testing.T) {
+
+	particles := NewParticleSystemData3()
+	idx := particles.addScalarData("age", 0)
+
+	positions := make([]*Vector3D.Vector3D, 0)
+	velocities := make([]*Vector3D.Vector3D, 0)
+	for i := 0; i < 5; i++ {
+		positions = append(positions, Vector3D.NewVector(float64(i), 0, 0))
+		velocities = append(velocities, Vector3D.NewVector(0, float64(i), 0))
+	}
+	particles.addParticles(positions, velocities, nil)
+
+	for i := 0; i < 5; i++ {
+		particles.scalarDataList[idx][i] = float64(10 * i)
+	}
+
+	removed := particles.removeParticles([]int64{1, 3, 7})
+	if removed != 2 || particles.numberOfParticles != 3 {
+		t.Fatalf("expected 2 removed and 3 left, got %d removed and %d left", removed, particles.numberOfParticles)
+	}
+
+	expected := []float64{0, 2, 4}
+	for i, x := range expected {
+		if particles.positions()[i].X != x || particles.velocities()[i].Y != x {
+			t.Errorf("particle %d: expected channel value %v, got position %v velocity %v",
+				i, x, particles.positions()[i], particles.velocities()[i])
+		}
+		if particles.scalarDataList[idx][i] != 10*x {
+			t.Errorf("particle %d: expected scalar %v, got %v", i, 10*x, particles.scalarDataList[idx][i])
+		}
+	}
+
+	for _, data := range particles.vectorDataList {
+		if len(data) != 3 {
+			t.Errorf("expected vector channel of length 3, got %d", len(data))
+		}
+	}
+}
+
+func TestParticleSystemData3KillZones(t *testing.T) {
+
+	particles := NewParticleSystemData3()
+	particles.addParticles([]*Vector3D.Vector3D{
+		Vector3D.NewVector(0.5, 0.5, 0.5),
+		Vector3D.NewVector(0.5, -1, 0.5),
+		Vector3D.NewVector(3, 3, 3),
+		Vector3D.NewVector(0.2, 0.9, 0.2),
+	}, nil, nil)
+
+	domain := NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(1, 1, 1))
+	particles.addKillZone(NewInvertedKillZone3(domain))
+	particles.addKillZone(NewImplicitSurfaceKillZone3(NewSphere3(Vector3D.NewVector(0.2, 0.9, 0.2), 0.05)))
+
+	removed := particles.removeParticlesInKillZones()
+	if removed != 3 || particles.numberOfParticles != 1 {
+		t.Fatalf("expected 3 removed and 1 left, got %d removed and %d left", removed, particles.numberOfParticles)
+	}
+	if !particles.positions()[0].IsSimilar(Vector3D.NewVector(0.5, 0.5, 0.5)) {
+		t.Errorf("unexpected surviving particle %v", particles.positions()[0])
+	}
+}
+
+func TestParticleSystemData3NamedChannels(t *testing.T) {
+
+	particles := NewParticleSystemData3()
+	particles.addParticles([]*Vector3D.Vector3D{Vector3D.NewVector(0, 0, 0)}, nil, nil)
+
+	lifetimeIdx := particles.addScalarData("lifetime", 2.5)
+	particles.addVectorData("color", Vector3D.NewVector(1, 0, 0))
+	particles.addIntData("phase", 3)
+
+	if particles.addScalarData("lifetime", 0) != lifetimeIdx {
+		t.Errorf("registering an existing channel name should return its index")
+	}
+	if !particles.hasIntData("phase") || particles.hasIntData("lifetime") {
+		t.Errorf("channel lookup by name returned the wrong channel kind")
+	}
+
+	particles.addParticles([]*Vector3D.Vector3D{Vector3D.NewVector(1, 0, 0), Vector3D.NewVector(2, 0, 0)}, nil, nil)
+
+	lifetimes := particles.scalarData("lifetime")
+	colors := particles.vectorData("color")
+	phases := particles.intData("phase")
+
+	if len(lifetimes) != 3 || len(colors) != 3 || len(phases) != 3 {
+		t.Fatalf("expected all channels to hold 3 particles, got %d, %d and %d", len(lifetimes), len(colors), len(phases))
+	}
+
+	for i := 0; i < 3; i++ {
+		if lifetimes[i] != 2.5 || phases[i] != 3 || !colors[i].IsSimilar(Vector3D.NewVector(1, 0, 0)) {
+			t.Errorf("particle %d was not initialized with the channel defaults", i)
+		}
+	}
+
+	colors[0].X = 0
+	if colors[1].X != 1 {
+		t.Errorf("vector channel entries must not share the default value")
+	}
+
+	if particles.scalarData("missing") != nil {
+		t.Errorf("expected nil for an unknown channel")
+	}
+}
+
+func TestParticleSystemData3StableIds(t *testing.T) {
+
+	particles := NewParticleSystemData3()
+	for i := 0; i < 4; i++ {
+		particles.addParticle(Vector3D.NewVector(float64(i), 0, 0), Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(0, 0, 0))
+	}
+
+	particles.removeParticles([]int64{0, 2})
+	particles.addParticle(Vector3D.NewVector(4, 0, 0), Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(0, 0, 0))
+
+	expected := []int64{1, 3, 4}
+	ids := particles.ids()
+	if len(ids) != len(expected) {
+		t.Fatalf("expected %d IDs, got %d", len(expected), len(ids))
+	}
+	for i, id := range expected {
+		if ids[i] != id || particles.positions()[i].X != float64(id) {
+			t.Errorf("particle %d: expected ID %d at x = %d, got ID %d at %v", i, id, id, ids[i], particles.positions()[i])
+		}
+	}
+}
+
+func TestParticleSystemData3SortBySpatialOrder(t *testing.T) {
+
+	particles := NewParticleSystemData3()
+	particles.neighborSearcher = NewPointParallelHashGridSearcher3(8, 8, 8, 0.5)
+
+	positions := make([]*Vector3D.Vector3D, 0)
+	for i := 0; i < 64; i++ {
+		// Scatter the points over the grid in an order unrelated to space.
+		j := (i * 37) % 64
+		positions = append(positions, Vector3D.NewVector(float64(j%4), float64((j/4)%4), float64(j/16)))
+	}
+	particles.addParticles(positions, nil, nil)
+
+	xBefore := make(map[int64]float64)
+	for i, id := range particles.ids() {
+		xBefore[id] = particles.positions()[i].X
+	}
+
+	particles.neighborSearcher.build(particles.positions())
+	particles.sortBySpatialOrder()
+
+	codes := make([]int64, 0)
+	for i, id := range particles.ids() {
+		position := particles.positions()[i]
+		if position.X != xBefore[id] {
+			t.Fatalf("particle %d lost track of its ID %d", i, id)
+		}
+		key := particles.neighborSearcher.getHashKeyFromPosition3(position)
+		codes = append(codes, particles.neighborSearcher.getMortonCodeFromHashKey(key))
+	}
+
+	for i := 1; i < len(codes); i++ {
+		if codes[i] < codes[i-1] {
+			t.Fatalf("particles are not in Morton order at %d", i)
+		}
+	}
+
+	// The remapped searcher must report the new indices.
+	origin := particles.positions()[10]
+	callback := func(i, j int64, v *Vector3D.Vector3D, origin *Vector3D.Vector3D, sum *float64) {
+		if !particles.positions()[j].IsSimilar(v) {
+			t.Errorf("searcher index %d does not match the reordered position %v", j, v)
+		}
+	}
+	particles.neighborSearcher.forEachNearbyPoint3(origin, 1.0, 10, nil, callback)
+}
+
+func TestParticleSystemData3ValueStorage(t *testing.T) {
+
+	particles := NewParticleSystemData3()
+	particles.addParticles([]*Vector3D.Vector3D{
+		Vector3D.NewVector(0, 0, 0),
+		Vector3D.NewVector(1, 0, 0),
+	}, nil, nil)
+	particles.setIsUsingValueStorage(true)
+
+	positions := make([]*Vector3D.Vector3D, 0)
+	for i := 2; i < 100; i++ {
+		positions = append(positions, Vector3D.NewVector(float64(i), 0, 0))
+	}
+	particles.addParticles(positions, nil, nil)
+	particles.removeParticlesIf(func(i int64) bool { return i%2 == 1 })
+
+	values := particles.vectorValueList[particles.positionIdx]
+	if int64(len(values)) != particles.numberOfParticles {
+		t.Fatalf("expected %d values, got %d", particles.numberOfParticles, len(values))
+	}
+	for i, p := range particles.positions() {
+		if p != &values[i] {
+			t.Fatalf("particle %d is not backed by the contiguous array", i)
+		}
+		if p.X != float64(2*i) {
+			t.Errorf("particle %d: expected x %v, got %v", i, 2*i, p.X)
+		}
+	}
+
+	particles.velocities()[0].AddInPlace(Vector3D.NewVector(0, 1, 0))
+	particles.setIsUsingValueStorage(false)
+	if particles.velocities()[0].Y != 1 || particles.positions()[49].X != 98 {
+		t.Errorf("expected data to survive switching back, got %v and %v",
+			particles.velocities()[0], particles.positions()[49])
+	}
+}
@@ -28,8 +28,8 @@ type ParticleSystemSolver3 struct {
 	particleSystemData        *ParticleSystemData3
 	newPositions              []*Vector3D.Vector3D
 	newVelocities             []*Vector3D.Vector3D
-	collider                  *RigidBodyCollider3
-	emitter                   *PointParticleEmitter3
+	collider                  Collider3IF
+	emitter                   ParticleEmitter3IF
 	wind                      *ConstantVectorField3
 }
 
@@ -53,15 +53,15 @@ func (p *ParticleSystemSolver3) SetRestitutionCoefficient(restitutionCoefficient
 	p.restitutionCoefficient = restitutionCoefficient
 }
 
-func (p *ParticleSystemSolver3) Collider() *RigidBodyCollider3 {
+func (p *ParticleSystemSolver3) Collider() Collider3IF {
 	return p.collider
 }
 
-func (p *ParticleSystemSolver3) SetCollider(collider *RigidBodyCollider3) {
+func (p *ParticleSystemSolver3) SetCollider(collider Collider3IF) {
 	p.collider = collider
 }
 
-func (p *ParticleSystemSolver3) SetEmitter(emitter *PointParticleEmitter3) {
+func (p *ParticleSystemSolver3) SetEmitter(emitter ParticleEmitter3IF) {
 	p.emitter = emitter
 	emitter.setTarget(p.particleSystemData)
 }
@@ -129,11 +129,13 @@ func (p *ParticleSystemSolver3) resolveCollision() {
 
 	numberOfParticles := p.particleSystemData.numberOfParticles
 	radius := p.particleSystemData.radius
+	mass := p.particleSystemData.Mass()
+	positions := p.particleSystemData.positions()
 
 	for i := 0; i < int(numberOfParticles); i++ {
-		p.collider.resolveCollision(radius, p.restitutionCoefficient, &p.newPositions[i], &p.newVelocities[i])
-		p.particleSystemData.vectorDataList[p.particleSystemData.velocityIdx][i] = p.newVelocities[i]
-		p.particleSystemData.vectorDataList[p.particleSystemData.positionIdx][i] = p.newPositions[i]
+		p.collider.resolveCollision(int64(i), mass, radius, p.restitutionCoefficient, positions[i], &p.newPositions[i], &p.newVelocities[i])
+		p.particleSystemData.vectorDataList[p.particleSystemData.velocityIdx][i].Set(p.newVelocities[i])
+		p.particleSystemData.vectorDataList[p.particleSystemData.positionIdx][i].Set(p.newPositions[i])
 	}
 }
 
@@ -150,26 +152,22 @@ func (p *ParticleSystemSolver3) timeIntegration(timeStepsInSeconds float64) {
 
 		// Integrate velocity first.
 		newVelocity := p.newVelocities[i]
-		forceMultiply := forces[i].Multiply(timeStepsInSeconds)
-		forceMultiplyDivide := forceMultiply.Divide(mass)
-		newVelocity = velocities[i].Add(forceMultiplyDivide)
-		p.newVelocities[i] = newVelocity
-		p.particleSystemData.vectorDataList[p.particleSystemData.velocityIdx][i] = newVelocity
-		//(*p.particleSystemData.vectorDataList[p.particleSystemData.velocityIdx][i]).Set(newVelocity)
+		newVelocity.Set(velocities[i])
+		newVelocity.AddScaledInPlace(forces[i], timeStepsInSeconds/mass)
+		velocities[i].Set(newVelocity)
 
 		// Integrate position.
 
 		newPosition := p.newPositions[i]
-		newVelocityMultiply := newVelocity.Multiply(timeStepsInSeconds)
-		newPosition = positions[i].Add(newVelocityMultiply)
-		p.newPositions[i] = newPosition
-		p.particleSystemData.vectorDataList[p.particleSystemData.positionIdx][i] = newPosition
-		//(*p.particleSystemData.vectorDataList[p.particleSystemData.positionIdx][i]).Set(newPosition)
+		newPosition.Set(positions[i])
+		newPosition.AddScaledInPlace(newVelocity, timeStepsInSeconds)
+		positions[i].Set(newPosition)
 	}
 }
 
 // mtResult collects the information from the worker threads for timeIntegrationMT.
 type mtResult struct {
+	index       int64
 	newVelocity *Vector3D.Vector3D
 	newPosition *Vector3D.Vector3D
 }
@@ -197,21 +195,18 @@ func (p *ParticleSystemSolver3) timeIntegrationMT(timeStepsInSeconds float64) {
 				mass := p.particleSystemData.Mass()
 
 				// Integrate velocity first.
+				// Each job owns index i, so the scratch vectors are updated in place.
 				newVelocity := p.newVelocities[i]
-				forceMultiply := forces[i].Multiply(timeStepsInSeconds)
-				forceMultiplyDivide := forceMultiply.Divide(mass)
-				newVelocity = velocities[i].Add(forceMultiplyDivide)
-				//p.newVelocities[i] = newVelocity
-				//p.particleSystemData.vectorDataList[p.particleSystemData.velocityIdx][i] = newVelocity
+				newVelocity.Set(velocities[i])
+				newVelocity.AddScaledInPlace(forces[i], timeStepsInSeconds/mass)
 
 				// Integrate position.
 				newPosition := p.newPositions[i]
-				newVelocityMultiply := newVelocity.Multiply(timeStepsInSeconds)
-				newPosition = positions[i].Add(newVelocityMultiply)
-				//p.newPositions[i] = newPosition
-				//p.particleSystemData.vectorDataList[p.particleSystemData.positionIdx][i] = newPosition
+				newPosition.Set(positions[i])
+				newPosition.AddScaledInPlace(newVelocity, timeStepsInSeconds)
 
 				results <- &mtResult{
+					index:       i,
 					newVelocity: newVelocity,
 					newPosition: newPosition,
 				}
@@ -225,15 +220,14 @@ func (p *ParticleSystemSolver3) timeIntegrationMT(timeStepsInSeconds float64) {
 	}
 	close(jobs)
 
-	for a := int64(0); a < n; a++ {
+	for r := int64(0); r < n; r++ {
 
+		// Results arrive in completion order, so store them at their own index.
 		resultStruct := <-results
+		a := resultStruct.index
 
-		p.newVelocities[a] = resultStruct.newVelocity
-		p.particleSystemData.vectorDataList[p.particleSystemData.velocityIdx][a] = resultStruct.newVelocity
-
-		p.newPositions[a] = resultStruct.newPosition
-		p.particleSystemData.vectorDataList[p.particleSystemData.positionIdx][a] = resultStruct.newPosition
+		p.particleSystemData.vectorDataList[p.particleSystemData.velocityIdx][a].Set(resultStruct.newVelocity)
+		p.particleSystemData.vectorDataList[p.particleSystemData.positionIdx][a].Set(resultStruct.newPosition)
 	}
 
 	wg.Wait()
@@ -254,7 +248,7 @@ func (p *ParticleSystemSolver3) accumulateExternalForces() {
 		relativeVel := velocities[i].Substract(p.wind.value)
 		force.Add(relativeVel.Multiply(p.dragCoefficient))
 
-		forces[i] = forces[i].Add(force)
+		forces[i].AddInPlace(force)
 	}
 }
 
@@ -264,15 +258,17 @@ func (p *ParticleSystemSolver3) beginAdvanceTimeStep(timeStepInSeconds float64)
 	// Clear forces.
 	forces := p.particleSystemData.forces()
 	for i := 0; i < len(forces); i++ {
-		forces[i] = Vector3D.NewVector(0, 0, 0)
+		*forces[i] = Vector3D.Vector3D{}
 	}
 
 	// Update collider and emitter.
-	// collider does nothing.
-
 	p.currentTime = float64(p.currentFrame.index) * p.currentFrame.timeIntervalInSeconds
+	p.collider.update(p.currentTime, timeStepInSeconds)
 	p.emitter.update(p.currentTime, timeStepInSeconds)
 
+	// Discard particles that entered a kill zone.
+	p.particleSystemData.removeParticlesInKillZones()
+
 	// Allocate buffers.
 	n := p.particleSystemData.numberOfParticles
 	p.resize(n)
@@ -295,11 +291,10 @@ func (p *ParticleSystemSolver3) setWind(wind *ConstantVectorField3) {
 
 func (p *ParticleSystemSolver3) resize(size int64) {
 
-	for i := int64(0); i < size-1; i++ {
+	for int64(len(p.newPositions)) < size {
 		p.newPositions = append(p.newPositions, Vector3D.NewVector(0, 0, 0))
 		p.newVelocities = append(p.newVelocities, Vector3D.NewVector(0, 0, 0))
 	}
-
 }
 
 func (p *ParticleSystemSolver3) saveParticleDataXyUpdate(particles *ParticleSystemData3, frame *Frame) {
@@ -308,6 +303,7 @@ func (p *ParticleSystemSolver3) saveParticleDataXyUpdate(particles *ParticleSyst
 
 	x := make([]float64, n)
 	y := make([]float64, n)
+	ids := make([]int64, n)
 
 	//positions := particles.positions()
 
@@ -315,6 +311,7 @@ func (p *ParticleSystemSolver3) saveParticleDataXyUpdate(particles *ParticleSyst
 
 		x[i] = particles.positions()[i].X
 		y[i] = particles.positions()[i].Y
+		ids[i] = particles.ids()[i]
 
 		//if frame.index == 100 {
 		//	z := make([]float64, n)
@@ -330,9 +327,11 @@ func (p *ParticleSystemSolver3) saveParticleDataXyUpdate(particles *ParticleSyst
 	const conf = "animation/Update"
 	fileNameX := fmt.Sprintf("data.#point2,%04d,x.npy", frame.index)
 	fileNameY := fmt.Sprintf("data.#point2,%04d,y.npy", frame.index)
+	fileNameId := fmt.Sprintf("data.#point2,%04d,id.npy", frame.index)
 
 	saveNpy(path, conf, fileNameX, x, frame)
 	saveNpy(path, conf, fileNameY, y, frame)
+	saveNpyInt64(path, conf, fileNameId, ids, frame)
 }
 
 func (p *ParticleSystemSolver3) setIsUsingFixedSubTimeSteps(isUsing bool) {
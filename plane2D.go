@@ -24,6 +24,11 @@ func (p *Plane2D) getTransform() *Transform2 {
 	return p.transform
 }
 
+// getIsNormalFlipped returns true if the normal is flipped.
+func (p *Plane2D) getIsNormalFlipped() bool {
+	return p.isNormalFlipped
+}
+
 // NewPlane2D constructs a plane that cross \p point with surface normal \p normal.
 func NewPlane2D(normal, point *Vector3D.Vector3D) *Plane2D {
 	return &Plane2D{
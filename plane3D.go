@@ -1,6 +1,9 @@
 package main
 
-import "jimmykiang/fluidengine/Vector3D"
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
 
 // Plane3D defines a simple Plane3D struct data representing a 3-D plane geometry.
 type Plane3D struct {
@@ -56,7 +59,7 @@ func (p *Plane3D) closestNormal(otherPoint *Vector3D.Vector3D) *Vector3D.Vector3
 	result := p.transform.toWorldDirection(p.closestNormalLocal(otherPoint))
 	if p.isNormalFlipped {
 
-		result.Multiply(-1)
+		result = result.Multiply(-1)
 	}
 
 	return result
@@ -107,7 +110,7 @@ func (p *Plane3D) boundingBox() *BoundingBox3D {
 
 func (p *Plane3D) signedDistance(otherPoint *Vector3D.Vector3D) float64 {
 
-	x := p.closestPoint(p.transform.toLocal(otherPoint))
+	x := p.closestPoint(otherPoint)
 	inside := p.isInside(otherPoint)
 
 	sd := 0.0
@@ -126,3 +129,42 @@ func (p *Plane3D) signedDistance(otherPoint *Vector3D.Vector3D) float64 {
 func (p *Plane3D) getTransform() *Transform3 {
 	return p.transform
 }
+
+// getIsNormalFlipped returns true if the normal is flipped.
+func (p *Plane3D) getIsNormalFlipped() bool {
+	return p.isNormalFlipped
+}
+
+// closestIntersection returns the hit of the ray with the plane.
+func (p *Plane3D) closestIntersection(ray *Ray3) *SurfaceRayIntersection3 {
+
+	return intersectionToWorld(p.transform, p.isNormalFlipped, p.closestIntersectionLocal(p.transform.toLocalRay(ray)))
+}
+
+// intersects returns true if the ray hits the plane.
+func (p *Plane3D) intersects(ray *Ray3) bool {
+
+	return p.closestIntersectionLocal(p.transform.toLocalRay(ray)).isIntersecting
+}
+
+func (p *Plane3D) closestIntersectionLocal(ray *Ray3) *SurfaceRayIntersection3 {
+
+	intersection := NewSurfaceRayIntersection3()
+
+	// A ray parallel to the plane never hits it.
+	dDotN := ray.direction.DotProduct(p.normal)
+	if math.Abs(dDotN) == 0 {
+		return intersection
+	}
+
+	t := p.normal.DotProduct(p.point.Substract(ray.origin)) / dDotN
+	if t < 0 {
+		return intersection
+	}
+
+	intersection.isIntersecting = true
+	intersection.distance = t
+	intersection.point = ray.pointAt(t)
+	intersection.normal = Vector3D.NewVector(p.normal.X, p.normal.Y, p.normal.Z)
+	return intersection
+}
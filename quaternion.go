@@ -1,15 +1,224 @@
 package main
 
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+)
+
 // Quaternion struct defined as q = w + xi + yj + zk.
 type Quaternion struct {
 	// Real part.
-	w float32
+	w float64
 
 	// Imaginary parts.
-	x, y, z float32
+	x, y, z float64
 }
 
 // newQuaternion creates an identity Quaternion.
 func newQuaternion() *Quaternion {
 	return &Quaternion{1, 0, 0, 0}
 }
+
+// newQuaternionFromAxisAngle creates a Quaternion rotating by angle radians
+// around axis.
+func newQuaternionFromAxisAngle(axis *Vector3D.Vector3D, angle float64) *Quaternion {
+
+	a := axis.Normalize()
+	if a.Length() == 0 {
+		return newQuaternion()
+	}
+
+	s := math.Sin(0.5 * angle)
+	return &Quaternion{
+		w: math.Cos(0.5 * angle),
+		x: a.X * s,
+		y: a.Y * s,
+		z: a.Z * s,
+	}
+}
+
+// newQuaternionFromEuler creates a Quaternion from Euler angles in radians.
+// The rotation is applied around x first, then y, then z.
+func newQuaternionFromEuler(angleX, angleY, angleZ float64) *Quaternion {
+
+	qx := newQuaternionFromAxisAngle(Vector3D.NewVector(1, 0, 0), angleX)
+	qy := newQuaternionFromAxisAngle(Vector3D.NewVector(0, 1, 0), angleY)
+	qz := newQuaternionFromAxisAngle(Vector3D.NewVector(0, 0, 1), angleZ)
+
+	return qz.mul(qy).mul(qx)
+}
+
+// mul returns q * other, the rotation other followed by q.
+func (q *Quaternion) mul(other *Quaternion) *Quaternion {
+	return &Quaternion{
+		w: q.w*other.w - q.x*other.x - q.y*other.y - q.z*other.z,
+		x: q.w*other.x + q.x*other.w + q.y*other.z - q.z*other.y,
+		y: q.w*other.y - q.x*other.z + q.y*other.w + q.z*other.x,
+		z: q.w*other.z + q.x*other.y - q.y*other.x + q.z*other.w,
+	}
+}
+
+// l2Norm returns the length of the quaternion.
+func (q *Quaternion) l2Norm() float64 {
+
+	return math.Sqrt(q.w*q.w + q.x*q.x + q.y*q.y + q.z*q.z)
+}
+
+// normalize scales the quaternion to unit length in place.
+func (q *Quaternion) normalize() {
+
+	norm := q.l2Norm()
+	if norm > 0 {
+		q.w /= norm
+		q.x /= norm
+		q.y /= norm
+		q.z /= norm
+	}
+}
+
+// normalized returns a unit length copy of the quaternion.
+func (q *Quaternion) normalized() *Quaternion {
+
+	result := &Quaternion{q.w, q.x, q.y, q.z}
+	result.normalize()
+	return result
+}
+
+// inverse returns the inverse rotation.
+func (q *Quaternion) inverse() *Quaternion {
+
+	denom := q.w*q.w + q.x*q.x + q.y*q.y + q.z*q.z
+	return &Quaternion{q.w / denom, -q.x / denom, -q.y / denom, -q.z / denom}
+}
+
+// dot returns the dot product with other.
+func (q *Quaternion) dot(other *Quaternion) float64 {
+
+	return q.w*other.w + q.x*other.x + q.y*other.y + q.z*other.z
+}
+
+// axis returns the rotational axis of a unit quaternion.
+func (q *Quaternion) axis() *Vector3D.Vector3D {
+
+	result := Vector3D.NewVector(q.x, q.y, q.z).Normalize()
+	if result.Length() == 0 {
+		return Vector3D.NewVector(1, 0, 0)
+	}
+	if 2*math.Acos(math.Max(-1, math.Min(1, q.w))) < math.Pi {
+		return result
+	}
+	return result.Multiply(-1)
+}
+
+// angle returns the rotational angle of a unit quaternion in [0, pi].
+func (q *Quaternion) angle() float64 {
+
+	result := 2 * math.Acos(math.Max(-1, math.Min(1, q.w)))
+	if result < math.Pi {
+		return result
+	}
+	return 2*math.Pi - result
+}
+
+// rotate returns v rotated by the unit quaternion.
+func (q *Quaternion) rotate(v *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	// v + 2w (u x v) + 2 u x (u x v) with u the vector part.
+	u := Vector3D.NewVector(q.x, q.y, q.z)
+	t := u.CrossProduct(v).Multiply(2)
+	result := v.Add(t.Multiply(q.w))
+	result.AddInPlace(u.CrossProduct(t))
+	return result
+}
+
+// newQuaternionFromMatrix creates a Quaternion from a 3x3 rotation matrix.
+func newQuaternionFromMatrix(m Matrix3x3) *Quaternion {
+
+	q := &Quaternion{}
+	trace := m.Trace()
+
+	// Pick the largest component to keep the square root well conditioned.
+	if trace > 0 {
+		s := 0.5 / math.Sqrt(trace+1)
+		q.w = 0.25 / s
+		q.x = (m[2][1] - m[1][2]) * s
+		q.y = (m[0][2] - m[2][0]) * s
+		q.z = (m[1][0] - m[0][1]) * s
+	} else if m[0][0] > m[1][1] && m[0][0] > m[2][2] {
+		s := 2 * math.Sqrt(1+m[0][0]-m[1][1]-m[2][2])
+		q.w = (m[2][1] - m[1][2]) / s
+		q.x = 0.25 * s
+		q.y = (m[0][1] + m[1][0]) / s
+		q.z = (m[0][2] + m[2][0]) / s
+	} else if m[1][1] > m[2][2] {
+		s := 2 * math.Sqrt(1+m[1][1]-m[0][0]-m[2][2])
+		q.w = (m[0][2] - m[2][0]) / s
+		q.x = (m[0][1] + m[1][0]) / s
+		q.y = 0.25 * s
+		q.z = (m[1][2] + m[2][1]) / s
+	} else {
+		s := 2 * math.Sqrt(1+m[2][2]-m[0][0]-m[1][1])
+		q.w = (m[1][0] - m[0][1]) / s
+		q.x = (m[0][2] + m[2][0]) / s
+		q.y = (m[1][2] + m[2][1]) / s
+		q.z = 0.25 * s
+	}
+
+	q.normalize()
+	return q
+}
+
+// toMatrix returns the 3x3 rotation matrix of the unit quaternion.
+func (q *Quaternion) toMatrix() Matrix3x3 {
+
+	_2xx := 2 * q.x * q.x
+	_2yy := 2 * q.y * q.y
+	_2zz := 2 * q.z * q.z
+	_2xy := 2 * q.x * q.y
+	_2xz := 2 * q.x * q.z
+	_2xw := 2 * q.x * q.w
+	_2yz := 2 * q.y * q.z
+	_2yw := 2 * q.y * q.w
+	_2zw := 2 * q.z * q.w
+
+	return Matrix3x3{
+		{1 - _2yy - _2zz, _2xy - _2zw, _2xz + _2yw},
+		{_2xy + _2zw, 1 - _2zz - _2xx, _2yz - _2xw},
+		{_2xz - _2yw, _2yz + _2xw, 1 - _2yy - _2xx},
+	}
+}
+
+// slerp returns the spherical linear interpolation from q (t = 0) to other
+// (t = 1) along the shorter arc.
+func (q *Quaternion) slerp(other *Quaternion, t float64) *Quaternion {
+
+	b := &Quaternion{other.w, other.x, other.y, other.z}
+	cosHalfAngle := q.dot(b)
+
+	// Take the shorter arc.
+	if cosHalfAngle < 0 {
+		b = &Quaternion{-b.w, -b.x, -b.y, -b.z}
+		cosHalfAngle = -cosHalfAngle
+	}
+
+	// Fall back to linear interpolation for nearly equal rotations.
+	var wa, wb float64
+	if cosHalfAngle > 1-1e-9 {
+		wa = 1 - t
+		wb = t
+	} else {
+		halfAngle := math.Acos(cosHalfAngle)
+		sinHalfAngle := math.Sin(halfAngle)
+		wa = math.Sin((1-t)*halfAngle) / sinHalfAngle
+		wb = math.Sin(t*halfAngle) / sinHalfAngle
+	}
+
+	result := &Quaternion{
+		w: wa*q.w + wb*b.w,
+		x: wa*q.x + wb*b.x,
+		y: wa*q.y + wb*b.y,
+		z: wa*q.z + wb*b.z,
+	}
+	result.normalize()
+	return result
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"testing"
+)
+
+func TestQuaternionRotate(t *testing.T) {
+
+	q := newQuaternionFromAxisAngle(Vector3D.NewVector(0, 0, 1), 0.5*math.Pi)
+	v := Vector3D.NewVector(1, 0, 0)
+
+	if r := q.rotate(v); r.DistanceTo(Vector3D.NewVector(0, 1, 0)) > 1e-12 {
+		t.Errorf("expected (0, 1, 0), got %v", r)
+	}
+	if r := q.toMatrix().MulVector(v); r.DistanceTo(Vector3D.NewVector(0, 1, 0)) > 1e-12 {
+		t.Errorf("expected matrix to match rotate, got %v", r)
+	}
+	if r := q.inverse().rotate(q.rotate(v)); r.DistanceTo(v) > 1e-12 {
+		t.Errorf("expected inverse to undo the rotation, got %v", r)
+	}
+
+	// Rotating around x then y is the product qy * qx.
+	qx := newQuaternionFromAxisAngle(Vector3D.NewVector(1, 0, 0), 0.3)
+	qy := newQuaternionFromAxisAngle(Vector3D.NewVector(0, 1, 0), -0.7)
+	w := Vector3D.NewVector(0.2, -1.5, 0.8)
+	if r := qy.mul(qx).rotate(w); r.DistanceTo(qy.rotate(qx.rotate(w))) > 1e-12 {
+		t.Errorf("expected composed rotation, got %v", r)
+	}
+	if e := newQuaternionFromEuler(0.3, -0.7, 0); e.rotate(w).DistanceTo(qy.rotate(qx.rotate(w))) > 1e-12 {
+		t.Errorf("expected Euler angles to apply x then y")
+	}
+
+	if math.Abs(q.angle()-0.5*math.Pi) > 1e-12 || q.axis().DistanceTo(Vector3D.NewVector(0, 0, 1)) > 1e-12 {
+		t.Errorf("expected axis (0, 0, 1) and angle pi/2, got %v and %v", q.axis(), q.angle())
+	}
+}
+
+func TestQuaternionSlerp(t *testing.T) {
+
+	axis := Vector3D.NewVector(1, 1, 0)
+	a := newQuaternionFromAxisAngle(axis, 0.2)
+	b := newQuaternionFromAxisAngle(axis, 1.4)
+
+	for _, s := range []float64{0, 0.25, 0.5, 1} {
+		expected := newQuaternionFromAxisAngle(axis, 0.2+1.2*s)
+		if r := a.slerp(b, s); math.Abs(math.Abs(r.dot(expected))-1) > 1e-12 {
+			t.Errorf("t=%v: expected %v, got %v", s, expected, r)
+		}
+	}
+
+	// The same rotation with opposite sign is interpolated along the short arc.
+	negB := &Quaternion{-b.w, -b.x, -b.y, -b.z}
+	if r := a.slerp(negB, 0.5); math.Abs(math.Abs(r.dot(newQuaternionFromAxisAngle(axis, 0.8)))-1) > 1e-12 {
+		t.Errorf("expected slerp to take the shorter arc, got %v", r)
+	}
+}
+
+func TestTransform3Orientation(t *testing.T) {
+
+	q := newQuaternionFromAxisAngle(Vector3D.NewVector(0, 0, 1), 0.5*math.Pi)
+	transform := NewTransform3WithTranslationAndOrientation(Vector3D.NewVector(1, 2, 3), q)
+
+	p := Vector3D.NewVector(1, 0, 0)
+	world := transform.toWorld(p)
+	if world.DistanceTo(Vector3D.NewVector(1, 3, 3)) > 1e-12 {
+		t.Errorf("expected (1, 3, 3), got %v", world)
+	}
+	if local := transform.toLocal(world); local.DistanceTo(p) > 1e-12 {
+		t.Errorf("expected round trip to %v, got %v", p, local)
+	}
+	if d := transform.toWorldDirection(p); d.DistanceTo(Vector3D.NewVector(0, 1, 0)) > 1e-12 {
+		t.Errorf("expected direction (0, 1, 0), got %v", d)
+	}
+
+	box := transform.toWorldBoundingBox(NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(2, 1, 1)))
+	if box.lowerCorner.DistanceTo(Vector3D.NewVector(0, 2, 3)) > 1e-12 ||
+		box.upperCorner.DistanceTo(Vector3D.NewVector(1, 4, 4)) > 1e-12 {
+		t.Errorf("expected box (0, 2, 3)-(1, 4, 4), got %v-%v", box.lowerCorner, box.upperCorner)
+	}
+
+	// A rotated box reports world space queries.
+	b := NewBox3(NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(2, 1, 1)))
+	b.transform = transform
+	b.isNormalFlipped = false
+	query := Vector3D.NewVector(0.5, 3.8, 3.5)
+	if d := b.signedDistance(query); math.Abs(d+0.2) > 1e-12 {
+		t.Errorf("expected signed distance -0.2, got %v", d)
+	}
+	if n := b.closestNormal(query); n.DistanceTo(Vector3D.NewVector(0, 1, 0)) > 1e-12 {
+		t.Errorf("expected normal (0, 1, 0), got %v", n)
+	}
+
+	sphere := NewSphere3(Vector3D.NewVector(1, 0, 0), 0.5)
+	sphere.transform = transform
+	if d := sphere.signedDistance(Vector3D.NewVector(1, 5, 3)); math.Abs(d-1.5) > 1e-12 {
+		t.Errorf("expected sphere signed distance 1.5, got %v", d)
+	}
+}
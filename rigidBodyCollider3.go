@@ -8,11 +8,25 @@ import (
 // RigidBodyCollider3 implements 3-D rigid body collider. The collider can only take
 // rigid body motion with linear and rotational velocities.
 type RigidBodyCollider3 struct {
-	surface                  Surface3IF
-	linearVelocity           *Vector3D.Vector3D
-	angularVelocity          *Vector3D.Vector3D
-	frictionCoefficient      float64
+	surface         Surface3IF
+	linearVelocity  *Vector3D.Vector3D
+	angularVelocity *Vector3D.Vector3D
+	// Contact material of the surface.
+	material *ColliderMaterial
+	// Materials of parts of the surface, such as the operands of a CSG
+	// surface, looked up by the contact point.
+	surfaceMaterials         []*surfaceMaterial3
 	onUpdateCallbackCollider OnBeginUpdateCallbackCollider
+	// Keyframed poses driving the surface transform. Velocities are derived
+	// from the track when it is set.
+	keyframeTrack *KeyframeTrack3
+	// Contacts resolved since the last update and their accumulated impulse
+	// and angular impulse around the surface translation.
+	isRecordingContacts bool
+	recordedContacts    []*ColliderContact
+	totalImpulse        *Vector3D.Vector3D
+	totalAngularImpulse *Vector3D.Vector3D
+	lastTimeInterval    float64
 }
 
 // ColliderQueryResult is an internal query result structure.
@@ -23,7 +37,7 @@ type ColliderQueryResult struct {
 	velocity *Vector3D.Vector3D
 }
 
-func (c RigidBodyCollider3) NewColliderQueryResult() *ColliderQueryResult {
+func (c *RigidBodyCollider3) NewColliderQueryResult() *ColliderQueryResult {
 	return &ColliderQueryResult{
 		distance: 0,
 		point:    Vector3D.NewVector(0, 0, 0),
@@ -32,21 +46,43 @@ func (c RigidBodyCollider3) NewColliderQueryResult() *ColliderQueryResult {
 	}
 }
 
-// Resolves collision for given point.
-func (c RigidBodyCollider3) resolveCollision(
+// Resolves collision for given point. The path from oldPosition to the new
+// position is swept first so that fast particles stop at the time of impact
+// instead of tunneling through thin surfaces.
+func (c *RigidBodyCollider3) resolveCollision(
+	particleIndex int64,
+	mass float64,
 	radius float64,
 	restitutionCoefficient float64,
+	oldPosition *Vector3D.Vector3D,
 	newPosition **Vector3D.Vector3D,
 	newVelocity **Vector3D.Vector3D,
 ) {
 
+	if impactPoint, ok := c.sweptImpactPoint(oldPosition, *newPosition, radius); ok {
+		(*newPosition).Set(impactPoint)
+	}
+
 	colliderPoint := c.NewColliderQueryResult()
 
 	c.getClosestPoint(c.surface, *newPosition, colliderPoint)
+	c.resolveCollisionWithQuery(particleIndex, mass, colliderPoint, radius, restitutionCoefficient, newPosition, newVelocity)
+}
+
+// resolveCollisionWithQuery resolves collision for given point against an
+// already computed closest point query.
+func (c *RigidBodyCollider3) resolveCollisionWithQuery(
+	particleIndex int64,
+	mass float64,
+	colliderPoint *ColliderQueryResult,
+	radius float64,
+	restitutionCoefficient float64,
+	newPosition **Vector3D.Vector3D,
+	newVelocity **Vector3D.Vector3D,
+) {
 
-	// Check if the new position is penetrating the surface
-	x := c.isPenetrating(colliderPoint, *newPosition, radius)
-	if x {
+	// Check if the new position is penetrating the surface.
+	if c.isPenetrating(colliderPoint, *newPosition, radius) {
 
 		// Target point is the closest non-penetrating position from the
 		// new position.
@@ -55,44 +91,102 @@ func (c RigidBodyCollider3) resolveCollision(
 		targetPoint := colliderPoint.point.Add(rt)
 		colliderVelAtTargetPoint := colliderPoint.velocity
 
-		// Get new candidate relative velocity from the target point.
-
+		// Get new candidate relative velocity from the target point and
+		// apply the contact material to it.
 		relativeVel := (*newVelocity).Substract(colliderVelAtTargetPoint)
-		normalDotRelativeVel := targetNormal.DotProduct(relativeVel)
-		relativeVelN := targetNormal.Multiply(normalDotRelativeVel)
-		relativeVelT := relativeVel.Substract(relativeVelN)
+		var impulse *Vector3D.Vector3D
 
-		// Check if the velocity is facing opposite direction of the surface normal.
+		if targetNormal.DotProduct(relativeVel) < 0.0 {
+			material := c.materialAt(colliderPoint.point, radius)
+			newRelativeVel := material.relativeVelocityAfterImpact(relativeVel, targetNormal, restitutionCoefficient)
+			impulse = newRelativeVel.Substract(relativeVel).Multiply(mass)
+			c.accumulateImpulse(colliderPoint.point, impulse)
 
-		if normalDotRelativeVel < 0.0 {
+			// Reassemble the components.
+			*newVelocity = newRelativeVel.Add(colliderVelAtTargetPoint)
+		}
+		if c.isRecordingContacts {
+			if impulse == nil {
+				impulse = Vector3D.NewVector(0, 0, 0)
+			}
+			c.recordedContacts = append(c.recordedContacts, &ColliderContact{
+				particleIndex:    particleIndex,
+				point:            colliderPoint.point,
+				normal:           targetNormal,
+				relativeVelocity: relativeVel,
+				impulse:          impulse,
+			})
+		}
+		// Geometric fix
+		//*newPosition = (*newPosition).Set(targetPoint)
+		(*newPosition).Set(targetPoint)
+	}
+}
 
-			// Apply restitution coefficient to the surface normal component of the velocity.
+// sweptImpactPoint returns the first point where the segment from oldPosition
+// to newPosition crosses the surface. The segment is marched by the distance
+// to the surface, which never steps over it. Segments starting inside or on
+// the surface are left to the closest point resolution.
+func (c *RigidBodyCollider3) sweptImpactPoint(oldPosition, newPosition *Vector3D.Vector3D, radius float64) (*Vector3D.Vector3D, bool) {
 
-			deltaRelativeVelN := relativeVelN.Multiply(-restitutionCoefficient - 1.0)
-			relativeVelN := relativeVelN.Multiply(-restitutionCoefficient)
+	displacement := newPosition.Substract(oldPosition)
+	length := displacement.Length()
+	if length == 0 || c.surface.isInside(oldPosition) {
+		return nil, false
+	}
 
-			// Apply friction to the tangential component of the velocity From Bridson et
-			// al., Robust Treatment of Collisions, Contact and Friction for Cloth Animation,
-			// 2002. http://graphics.stanford.edu/papers/cloth-sig02/cloth.pdf
+	ray := NewRay3(oldPosition, displacement)
+	t := c.surface.closestDistance(oldPosition)
+	if t <= kSphereTracingEpsilon {
+		return nil, false
+	}
 
-			if relativeVelT.LengthSquared() > 0.0 {
+	for i := 0; i < kMaxSphereTracingSteps; i++ {
+		if t > length {
+			return nil, false
+		}
+		point := ray.pointAt(t)
+		distance := c.surface.closestDistance(point)
+		if distance <= kSphereTracingEpsilon {
+			return point, true
+		}
+		t += distance
+	}
 
-				a := c.frictionCoefficient * deltaRelativeVelN.Length() / relativeVelT.Length()
-				frictionScale := math.Max(1-a, 0)
-				relativeVelT = relativeVelT.Multiply(frictionScale)
+	// At shallow angles the march converges too slowly, so the rest of the
+	// segment is sampled at the particle radius and the first inside sample
+	// is bisected down to the surface.
+	spacing := math.Max(radius, length/kMaxSphereTracingSteps)
+	for t < length {
+		tNext := math.Min(t+spacing, length)
+		if c.surface.isInside(ray.pointAt(tNext)) {
+			return ray.pointAt(c.bisectSurface(ray, t, tNext)), true
+		}
+		t = tNext
+	}
+	return nil, false
+}
 
-			}
-			// Reassemble the components.
-			*newVelocity = relativeVelN.Add(relativeVelT).Add(colliderVelAtTargetPoint)
+// bisectSurface narrows the interval between tOutside and tInside down to
+// the surface crossing and returns the outside end.
+func (c *RigidBodyCollider3) bisectSurface(ray *Ray3, tOutside, tInside float64) float64 {
+
+	for tInside-tOutside > kSphereTracingEpsilon {
+		tMid := 0.5 * (tOutside + tInside)
+		if tMid <= tOutside || tMid >= tInside {
+			break
+		}
+		if c.surface.isInside(ray.pointAt(tMid)) {
+			tInside = tMid
+		} else {
+			tOutside = tMid
 		}
-		// Geometric fix
-		//*newPosition = (*newPosition).Set(targetPoint)
-		(*newPosition).Set(targetPoint)
 	}
+	return tOutside
 }
 
 // Returns true if given point is in the opposite side of the surface.
-func (c RigidBodyCollider3) isPenetrating(colliderPoint *ColliderQueryResult, position *Vector3D.Vector3D, radius float64) bool {
+func (c *RigidBodyCollider3) isPenetrating(colliderPoint *ColliderQueryResult, position *Vector3D.Vector3D, radius float64) bool {
 
 	// If the new candidate position of the particle is inside the volume defined by
 	// the surface OR the new distance to the surface is less than the particle's
@@ -102,7 +196,7 @@ func (c RigidBodyCollider3) isPenetrating(colliderPoint *ColliderQueryResult, po
 }
 
 // Outputs closest point's information.
-func (c RigidBodyCollider3) getClosestPoint(surface Surface3IF, queryPoint *Vector3D.Vector3D, result *ColliderQueryResult) {
+func (c *RigidBodyCollider3) getClosestPoint(surface Surface3IF, queryPoint *Vector3D.Vector3D, result *ColliderQueryResult) {
 
 	result.distance = surface.closestDistance(queryPoint)
 	result.point = surface.closestPoint(queryPoint)
@@ -111,7 +205,7 @@ func (c RigidBodyCollider3) getClosestPoint(surface Surface3IF, queryPoint *Vect
 }
 
 // Returns the velocity of the collider at given point.
-func (c RigidBodyCollider3) velocityAt(point *Vector3D.Vector3D) *Vector3D.Vector3D {
+func (c *RigidBodyCollider3) velocityAt(point *Vector3D.Vector3D) *Vector3D.Vector3D {
 
 	//r := point.Substract(c.surface.transform.translation)
 	r := point.Substract(c.surface.getTransform().translation)
@@ -133,12 +227,209 @@ func NewRigidBodyCollider3(surface Surface3IF) *RigidBodyCollider3 {
 		surface:                  surface,
 		linearVelocity:           Vector3D.NewVector(0, 0, 0),
 		angularVelocity:          Vector3D.NewVector(0, 0, 0),
-		frictionCoefficient:      0,
+		material:                 NewColliderMaterial(),
+		surfaceMaterials:         make([]*surfaceMaterial3, 0),
 		onUpdateCallbackCollider: nil,
+		keyframeTrack:            nil,
+		isRecordingContacts:      false,
+		recordedContacts:         make([]*ColliderContact, 0),
+		totalImpulse:             Vector3D.NewVector(0, 0, 0),
+		totalAngularImpulse:      Vector3D.NewVector(0, 0, 0),
+		lastTimeInterval:         0,
+	}
+}
+
+// setFrictionCoefficient sets both the static and the dynamic friction coefficient.
+func (c *RigidBodyCollider3) setFrictionCoefficient(frictionCoefficient float64) {
+
+	c.material.setStaticFrictionCoefficient(frictionCoefficient)
+	c.material.setDynamicFrictionCoefficient(frictionCoefficient)
+}
+
+// setStaticFrictionCoefficient sets the friction coefficient below which contacts stick.
+func (c *RigidBodyCollider3) setStaticFrictionCoefficient(frictionCoefficient float64) {
+
+	c.material.setStaticFrictionCoefficient(frictionCoefficient)
+}
+
+// setDynamicFrictionCoefficient sets the friction coefficient of sliding contacts.
+func (c *RigidBodyCollider3) setDynamicFrictionCoefficient(frictionCoefficient float64) {
+
+	c.material.setDynamicFrictionCoefficient(frictionCoefficient)
+}
+
+// setRestitutionCoefficient overrides the solver restitution for this collider.
+func (c *RigidBodyCollider3) setRestitutionCoefficient(restitutionCoefficient float64) {
+
+	c.material.setNormalRestitutionCoefficient(restitutionCoefficient)
+}
+
+// setTangentialRestitutionCoefficient sets the fraction of the tangential
+// velocity kept after an impact.
+func (c *RigidBodyCollider3) setTangentialRestitutionCoefficient(restitutionCoefficient float64) {
+
+	c.material.setTangentialRestitutionCoefficient(restitutionCoefficient)
+}
+
+// setMaterial sets the contact material of the whole surface.
+func (c *RigidBodyCollider3) setMaterial(material *ColliderMaterial) {
+
+	c.material = material
+}
+
+// setSurfaceMaterial assigns a material to contacts on the given part of the
+// collider surface, given in the collider surface frame.
+func (c *RigidBodyCollider3) setSurfaceMaterial(surface ImplicitSurface3, material *ColliderMaterial) {
+
+	for _, surfaceMaterial := range c.surfaceMaterials {
+		if surfaceMaterial.surface == surface {
+			surfaceMaterial.material = material
+			return
+		}
+	}
+	c.surfaceMaterials = append(c.surfaceMaterials, &surfaceMaterial3{surface: surface, material: material})
+}
+
+// materialAt returns the material of the registered surface part closest to
+// the contact point, or the collider material if no part is within tolerance.
+func (c *RigidBodyCollider3) materialAt(point *Vector3D.Vector3D, tolerance float64) *ColliderMaterial {
+
+	if len(c.surfaceMaterials) == 0 {
+		return c.material
+	}
+
+	pointLocal := c.surface.getTransform().toLocal(point)
+	material := c.material
+	minDistance := tolerance
+	for _, surfaceMaterial := range c.surfaceMaterials {
+		if d := math.Abs(surfaceMaterial.surface.signedDistance(pointLocal)); d <= minDistance {
+			material = surfaceMaterial.material
+			minDistance = d
+		}
+	}
+	return material
+}
+
+// setOnBeginUpdateCallback sets the callback invoked at the beginning of each update.
+func (c *RigidBodyCollider3) setOnBeginUpdateCallback(callback OnBeginUpdateCallbackCollider) {
+
+	c.onUpdateCallbackCollider = callback
+}
+
+// setLinearVelocity sets the linear velocity of the rigid body.
+func (c *RigidBodyCollider3) setLinearVelocity(linearVelocity *Vector3D.Vector3D) {
+
+	c.linearVelocity = Vector3D.NewVector(linearVelocity.X, linearVelocity.Y, linearVelocity.Z)
+}
+
+// setAngularVelocity sets the angular velocity in radians per second around the
+// surface translation.
+func (c *RigidBodyCollider3) setAngularVelocity(angularVelocity *Vector3D.Vector3D) {
+
+	c.angularVelocity = Vector3D.NewVector(angularVelocity.X, angularVelocity.Y, angularVelocity.Z)
+}
+
+// setKeyframeTrack sets the keyframes driving the surface transform. A nil
+// track returns the collider to velocity integration.
+func (c *RigidBodyCollider3) setKeyframeTrack(track *KeyframeTrack3) {
+
+	c.keyframeTrack = track
+}
+
+// update invokes the update callback and then moves the surface to the end of
+// the time interval, either along the keyframe track or by integrating the
+// linear and angular velocities.
+func (c *RigidBodyCollider3) update(currentTime, timeIntervalInSeconds float64) {
+
+	c.clearContacts()
+	c.lastTimeInterval = timeIntervalInSeconds
+
+	if c.onUpdateCallbackCollider != nil {
+		c.onUpdateCallbackCollider(c, currentTime, timeIntervalInSeconds)
 	}
+
+	transform := c.surface.getTransform()
+
+	if c.keyframeTrack != nil && c.keyframeTrack.numberOfKeyframes() > 0 {
+		translation, orientation := c.keyframeTrack.sample(currentTime + timeIntervalInSeconds)
+
+		// Derive the velocities from the motion over the interval so that
+		// colliding particles pick up the surface velocity.
+		if timeIntervalInSeconds > 0 {
+			c.linearVelocity = translation.Substract(transform.translation).Divide(timeIntervalInSeconds)
+
+			delta := orientation.mul(transform.orientation.inverse())
+			if delta.w < 0 {
+				delta = &Quaternion{-delta.w, -delta.x, -delta.y, -delta.z}
+			}
+			c.angularVelocity = delta.axis().Multiply(delta.angle() / timeIntervalInSeconds)
+		}
+
+		transform.setTranslation(translation)
+		transform.setOrientation(orientation)
+		return
+	}
+
+	if c.linearVelocity.LengthSquared() > 0 {
+		transform.setTranslation(transform.translation.Add(c.linearVelocity.Multiply(timeIntervalInSeconds)))
+	}
+
+	if omega := c.angularVelocity.Length(); omega > 0 {
+		rotation := newQuaternionFromAxisAngle(c.angularVelocity, omega*timeIntervalInSeconds)
+		transform.setOrientation(rotation.mul(transform.orientation))
+	}
+}
+
+// setIsRecordingContacts enables or disables keeping the individual contacts.
+// Recording is off by default; the total force and torque are accumulated
+// either way.
+func (c *RigidBodyCollider3) setIsRecordingContacts(isRecording bool) {
+
+	c.isRecordingContacts = isRecording
+}
+
+// contacts returns the contacts resolved since the last update.
+func (c *RigidBodyCollider3) contacts() []*ColliderContact {
+
+	return c.recordedContacts
+}
+
+// totalForce returns the force the particles exerted on the collider during
+// the last sub-timestep.
+func (c *RigidBodyCollider3) totalForce() *Vector3D.Vector3D {
+
+	if c.lastTimeInterval <= 0 {
+		return Vector3D.NewVector(0, 0, 0)
+	}
+	return c.totalImpulse.Multiply(-1 / c.lastTimeInterval)
+}
+
+// totalTorque returns the torque around the surface translation the particles
+// exerted on the collider during the last sub-timestep.
+func (c *RigidBodyCollider3) totalTorque() *Vector3D.Vector3D {
+
+	if c.lastTimeInterval <= 0 {
+		return Vector3D.NewVector(0, 0, 0)
+	}
+	return c.totalAngularImpulse.Multiply(-1 / c.lastTimeInterval)
+}
+
+// clearContacts discards the contacts and accumulated impulses.
+func (c *RigidBodyCollider3) clearContacts() {
+
+	c.recordedContacts = c.recordedContacts[:0]
+	c.totalImpulse = Vector3D.NewVector(0, 0, 0)
+	c.totalAngularImpulse = Vector3D.NewVector(0, 0, 0)
 }
 
-func (c *RigidBodyCollider3) update(seconds float64) {
+// accumulateImpulse adds an impulse applied at point to the total impulse and
+// to the angular impulse around the surface translation.
+func (c *RigidBodyCollider3) accumulateImpulse(point, impulse *Vector3D.Vector3D) {
 
-	// do nothing?
+	center := c.surface.getTransform().translation
+	rx, ry, rz := point.X-center.X, point.Y-center.Y, point.Z-center.Z
+	c.totalImpulse.AddInPlace(impulse)
+	c.totalAngularImpulse.X += ry*impulse.Z - rz*impulse.Y
+	c.totalAngularImpulse.Y += rz*impulse.X - rx*impulse.Z
+	c.totalAngularImpulse.Z += rx*impulse.Y - ry*impulse.X
 }
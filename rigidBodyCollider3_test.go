@@ -0,0 +1,413 @@
+package main
+
+import (
+	"jimmykiang/fluidengine/Vector3D"
+	"math"
+	"testing"
+)
+
+func TestRigidBodyCollider3UpdateVelocity(t *testing.T) {
+
+	sphere := NewSphere3(Vector3D.NewVector(1, 0, 0), 0.5)
+	collider := NewRigidBodyCollider3(sphere)
+
+	calls := 0
+	collider.setOnBeginUpdateCallback(func(c *RigidBodyCollider3, currentTime, timeInterval float64) {
+		calls++
+		if currentTime != 0.5 || timeInterval != 0.25 {
+			t.Errorf("expected callback at 0.5 + 0.25, got %v + %v", currentTime, timeInterval)
+		}
+		c.setLinearVelocity(Vector3D.NewVector(0, 0, 4))
+		c.setAngularVelocity(Vector3D.NewVector(0, 2*math.Pi, 0))
+	})
+	collider.update(0.5, 0.25)
+
+	if calls != 1 {
+		t.Fatalf("expected one callback, got %d", calls)
+	}
+
+	// A quarter turn around y maps the local x axis to -z, then the translation adds 1 to z.
+	transform := sphere.getTransform()
+	if transform.translation.DistanceTo(Vector3D.NewVector(0, 0, 1)) > 1e-12 {
+		t.Errorf("expected translation (0, 0, 1), got %v", transform.translation)
+	}
+	if p := sphere.closestPoint(Vector3D.NewVector(0, 0, -5)); p.DistanceTo(Vector3D.NewVector(0, 0, -0.5)) > 1e-12 {
+		t.Errorf("expected the moved sphere surface at (0, 0, -0.5), got %v", p)
+	}
+
+	// The surface velocity adds the rotation around the translated pivot.
+	if v := collider.velocityAt(Vector3D.NewVector(0, 0, 1)); v.DistanceTo(Vector3D.NewVector(0, 0, 4)) > 1e-12 {
+		t.Errorf("expected velocity (0, 0, 4) at the pivot, got %v", v)
+	}
+	if v := collider.velocityAt(Vector3D.NewVector(0, 0, 0)); v.DistanceTo(Vector3D.NewVector(-2*math.Pi, 0, 4)) > 1e-12 {
+		t.Errorf("expected velocity (-2 pi, 0, 4) below the pivot, got %v", v)
+	}
+}
+
+func TestRigidBodyCollider3Keyframes(t *testing.T) {
+
+	track := NewKeyframeTrack3()
+	track.addKeyframe(1, Vector3D.NewVector(2, 0, 0), newQuaternionFromAxisAngle(Vector3D.NewVector(0, 0, 1), 0.5*math.Pi))
+	track.addKeyframe(0, Vector3D.NewVector(0, 0, 0), newQuaternion())
+
+	translation, orientation := track.sample(0.5)
+	if translation.DistanceTo(Vector3D.NewVector(1, 0, 0)) > 1e-12 {
+		t.Errorf("expected interpolated translation (1, 0, 0), got %v", translation)
+	}
+	if math.Abs(orientation.angle()-0.25*math.Pi) > 1e-12 {
+		t.Errorf("expected interpolated angle pi/4, got %v", orientation.angle())
+	}
+	if translation, _ = track.sample(3); translation.DistanceTo(Vector3D.NewVector(2, 0, 0)) > 1e-12 {
+		t.Errorf("expected the last pose to be held, got %v", translation)
+	}
+
+	box := NewBox3(NewBoundingBox3D(Vector3D.NewVector(-1, -1, -1), Vector3D.NewVector(1, 1, 1)))
+	collider := NewRigidBodyCollider3(box)
+	collider.setKeyframeTrack(track)
+	collider.update(0, 0.5)
+
+	if box.getTransform().translation.DistanceTo(Vector3D.NewVector(1, 0, 0)) > 1e-12 {
+		t.Errorf("expected the box at (1, 0, 0), got %v", box.getTransform().translation)
+	}
+	if collider.linearVelocity.DistanceTo(Vector3D.NewVector(2, 0, 0)) > 1e-12 {
+		t.Errorf("expected derived linear velocity (2, 0, 0), got %v", collider.linearVelocity)
+	}
+	if collider.angularVelocity.DistanceTo(Vector3D.NewVector(0, 0, 0.5*math.Pi)) > 1e-12 {
+		t.Errorf("expected derived angular velocity (0, 0, pi/2), got %v", collider.angularVelocity)
+	}
+}
+
+func TestRigidBodyCollider2Update(t *testing.T) {
+
+	box := NewBox2(NewBoundingBox2D(Vector3D.NewVector(-1, -1, 0), Vector3D.NewVector(1, 1, 0)))
+	collider := NewRigidBodyCollider2(box)
+	collider.setLinearVelocity(Vector3D.NewVector(1, 2, 0))
+	collider.setAngularVelocity(math.Pi)
+	collider.update(0, 0.5)
+
+	transform := box.getTransform()
+	if transform.translation.DistanceTo(Vector3D.NewVector(0.5, 1, 0)) > 1e-12 {
+		t.Errorf("expected translation (0.5, 1, 0), got %v", transform.translation)
+	}
+	if d := transform.toWorldDirection(Vector3D.NewVector(1, 0, 0)); d.DistanceTo(Vector3D.NewVector(0, 1, 0)) > 1e-12 {
+		t.Errorf("expected a quarter turn, got %v", d)
+	}
+
+	track := NewKeyframeTrack2()
+	track.addKeyframe(0, Vector3D.NewVector(0, 0, 0), 0)
+	track.addKeyframe(2, Vector3D.NewVector(4, 0, 0), 1)
+	collider.setKeyframeTrack(track)
+	collider.update(0.5, 0.5)
+
+	if transform.translation.DistanceTo(Vector3D.NewVector(2, 0, 0)) > 1e-12 || math.Abs(transform.orientation-0.5) > 1e-12 {
+		t.Errorf("expected keyframed pose (2, 0, 0) at 0.5 rad, got %v at %v", transform.translation, transform.orientation)
+	}
+}
+
+func TestColliderSet3ResolveCollision(t *testing.T) {
+
+	container := NewRigidBodyCollider3(NewBox3(NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(4, 4, 4))))
+	obstacle := NewRigidBodyCollider3(NewSphere3(Vector3D.NewVector(2, 2, 2), 1))
+	obstacle.setRestitutionCoefficient(0.5)
+	set := NewColliderSet3(container)
+	set.addCollider(obstacle)
+
+	if set.numberOfColliders() != 2 {
+		t.Fatalf("expected two colliders, got %d", set.numberOfColliders())
+	}
+
+	// Inside the obstacle, far from the container walls.
+	position := Vector3D.NewVector(2, 2.9, 2)
+	velocity := Vector3D.NewVector(0, -1, 0)
+	set.resolveCollision(0, 1, 0.05, 0, position, &position, &velocity)
+	if position.DistanceTo(Vector3D.NewVector(2, 3.05, 2)) > 1e-12 {
+		t.Errorf("expected the particle pushed onto the obstacle, got %v", position)
+	}
+	if velocity.DistanceTo(Vector3D.NewVector(0, 0.5, 0)) > 1e-12 {
+		t.Errorf("expected the obstacle restitution to apply, got %v", velocity)
+	}
+
+	// Close to the container ceiling.
+	position = Vector3D.NewVector(2, 3.9, 2)
+	velocity = Vector3D.NewVector(0, 1, 0)
+	set.resolveCollision(0, 1, 0.2, 0, position, &position, &velocity)
+	if position.DistanceTo(Vector3D.NewVector(2, 3.8, 2)) > 1e-12 {
+		t.Errorf("expected the particle pushed off the ceiling, got %v", position)
+	}
+	if velocity.DistanceTo(Vector3D.NewVector(0, 0, 0)) > 1e-12 {
+		t.Errorf("expected the solver restitution to apply, got %v", velocity)
+	}
+
+	// Moving obstacles are updated with the set.
+	obstacle.setLinearVelocity(Vector3D.NewVector(1, 0, 0))
+	set.update(0, 0.5)
+	if p := obstacle.surface.getTransform().translation; p.DistanceTo(Vector3D.NewVector(0.5, 0, 0)) > 1e-12 {
+		t.Errorf("expected the obstacle to move, got %v", p)
+	}
+}
+
+func TestColliderSet2ResolveCollision(t *testing.T) {
+
+	leftBox := NewBox2(NewBoundingBox2D(Vector3D.NewVector(-1, -1, 0), Vector3D.NewVector(1, 1, 0)))
+	leftBox.isNormalFlipped = false
+	rightBox := NewBox2(NewBoundingBox2D(Vector3D.NewVector(1.15, -1, 0), Vector3D.NewVector(3, 1, 0)))
+	rightBox.isNormalFlipped = false
+	set := NewColliderSet2(NewRigidBodyCollider2(leftBox), NewRigidBodyCollider2(rightBox))
+
+	// Within the radius of both, but closer to the left box.
+	position := Vector3D.NewVector(1.05, 0, 0)
+	velocity := Vector3D.NewVector(-1, 0, 0)
+	set.resolveCollision(0, 1, 0.2, 0, position, &position, &velocity)
+	if position.DistanceTo(Vector3D.NewVector(1.2, 0, 0)) > 1e-12 {
+		t.Errorf("expected the particle pushed off the left box, got %v", position)
+	}
+	if velocity.Length() > 1e-12 {
+		t.Errorf("expected the normal velocity removed, got %v", velocity)
+	}
+}
+
+func TestColliderSet3ResolveCollisionInside(t *testing.T) {
+
+	obstacle := NewBox3(NewBoundingBox3D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(0.6, 0.6, 0.6)))
+	obstacle.isNormalFlipped = false
+	wall := NewBox3(NewBoundingBox3D(Vector3D.NewVector(0.31, -1, -1), Vector3D.NewVector(1, 1, 1)))
+	wall.isNormalFlipped = false
+	set := NewColliderSet3(NewRigidBodyCollider3(wall), NewRigidBodyCollider3(obstacle))
+
+	// Deep inside the obstacle and just outside the wall: the obstacle wins
+	// even though the wall is closer.
+	position := Vector3D.NewVector(0.3, 0.25, 0.3)
+	velocity := Vector3D.NewVector(0, 0, 0)
+	set.resolveCollision(0, 1, 0.05, 0, position, &position, &velocity)
+	if position.DistanceTo(Vector3D.NewVector(0.3, -0.05, 0.3)) > 1e-12 {
+		t.Errorf("expected the particle pushed out of the obstacle, got %v", position)
+	}
+}
+
+func TestColliderSet2ResolveCollisionInside(t *testing.T) {
+
+	obstacle := NewBox2(NewBoundingBox2D(Vector3D.NewVector(0, 0, 0), Vector3D.NewVector(0.6, 0.6, 0)))
+	obstacle.isNormalFlipped = false
+	wall := NewBox2(NewBoundingBox2D(Vector3D.NewVector(0.31, -1, 0), Vector3D.NewVector(1, 1, 0)))
+	wall.isNormalFlipped = false
+	set := NewColliderSet2(NewRigidBodyCollider2(wall), NewRigidBodyCollider2(obstacle))
+
+	position := Vector3D.NewVector(0.3, 0.25, 0)
+	velocity := Vector3D.NewVector(0, 0, 0)
+	set.resolveCollision(0, 1, 0.05, 0, position, &position, &velocity)
+	if position.DistanceTo(Vector3D.NewVector(0.3, -0.05, 0)) > 1e-12 {
+		t.Errorf("expected the particle pushed out of the obstacle, got %v", position)
+	}
+}
+
+func TestRigidBodyCollider3SweptCollision(t *testing.T) {
+
+	wall := NewBox3(NewBoundingBox3D(Vector3D.NewVector(-1, -0.01, -1), Vector3D.NewVector(1, 0.01, 1)))
+	wall.isNormalFlipped = false
+	collider := NewRigidBodyCollider3(wall)
+
+	// The end position is clear of the thin wall, but the path crosses it.
+	oldPosition := Vector3D.NewVector(0.2, 1, 0)
+	newPosition := Vector3D.NewVector(0.2, -1, 0)
+	velocity := Vector3D.NewVector(0, -100, 0)
+	collider.resolveCollision(0, 1, 0.05, 0.5, oldPosition, &newPosition, &velocity)
+
+	if newPosition.DistanceTo(Vector3D.NewVector(0.2, 0.06, 0)) > 1e-12 {
+		t.Errorf("expected the particle stopped above the wall, got %v", newPosition)
+	}
+	if velocity.DistanceTo(Vector3D.NewVector(0, 50, 0)) > 1e-12 {
+		t.Errorf("expected the bounced velocity (0, 50, 0), got %v", velocity)
+	}
+
+	// Paths that do not reach the wall are unchanged.
+	newPosition = Vector3D.NewVector(0.2, 0.5, 0)
+	velocity = Vector3D.NewVector(0, -1, 0)
+	collider.resolveCollision(0, 1, 0.05, 0.5, oldPosition, &newPosition, &velocity)
+	if newPosition.DistanceTo(Vector3D.NewVector(0.2, 0.5, 0)) > 1e-12 || velocity.DistanceTo(Vector3D.NewVector(0, -1, 0)) > 1e-12 {
+		t.Errorf("expected no collision, got %v with %v", newPosition, velocity)
+	}
+}
+
+func TestRigidBodyCollider2SweptCollision(t *testing.T) {
+
+	wall := NewBox2(NewBoundingBox2D(Vector3D.NewVector(-0.01, -1, 0), Vector3D.NewVector(0.01, 1, 0)))
+	wall.isNormalFlipped = false
+	collider := NewRigidBodyCollider2(wall)
+	set := NewColliderSet2(collider)
+
+	oldPosition := Vector3D.NewVector(-1, 0.3, 0)
+	newPosition := Vector3D.NewVector(1, 0.5, 0)
+	velocity := Vector3D.NewVector(100, 10, 0)
+	set.resolveCollision(0, 1, 0.05, 0, oldPosition, &newPosition, &velocity)
+
+	if math.Abs(newPosition.X+0.06) > 1e-9 || math.Abs(newPosition.Y-0.399) > 1e-9 {
+		t.Errorf("expected the particle stopped left of the wall, got %v", newPosition)
+	}
+	if velocity.DistanceTo(Vector3D.NewVector(0, 10, 0)) > 1e-9 {
+		t.Errorf("expected the normal velocity removed, got %v", velocity)
+	}
+}
+
+func TestRigidBodyCollider3Material(t *testing.T) {
+
+	resolve := func(collider *RigidBodyCollider3, velocity *Vector3D.Vector3D) *Vector3D.Vector3D {
+		position := Vector3D.NewVector(0, 0.05, 0)
+		collider.resolveCollision(0, 1, 0.1, 0, Vector3D.NewVector(0, 0.05, 0), &position, &velocity)
+		return velocity
+	}
+	floor := func() *RigidBodyCollider3 {
+		return NewRigidBodyCollider3(NewPlane3D(Vector3D.NewVector(0, 1, 0), Vector3D.NewVector(0, 0, 0)))
+	}
+
+	// The normal impulse is 2, so a tangential speed of 1 sticks below a
+	// static coefficient of 0.5 and slides above it.
+	collider := floor()
+	collider.setStaticFrictionCoefficient(0.6)
+	if v := resolve(collider, Vector3D.NewVector(1, -2, 0)); v.Length() > 1e-12 {
+		t.Errorf("expected static friction to stop the particle, got %v", v)
+	}
+
+	collider = floor()
+	collider.setStaticFrictionCoefficient(0.4)
+	collider.setDynamicFrictionCoefficient(0.25)
+	if v := resolve(collider, Vector3D.NewVector(1, -2, 0)); v.DistanceTo(Vector3D.NewVector(0.5, 0, 0)) > 1e-12 {
+		t.Errorf("expected dynamic friction to halve the sliding speed, got %v", v)
+	}
+
+	collider.setTangentialRestitutionCoefficient(0.5)
+	if v := resolve(collider, Vector3D.NewVector(1, -2, 0)); v.DistanceTo(Vector3D.NewVector(0.25, 0, 0)) > 1e-12 {
+		t.Errorf("expected tangential restitution to scale the sliding speed, got %v", v)
+	}
+
+	collider = floor()
+	collider.setRestitutionCoefficient(0.5)
+	if v := resolve(collider, Vector3D.NewVector(1, -2, 0)); v.DistanceTo(Vector3D.NewVector(1, 1, 0)) > 1e-12 {
+		t.Errorf("expected the normal restitution to override the solver, got %v", v)
+	}
+
+	// A sticky floor and a frictionless wall combined in one surface.
+	floorPlane := NewPlane3D(Vector3D.NewVector(0, 1, 0), Vector3D.NewVector(0, 0, 0))
+	wallBox := NewBox3(NewBoundingBox3D(Vector3D.NewVector(1, -1, -1), Vector3D.NewVector(2, 3, 1)))
+	wallBox.isNormalFlipped = false
+	collider = NewRigidBodyCollider3(NewCsgUnion3(floorPlane, wallBox))
+	sticky := NewColliderMaterial()
+	sticky.setStaticFrictionCoefficient(10)
+	collider.setSurfaceMaterial(floorPlane, sticky)
+
+	if v := resolve(collider, Vector3D.NewVector(1, -2, 0)); v.Length() > 1e-12 {
+		t.Errorf("expected the floor material to stick, got %v", v)
+	}
+
+	position := Vector3D.NewVector(0.95, 1, 0)
+	velocity := Vector3D.NewVector(1, -1, 0)
+	collider.resolveCollision(0, 1, 0.1, 0, Vector3D.NewVector(0.95, 1, 0), &position, &velocity)
+	if velocity.DistanceTo(Vector3D.NewVector(0, -1, 0)) > 1e-9 {
+		t.Errorf("expected the wall to keep the tangential velocity, got %v", velocity)
+	}
+}
+
+func TestRigidBodyCollider3Contacts(t *testing.T) {
+
+	collider := NewRigidBodyCollider3(NewPlane3D(Vector3D.NewVector(0, 1, 0), Vector3D.NewVector(0, 0, 0)))
+	collider.setIsRecordingContacts(true)
+	collider.update(0, 0.5)
+
+	position := Vector3D.NewVector(1, 0.05, 0)
+	velocity := Vector3D.NewVector(0, -3, 0)
+	collider.resolveCollision(7, 2, 0.1, 0, Vector3D.NewVector(1, 0.05, 0), &position, &velocity)
+
+	contacts := collider.contacts()
+	if len(contacts) != 1 {
+		t.Fatalf("expected one contact, got %d", len(contacts))
+	}
+	contact := contacts[0]
+	if contact.particleIndex != 7 || contact.point.DistanceTo(Vector3D.NewVector(1, 0, 0)) > 1e-12 {
+		t.Errorf("expected particle 7 touching at (1, 0, 0), got %d at %v", contact.particleIndex, contact.point)
+	}
+	if contact.relativeVelocity.DistanceTo(Vector3D.NewVector(0, -3, 0)) > 1e-12 {
+		t.Errorf("expected the incoming relative velocity, got %v", contact.relativeVelocity)
+	}
+	if contact.impulse.DistanceTo(Vector3D.NewVector(0, 6, 0)) > 1e-12 {
+		t.Errorf("expected an impulse of mass times velocity change, got %v", contact.impulse)
+	}
+	if f := collider.totalForce(); f.DistanceTo(Vector3D.NewVector(0, -12, 0)) > 1e-12 {
+		t.Errorf("expected the particle to push the floor down, got %v", f)
+	}
+	if torque := collider.totalTorque(); torque.DistanceTo(Vector3D.NewVector(0, 0, -12)) > 1e-12 {
+		t.Errorf("expected a clockwise torque around the origin, got %v", torque)
+	}
+
+	collider.update(0.5, 0.5)
+	if len(collider.contacts()) != 0 || collider.totalForce().Length() != 0 {
+		t.Errorf("expected contacts cleared by the next update")
+	}
+
+	floor := NewBox2(NewBoundingBox2D(Vector3D.NewVector(-2, -1, 0), Vector3D.NewVector(2, 0, 0)))
+	floor.isNormalFlipped = false
+	collider2 := NewRigidBodyCollider2(floor)
+	collider2.update(0, 0.5)
+
+	position = Vector3D.NewVector(1, 0.05, 0)
+	velocity = Vector3D.NewVector(0, -3, 0)
+	collider2.resolveCollision(0, 2, 0.1, 0, Vector3D.NewVector(1, 0.05, 0), &position, &velocity)
+
+	if len(collider2.contacts()) != 0 {
+		t.Errorf("expected no contacts kept while recording is off by default")
+	}
+	if f := collider2.totalForce(); f.DistanceTo(Vector3D.NewVector(0, -12, 0)) > 1e-12 {
+		t.Errorf("expected the 2-D force accumulated, got %v", f)
+	}
+	if torque := collider2.totalTorque(); math.Abs(torque+12) > 1e-12 {
+		t.Errorf("expected a 2-D torque of -12, got %v", torque)
+	}
+}
+
+func TestRigidBodyCollider3SweptCollisionShallowAngle(t *testing.T) {
+
+	slab := NewBox3(NewBoundingBox3D(Vector3D.NewVector(-20, -0.005, -20), Vector3D.NewVector(20, 0.005, 20)))
+	slab.isNormalFlipped = false
+	wall := NewCsgUnion3(slab, NewSphere3(Vector3D.NewVector(0, 5, 0), 1))
+	collider := NewRigidBodyCollider3(wall)
+
+	// The path crosses the thin implicit wall at 2 degrees, too shallow for
+	// the march alone to reach it within its step limit. The sphere keeps the
+	// bounding box from clipping the path onto the wall.
+	angle := 2 * math.Pi / 180
+	direction := Vector3D.NewVector(math.Cos(angle), -math.Sin(angle), 0)
+	oldPosition := Vector3D.NewVector(-8, 0.3, 0.5)
+	newPosition := oldPosition.Add(direction.Multiply(0.6 / math.Sin(angle)))
+	velocity := direction.Multiply(100)
+	collider.resolveCollision(0, 1, 0.05, 0, oldPosition, &newPosition, &velocity)
+
+	expectedX := -8 + 0.295/math.Tan(angle)
+	if math.Abs(newPosition.X-expectedX) > 1e-6 || math.Abs(newPosition.Y-0.055) > 1e-6 || math.Abs(newPosition.Z-0.5) > 1e-6 {
+		t.Errorf("expected the particle stopped above the wall at (%v, 0.055, 0.5), got %v", expectedX, newPosition)
+	}
+	if velocity.DistanceTo(Vector3D.NewVector(100*math.Cos(angle), 0, 0)) > 1e-6 {
+		t.Errorf("expected the normal velocity removed, got %v", velocity)
+	}
+}
+
+func TestRigidBodyCollider2SweptCollisionShallowAngle(t *testing.T) {
+
+	wall := NewBox2(NewBoundingBox2D(Vector3D.NewVector(-20, -0.005, 0), Vector3D.NewVector(20, 0.005, 0)))
+	wall.isNormalFlipped = false
+	collider := NewRigidBodyCollider2(wall)
+
+	// The path crosses the thin wall at 2 degrees, too shallow for the march
+	// alone to reach it within its step limit.
+	angle := 2 * math.Pi / 180
+	direction := Vector3D.NewVector(math.Cos(angle), -math.Sin(angle), 0)
+	oldPosition := Vector3D.NewVector(-8, 0.3, 0)
+	newPosition := oldPosition.Add(direction.Multiply(0.6 / math.Sin(angle)))
+	velocity := direction.Multiply(100)
+	collider.resolveCollision(0, 1, 0.05, 0, oldPosition, &newPosition, &velocity)
+
+	expectedX := -8 + 0.295/math.Tan(angle)
+	if math.Abs(newPosition.X-expectedX) > 1e-6 || math.Abs(newPosition.Y-0.055) > 1e-9 {
+		t.Errorf("expected the particle stopped above the wall at (%v, 0.055), got %v", expectedX, newPosition)
+	}
+	if velocity.DistanceTo(Vector3D.NewVector(100*math.Cos(angle), 0, 0)) > 1e-9 {
+		t.Errorf("expected the normal velocity removed, got %v", velocity)
+	}
+}
@@ -6,39 +6,83 @@ import "jimmykiang/fluidengine/Vector3D"
 type Transform3 struct {
 	translation            *Vector3D.Vector3D
 	orientation            *Quaternion
-	orientationMat3        Matrix
-	inverseOrientationMat3 Matrix
+	orientationMat3        Matrix3x3
+	inverseOrientationMat3 Matrix3x3
 }
 
 // Transforms a point in world coordinate to the local frame.
 func (t Transform3) toLocal(pointInWorld *Vector3D.Vector3D) *Vector3D.Vector3D {
 
 	a := pointInWorld.Substract(t.translation)
-	return t.inverseOrientationMat3.MultiplyMatrixByTuple(a)
+	return t.inverseOrientationMat3.MulVector(a)
 }
 
 // Transforms a point in local space to the world coordinate.
 func (t Transform3) toWorld(pointInLocal *Vector3D.Vector3D) *Vector3D.Vector3D {
 
-	a := t.inverseOrientationMat3.MultiplyMatrixByTuple(pointInLocal)
+	a := t.orientationMat3.MulVector(pointInLocal)
 	return a.Add(t.translation)
 }
 
 // Transforms a direction in local space to the world coordinate.
 func (t Transform3) toWorldDirection(dirInLocal *Vector3D.Vector3D) *Vector3D.Vector3D {
 
-	return t.orientationMat3.MultiplyMatrixByTuple(dirInLocal)
+	return t.orientationMat3.MulVector(dirInLocal)
+}
+
+// Transforms a direction in world coordinate to the local frame.
+func (t Transform3) toLocalDirection(dirInWorld *Vector3D.Vector3D) *Vector3D.Vector3D {
+
+	return t.inverseOrientationMat3.MulVector(dirInWorld)
+}
+
+// Transforms a ray in world coordinate to the local frame.
+func (t Transform3) toLocalRay(rayInWorld *Ray3) *Ray3 {
+
+	return NewRay3(t.toLocal(rayInWorld.origin), t.toLocalDirection(rayInWorld.direction))
 }
 
 func NewTransform3() *Transform3 {
 	return &Transform3{
 		translation:            Vector3D.NewVector(0, 0, 0),
 		orientation:            newQuaternion(),
-		orientationMat3:        New3x3IdentityMatrix(),
-		inverseOrientationMat3: New3x3IdentityMatrix(),
+		orientationMat3:        NewMatrix3x3Identity(),
+		inverseOrientationMat3: NewMatrix3x3Identity(),
 	}
 }
 
+// NewTransform3WithTranslationAndOrientation creates a transform with the given
+// translation and orientation.
+func NewTransform3WithTranslationAndOrientation(translation *Vector3D.Vector3D, orientation *Quaternion) *Transform3 {
+
+	t := NewTransform3()
+	t.setTranslation(translation)
+	t.setOrientation(orientation)
+	return t
+}
+
+// setTranslation sets the translation.
+func (t *Transform3) setTranslation(translation *Vector3D.Vector3D) {
+
+	t.translation = Vector3D.NewVector(translation.X, translation.Y, translation.Z)
+}
+
+// setOrientation sets the orientation and updates the cached rotation matrices.
+func (t *Transform3) setOrientation(orientation *Quaternion) {
+
+	t.orientation = orientation.normalized()
+	t.orientationMat3 = t.orientation.toMatrix()
+
+	// The inverse of a rotation matrix is its transpose.
+	t.inverseOrientationMat3 = t.orientationMat3.Transpose()
+}
+
+// toMatrix returns the local-to-world transform as a 4x4 affine matrix.
+func (t *Transform3) toMatrix() Matrix4x4 {
+
+	return NewMatrix4x4FromRotationAndTranslation(t.orientationMat3, t.translation)
+}
+
 // toWorld transforms a bounding box in local space to the world coordinate.
 func (t *Transform3) toWorldBoundingBox(bboxInLocal *BoundingBox3D) *BoundingBox3D {
 
@@ -47,11 +91,8 @@ func (t *Transform3) toWorldBoundingBox(bboxInLocal *BoundingBox3D) *BoundingBox
 	for i := 0; i < 8; i++ {
 		cornerInWorld := t.toWorld(bboxInLocal.corner(i))
 
-		a := bboxInWorld.lowerCorner.Min(cornerInWorld)
-		bboxInWorld.lowerCorner = Vector3D.NewVector(a.X, a.Y, 0)
-
-		b := bboxInWorld.upperCorner.Max(cornerInWorld)
-		bboxInWorld.upperCorner = Vector3D.NewVector(b.X, b.Y, 0)
+		bboxInWorld.lowerCorner = bboxInWorld.lowerCorner.Min(cornerInWorld)
+		bboxInWorld.upperCorner = bboxInWorld.upperCorner.Max(cornerInWorld)
 	}
 
 	return bboxInWorld